@@ -1,10 +1,13 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/10gen/realm-cli/auth"
 	"github.com/10gen/realm-cli/user"
@@ -30,10 +33,73 @@ type Client interface {
 type RequestOptions struct {
 	Body   io.Reader
 	Header http.Header
+
+	// GetBody optionally returns a fresh copy of Body so that the request can be retried,
+	// e.g. by re-opening the file it was read from. If it is nil, a Body that must be sent
+	// again is buffered in memory
+	GetBody func() (io.Reader, error)
+
+	// Idempotent opts a request whose method is not safe, e.g. an upload, in to being retried
+	// after transient failures
+	Idempotent bool
+}
+
+// replayable returns RequestOptions whose body can be sent again by calling GetBody
+func (ro RequestOptions) replayable() (RequestOptions, error) {
+	if ro.Body == nil || ro.GetBody != nil {
+		return ro, nil
+	}
+
+	switch body := ro.Body.(type) {
+	case *bytes.Buffer:
+		buf := body.Bytes()
+		ro.GetBody = func() (io.Reader, error) {
+			return bytes.NewReader(buf), nil
+		}
+	case *bytes.Reader:
+		snapshot := *body
+		ro.GetBody = func() (io.Reader, error) {
+			r := snapshot
+			return &r, nil
+		}
+	case *strings.Reader:
+		snapshot := *body
+		ro.GetBody = func() (io.Reader, error) {
+			r := snapshot
+			return &r, nil
+		}
+	default:
+		buf, err := ioutil.ReadAll(body)
+		if err != nil {
+			return RequestOptions{}, fmt.Errorf("failed to buffer request body: %s", err)
+		}
+		ro.Body = bytes.NewReader(buf)
+		ro.GetBody = func() (io.Reader, error) {
+			return bytes.NewReader(buf), nil
+		}
+	}
+
+	return ro, nil
+}
+
+// rewound returns a copy of the RequestOptions with a fresh body
+func (ro RequestOptions) rewound() (RequestOptions, error) {
+	if ro.GetBody == nil {
+		return ro, nil
+	}
+
+	body, err := ro.GetBody()
+	if err != nil {
+		return RequestOptions{}, err
+	}
+	ro.Body = body
+
+	return ro, nil
 }
 
 type basicAPIClient struct {
-	baseURL string
+	baseURL    string
+	httpClient *http.Client
 }
 
 const (
@@ -59,14 +125,19 @@ func (apiClient *basicAPIClient) ExecuteRequest(method, path string, options Req
 	}
 	req.Header.Set(RealmRequestOriginHeader, RealmCLIHeaderValue)
 
-	client := &http.Client{}
-	return client.Do(req)
+	return apiClient.httpClient.Do(req)
 }
 
 // NewClient returns a new Client
 func NewClient(baseURL string) Client {
+	return NewClientWithHTTPClient(baseURL, &http.Client{})
+}
+
+// NewClientWithHTTPClient returns a new Client that sends requests with the provided *http.Client
+func NewClientWithHTTPClient(baseURL string, httpClient *http.Client) Client {
 	return &basicAPIClient{
-		baseURL: baseURL,
+		baseURL:    baseURL,
+		httpClient: httpClient,
 	}
 }
 
@@ -78,10 +149,22 @@ func NewAuthClient(client Client, user *user.User) *AuthClient {
 	}
 }
 
+// UserWriter persists user data, such as an access token obtained by refreshing auth
+type UserWriter interface {
+	WriteUserConfig(u *user.User) error
+}
+
 // AuthClient is a Client that is aware of a User's auth credentials
 type AuthClient struct {
 	Client
-	user *user.User
+	user       *user.User
+	userWriter UserWriter
+}
+
+// WithUserWriter sets the UserWriter used to persist the user's access token whenever it is refreshed
+func (ac *AuthClient) WithUserWriter(userWriter UserWriter) *AuthClient {
+	ac.userWriter = userWriter
+	return ac
 }
 
 // RefreshAuth makes a call to the session endpoint using the user's refresh token in order to obtain a new access token
@@ -110,32 +193,60 @@ func (ac *AuthClient) RefreshAuth() (auth.Response, error) {
 	return authResponse, nil
 }
 
-// ExecuteRequest makes a call to the provided path, supplying the user's access token
-func (ac *AuthClient) ExecuteRequest(method, path string, options RequestOptions) (*http.Response, error) {
-	if options.Header == nil {
-		options.Header = http.Header{}
+// RefreshAccessToken refreshes the user's access token and persists it with the UserWriter, if one is set
+func (ac *AuthClient) RefreshAccessToken() error {
+	authResponse, err := ac.RefreshAuth()
+	if err != nil {
+		return err
+	}
+
+	ac.user.AccessToken = authResponse.AccessToken
+
+	if ac.userWriter == nil {
+		return nil
 	}
 
-	options.Header.Add("Authorization", "Bearer "+ac.user.AccessToken)
+	return ac.userWriter.WriteUserConfig(ac.user)
+}
+
+// ExecuteRequest makes a call to the provided path, supplying the user's access token. If the access token
+// has expired, it is refreshed and the request is sent again with the original body and headers
+func (ac *AuthClient) ExecuteRequest(method, path string, options RequestOptions) (*http.Response, error) {
+	options, err := options.replayable()
+	if err != nil {
+		return nil, err
+	}
 
-	res, err := ac.Client.ExecuteRequest(method, path, options)
+	res, err := ac.executeAuthorizedRequest(method, path, options)
 	if err != nil {
 		return nil, err
 	}
 
 	if res.StatusCode == http.StatusUnauthorized {
 		res.Body.Close()
-		authResponse, refreshErr := ac.RefreshAuth()
-		if refreshErr != nil {
+
+		if refreshErr := ac.RefreshAccessToken(); refreshErr != nil {
 			return nil, refreshErr
 		}
 
-		return ac.Client.ExecuteRequest(method, path, RequestOptions{
-			Header: http.Header{
-				"Authorization": []string{"Bearer " + authResponse.AccessToken},
-			},
-		})
+		retryOptions, rewindErr := options.rewound()
+		if rewindErr != nil {
+			return nil, fmt.Errorf("failed to retry request: %s", rewindErr)
+		}
+
+		return ac.executeAuthorizedRequest(method, path, retryOptions)
 	}
 
 	return res, err
 }
+
+func (ac *AuthClient) executeAuthorizedRequest(method, path string, options RequestOptions) (*http.Response, error) {
+	options.Header = options.Header.Clone()
+	if options.Header == nil {
+		options.Header = http.Header{}
+	}
+
+	options.Header.Set("Authorization", "Bearer "+ac.user.AccessToken)
+
+	return ac.Client.ExecuteRequest(method, path, options)
+}
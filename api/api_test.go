@@ -1,7 +1,11 @@
 package api_test
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/10gen/realm-cli/api"
@@ -62,3 +66,94 @@ func TestAuthClientExecuteRequest(t *testing.T) {
 		u.So(t, client.RequestData[2].Options.Header.Get("Authorization"), gc.ShouldEqual, "Bearer new.access.token")
 	})
 }
+
+func TestAuthClientExecuteRequestReplaysRequest(t *testing.T) {
+	setup := func() *u.MockClient {
+		return u.NewMockClient([]*http.Response{
+			{
+				StatusCode: http.StatusUnauthorized,
+				Body:       u.NewAuthResponseBody(auth.Response{}),
+			},
+			{
+				StatusCode: http.StatusCreated,
+				Body: u.NewAuthResponseBody(auth.Response{
+					AccessToken: "new.access.token",
+				}),
+			},
+			{
+				StatusCode: http.StatusOK,
+				Body:       u.NewAuthResponseBody(auth.Response{}),
+			},
+		})
+	}
+
+	readBody := func(t *testing.T, options api.RequestOptions) string {
+		t.Helper()
+		b, err := ioutil.ReadAll(options.Body)
+		u.So(t, err, gc.ShouldBeNil)
+		return string(b)
+	}
+
+	for _, tc := range []struct {
+		description string
+		body        func() io.Reader
+	}{
+		{"a bytes.Reader", func() io.Reader { return bytes.NewReader([]byte(`{"name":"secret"}`)) }},
+		{"a strings.Reader", func() io.Reader { return strings.NewReader(`{"name":"secret"}`) }},
+		{"a bytes.Buffer", func() io.Reader { return bytes.NewBufferString(`{"name":"secret"}`) }},
+		{"a stream", func() io.Reader { return ioutil.NopCloser(strings.NewReader(`{"name":"secret"}`)) }},
+	} {
+		t.Run("should resend the original body and headers of "+tc.description, func(t *testing.T) {
+			client := setup()
+			authClient := api.NewAuthClient(client, &user.User{AccessToken: "old.access.token", RefreshToken: "my.refresh.token"})
+
+			header := http.Header{"Content-Type": {"application/json"}}
+			_, err := authClient.ExecuteRequest(http.MethodPost, "/somewhere", api.RequestOptions{
+				Body:   tc.body(),
+				Header: header,
+			})
+			u.So(t, err, gc.ShouldBeNil)
+
+			u.So(t, len(client.RequestData), gc.ShouldEqual, 3)
+
+			retry := client.RequestData[2].Options
+			u.So(t, readBody(t, retry), gc.ShouldEqual, `{"name":"secret"}`)
+			u.So(t, retry.Header.Get("Content-Type"), gc.ShouldEqual, "application/json")
+			u.So(t, retry.Header.Get("Authorization"), gc.ShouldEqual, "Bearer new.access.token")
+
+			u.So(t, header.Get("Authorization"), gc.ShouldBeEmpty)
+		})
+	}
+
+	t.Run("should use GetBody to reopen the body when it is provided", func(t *testing.T) {
+		client := setup()
+		authClient := api.NewAuthClient(client, &user.User{AccessToken: "old.access.token", RefreshToken: "my.refresh.token"})
+
+		var opened int
+		_, err := authClient.ExecuteRequest(http.MethodPut, "/somewhere", api.RequestOptions{
+			Body: strings.NewReader("original"),
+			GetBody: func() (io.Reader, error) {
+				opened++
+				return strings.NewReader("reopened"), nil
+			},
+		})
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, opened, gc.ShouldEqual, 1)
+		u.So(t, readBody(t, client.RequestData[2].Options), gc.ShouldEqual, "reopened")
+	})
+
+	t.Run("should persist the refreshed access token", func(t *testing.T) {
+		client := setup()
+		store := u.NewEmptyStorage()
+		authClient := api.NewAuthClient(client, &user.User{AccessToken: "old.access.token", RefreshToken: "my.refresh.token"}).WithUserWriter(store)
+
+		_, err := authClient.ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+
+		storedUser, err := store.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.AccessToken, gc.ShouldEqual, "new.access.token")
+		u.So(t, storedUser.RefreshToken, gc.ShouldEqual, "my.refresh.token")
+	})
+}
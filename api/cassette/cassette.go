@@ -0,0 +1,361 @@
+// Package cassette records the HTTP requests made by the CLI to a file and replays them later, so that
+// commands can be exercised end-to-end without a live server.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/10gen/realm-cli/api/tracing"
+)
+
+// multipartBoundary replaces the random boundary of multipart bodies so that they match when replayed
+const multipartBoundary = "cassette-boundary"
+
+// placeholderTokenExpiry is when the placeholder token expires: 2100-01-01T00:00:00Z
+const placeholderTokenExpiry = 4102444800
+
+// tokenFields are the JSON fields holding session tokens, which are recorded as the placeholder token rather than
+// redacted, since a replayed session must be able to parse them
+var tokenFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+// placeholderToken is a well-formed JWT that expires long after any cassette is replayed, so that a replayed login
+// or session refresh leaves a session that is used as it is
+var placeholderToken = newPlaceholderToken()
+
+func newPlaceholderToken() string {
+	encode := base64.RawStdEncoding.EncodeToString
+	return strings.Join([]string{
+		encode([]byte(`{"alg":"none","typ":"JWT"}`)),
+		encode([]byte(fmt.Sprintf(`{"exp":%d,"sub":"cassette"}`, placeholderTokenExpiry))),
+		"cassette",
+	}, ".")
+}
+
+// ErrInteractionNotFound is returned when a replayed request does not match any unused Interaction
+type ErrInteractionNotFound struct {
+	Method string
+	URL    string
+}
+
+func (einf ErrInteractionNotFound) Error() string {
+	return fmt.Sprintf("no recorded interaction matches %s %s", einf.Method, einf.URL)
+}
+
+// Cassette is a recorded sequence of HTTP interactions
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	mu   sync.Mutex
+	path string
+	used []bool
+}
+
+// Interaction is a single recorded request and the response it received
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded HTTP request. The URL holds only the path and query so that a
+// Cassette can be replayed against any base URL
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body
+}
+
+// Body is a recorded request or response body. Text is kept as is, anything else is base64 encoded
+type Body struct {
+	Body       string `json:"body,omitempty"`
+	BodyBase64 string `json:"body_base64,omitempty"`
+}
+
+func newBody(data []byte) Body {
+	if utf8.Valid(data) {
+		return Body{Body: string(data)}
+	}
+
+	return Body{BodyBase64: base64.StdEncoding.EncodeToString(data)}
+}
+
+func (b Body) bytes() ([]byte, error) {
+	if b.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(b.BodyBase64)
+	}
+
+	return []byte(b.Body), nil
+}
+
+// New returns an empty Cassette that is saved to the provided path as interactions are recorded
+func New(path string) *Cassette {
+	return &Cassette{
+		Interactions: []*Interaction{},
+		path:         path,
+	}
+}
+
+// Load reads a Cassette from the provided path
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %s", err)
+	}
+
+	c := Cassette{path: path}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %s", path, err)
+	}
+
+	c.used = make([]bool, len(c.Interactions))
+
+	return &c, nil
+}
+
+// Save writes the Cassette to its path
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.save()
+}
+
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0600)
+}
+
+// Recorder returns an http.RoundTripper that sends requests with base, or http.DefaultTransport if it is nil,
+// and records each exchange to the Cassette, saving it after every request
+func (c *Cassette) Recorder(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &recorder{cassette: c, base: base}
+}
+
+// Replayer returns an http.RoundTripper that responds to each request with the response recorded for the
+// first unused Interaction whose method, URL, and body match it
+func (c *Cassette) Replayer() http.RoundTripper {
+	return &replayer{cassette: c}
+}
+
+type recorder struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+// RoundTrip sends the request and records the exchange
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.RequestURI(),
+			Header: tracing.RedactHeader(req.Header),
+			Body:   newBody(scrubBody(req.URL.Path, req.Header, reqBody)),
+		},
+		Response: Response{
+			Status: res.StatusCode,
+			Header: tracing.RedactHeader(res.Header),
+			Body:   newBody(scrubBody(req.URL.Path, res.Header, resBody)),
+		},
+	}
+
+	r.cassette.mu.Lock()
+	defer r.cassette.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.cassette.used = append(r.cassette.used, true)
+
+	if err := r.cassette.save(); err != nil {
+		return nil, fmt.Errorf("failed to save cassette: %s", err)
+	}
+
+	return res, nil
+}
+
+type replayer struct {
+	cassette *Cassette
+}
+
+// RoundTrip responds with the recorded response of the matching Interaction
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := scrubBody(req.URL.Path, req.Header, reqBody)
+	url := req.URL.RequestURI()
+
+	r.cassette.mu.Lock()
+	defer r.cassette.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.cassette.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != url {
+			continue
+		}
+
+		recordedBody, err := interaction.Request.bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		if !bodiesMatch(recordedBody, body) {
+			continue
+		}
+
+		resBody, err := interaction.Response.bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		r.cassette.used[i] = true
+
+		header := interaction.Response.Header
+		if header == nil {
+			header = http.Header{}
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(resBody)),
+			ContentLength: int64(len(resBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, ErrInteractionNotFound{Method: req.Method, URL: url}
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// scrubBody redacts credentials from JSON bodies and replaces the random boundary of multipart bodies
+func scrubBody(path string, header http.Header, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if redacted, ok := tracing.RedactJSON(path, body); ok {
+		return replaceTokens(redacted)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		return bytes.Replace(body, []byte(params["boundary"]), []byte(multipartBoundary), -1)
+	}
+
+	return body
+}
+
+// replaceTokens replaces the redacted session tokens of a JSON body with the placeholder token
+func replaceTokens(body []byte) []byte {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return body
+	}
+
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return body
+	}
+
+	var replaced bool
+	for field := range tokenFields {
+		if object[field] == tracing.Redacted {
+			object[field] = placeholderToken
+			replaced = true
+		}
+	}
+	if !replaced {
+		return body
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+func bodiesMatch(recorded, actual []byte) bool {
+	if bytes.Equal(recorded, actual) {
+		return true
+	}
+
+	var recordedDoc, actualDoc interface{}
+	if json.Unmarshal(recorded, &recordedDoc) != nil || json.Unmarshal(actual, &actualDoc) != nil {
+		return false
+	}
+
+	recordedJSON, _ := json.Marshal(recordedDoc)
+	actualJSON, _ := json.Marshal(actualDoc)
+
+	return bytes.Equal(recordedJSON, actualJSON)
+}
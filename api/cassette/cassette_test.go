@@ -0,0 +1,170 @@
+package cassette_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/10gen/realm-cli/api/cassette"
+	"github.com/10gen/realm-cli/api/tracing"
+	"github.com/10gen/realm-cli/auth"
+
+	u "github.com/10gen/realm-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCassette(t *testing.T) {
+	setup := func(t *testing.T) (*httptest.Server, string, func()) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				panic(err)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/login":
+				w.Write([]byte(`{"access_token":"my.access.token"}`)) // nolint: errcheck
+			case "/export":
+				w.Header().Set("Content-Type", "application/zip")
+				w.Write([]byte{0x50, 0x4b, 0x03, 0x04, 0xff, 0xfe}) // nolint: errcheck
+			default:
+				w.Write(append([]byte(`{"echo":`), append(bytes.TrimSpace(body), '}')...)) // nolint: errcheck
+			}
+		}))
+
+		dir, err := ioutil.TempDir("", "realm-cli-cassette")
+		u.So(t, err, gc.ShouldBeNil)
+
+		return server, filepath.Join(dir, "cassette.json"), func() {
+			server.Close()
+			os.RemoveAll(dir)
+		}
+	}
+
+	do := func(t *testing.T, client *http.Client, method, url, contentType, body string) (*http.Response, string, error) {
+		t.Helper()
+
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		u.So(t, err, gc.ShouldBeNil)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer my.refresh.token")
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		defer res.Body.Close()
+
+		resBody, err := ioutil.ReadAll(res.Body)
+		u.So(t, err, gc.ShouldBeNil)
+
+		return res, string(resBody), nil
+	}
+
+	t.Run("should replay a recorded session without the server", func(t *testing.T) {
+		server, path, teardown := setup(t)
+		defer teardown()
+
+		recording := &http.Client{Transport: cassette.New(path).Recorder(nil)}
+
+		_, _, err := do(t, recording, http.MethodPost, server.URL+"/login", "application/json", `{"username":"my-public-key","apiKey":"my-private-key"}`)
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, recordedEcho, err := do(t, recording, http.MethodPost, server.URL+"/apps?product=atlas", "application/json", `{"name":"my-app"}`)
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, recordedExport, err := do(t, recording, http.MethodGet, server.URL+"/export", "", "")
+		u.So(t, err, gc.ShouldBeNil)
+
+		server.Close()
+
+		recorded, err := ioutil.ReadFile(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(recorded), gc.ShouldNotContainSubstring, "my-private-key")
+		u.So(t, string(recorded), gc.ShouldNotContainSubstring, "my.access.token")
+		u.So(t, string(recorded), gc.ShouldNotContainSubstring, "my.refresh.token")
+
+		replayed, err := cassette.Load(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, len(replayed.Interactions), gc.ShouldEqual, 3)
+
+		replaying := &http.Client{Transport: replayed.Replayer()}
+
+		// replayed requests may be sent in a different order and to a different base URL
+		res, export, err := do(t, replaying, http.MethodGet, "http://example.com/export", "", "")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.Header.Get("Content-Type"), gc.ShouldEqual, "application/zip")
+		u.So(t, export, gc.ShouldEqual, recordedExport)
+
+		res, login, err := do(t, replaying, http.MethodPost, "http://example.com/login", "application/json", `{"apiKey":"another-private-key","username":"my-public-key"}`)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusOK)
+
+		// a replayed session must be usable, so tokens are recorded as a well-formed JWT that has not expired
+		var session auth.Response
+		u.So(t, json.Unmarshal([]byte(login), &session), gc.ShouldBeNil)
+		u.So(t, session.AccessToken, gc.ShouldNotEqual, tracing.Redacted)
+
+		token, err := auth.NewJWT(session.AccessToken)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, token.Expired(), gc.ShouldBeFalse)
+
+		_, echo, err := do(t, replaying, http.MethodPost, "http://example.com/apps?product=atlas", "application/json", `{ "name": "my-app" }`)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, echo, gc.ShouldEqual, recordedEcho)
+
+		_, _, err = do(t, replaying, http.MethodGet, "http://example.com/export", "", "")
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, cassette.ErrInteractionNotFound{Method: http.MethodGet, URL: "/export"}.Error())
+	})
+
+	t.Run("should not replay a request with a different body", func(t *testing.T) {
+		server, path, teardown := setup(t)
+		defer teardown()
+
+		recording := &http.Client{Transport: cassette.New(path).Recorder(nil)}
+		_, _, err := do(t, recording, http.MethodPost, server.URL+"/apps", "application/json", `{"name":"my-app"}`)
+		u.So(t, err, gc.ShouldBeNil)
+
+		replayed, err := cassette.Load(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, _, err = do(t, &http.Client{Transport: replayed.Replayer()}, http.MethodPost, server.URL+"/apps", "application/json", `{"name":"another-app"}`)
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+
+	t.Run("should replay multipart requests regardless of their boundary", func(t *testing.T) {
+		server, path, teardown := setup(t)
+		defer teardown()
+
+		multipartBody := func() (string, string) {
+			var buf bytes.Buffer
+			w := multipart.NewWriter(&buf)
+			part, err := w.CreateFormField("file")
+			u.So(t, err, gc.ShouldBeNil)
+			part.Write([]byte("asset contents")) // nolint: errcheck
+			u.So(t, w.Close(), gc.ShouldBeNil)
+			return w.FormDataContentType(), buf.String()
+		}
+
+		contentType, body := multipartBody()
+		recording := &http.Client{Transport: cassette.New(path).Recorder(nil)}
+		_, _, err := do(t, recording, http.MethodPut, server.URL+"/asset", contentType, body)
+		u.So(t, err, gc.ShouldBeNil)
+
+		replayed, err := cassette.Load(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		contentType, body = multipartBody()
+		_, _, err = do(t, &http.Client{Transport: replayed.Replayer()}, http.MethodPut, server.URL+"/asset", contentType, body)
+		u.So(t, err, gc.ShouldBeNil)
+	})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Error codes the Realm API responds with that the CLI handles specially
+const (
+	ErrorCodeDraftAlreadyExists = "DraftAlreadyExists"
+	ErrorCodeInvalidSession     = "InvalidSession"
+	ErrorCodeValidationFailed   = "ValidationFailed"
+)
+
+// ErrorCategory classifies a failed request by what the user can do about it
+type ErrorCategory string
+
+// The set of ErrorCategory values
+const (
+	ErrorCategoryUnknown      ErrorCategory = "unknown"
+	ErrorCategoryNotFound     ErrorCategory = "not_found"
+	ErrorCategoryUnauthorized ErrorCategory = "unauthorized"
+	ErrorCategoryForbidden    ErrorCategory = "forbidden"
+	ErrorCategoryValidation   ErrorCategory = "validation"
+	ErrorCategoryConflict     ErrorCategory = "conflict"
+	ErrorCategoryRateLimited  ErrorCategory = "rate_limited"
+)
+
+var errorCategoryHints = map[ErrorCategory]string{
+	ErrorCategoryNotFound:     "check that the App ID and Project ID are correct and that you are logged in to the profile that owns them",
+	ErrorCategoryUnauthorized: "your session is no longer valid; run 'realm-cli login' to log in again",
+	ErrorCategoryForbidden:    "check that your API key has the Project Owner role for the project the app belongs to",
+	ErrorCategoryValidation:   "correct the invalid configuration and try again",
+	ErrorCategoryConflict:     "the resource was created or changed by another request; check its current state and try again",
+	ErrorCategoryRateLimited:  "too many requests were sent; wait a moment and try again, or raise --retry-attempts to retry automatically",
+}
+
+// CategorizedError is an error that belongs to an ErrorCategory and suggests how it can be resolved
+type CategorizedError interface {
+	error
+	Category() ErrorCategory
+	Hint() string
+}
+
+// RealmError is implemented by every error decoded from a Realm API response
+type RealmError interface {
+	CategorizedError
+	ErrorCode() string
+	Status() int
+	Path() string
+	Fields() []FieldError
+}
+
+// FieldError describes an invalid value in a request that failed validation
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"error"`
+}
+
+// ErrNotFound is returned when the requested resource does not exist
+type ErrNotFound struct{ ErrRealmResponse }
+
+// ErrUnauthorized is returned when the request was made without a valid session
+type ErrUnauthorized struct{ ErrRealmResponse }
+
+// ErrForbidden is returned when the user is not permitted to make the request
+type ErrForbidden struct{ ErrRealmResponse }
+
+// ErrValidation is returned when the request contained invalid data
+type ErrValidation struct{ ErrRealmResponse }
+
+// ErrConflict is returned when the request conflicts with the current state of a resource
+type ErrConflict struct{ ErrRealmResponse }
+
+// ErrRateLimited is returned when too many requests were sent
+type ErrRateLimited struct{ ErrRealmResponse }
+
+// Category returns the ErrorCategory of the ErrAppNotFound
+func (eanf ErrAppNotFound) Category() ErrorCategory {
+	return ErrorCategoryNotFound
+}
+
+// Hint returns how the ErrAppNotFound can be resolved
+func (eanf ErrAppNotFound) Hint() string {
+	return errorCategoryHints[ErrorCategoryNotFound]
+}
+
+// categorize returns the ErrorCategory of a response with the provided status and error code
+func categorize(status int, code string) ErrorCategory {
+	switch code {
+	case ErrorCodeDraftAlreadyExists:
+		return ErrorCategoryConflict
+	case ErrorCodeInvalidSession:
+		return ErrorCategoryUnauthorized
+	case ErrorCodeValidationFailed:
+		return ErrorCategoryValidation
+	}
+
+	if strings.HasSuffix(code, "AlreadyExists") {
+		return ErrorCategoryConflict
+	}
+
+	if strings.HasSuffix(code, "NotFound") {
+		return ErrorCategoryNotFound
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		return ErrorCategoryNotFound
+	case http.StatusUnauthorized:
+		return ErrorCategoryUnauthorized
+	case http.StatusForbidden:
+		return ErrorCategoryForbidden
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrorCategoryValidation
+	case http.StatusConflict:
+		return ErrorCategoryConflict
+	case http.StatusTooManyRequests:
+		return ErrorCategoryRateLimited
+	}
+
+	return ErrorCategoryUnknown
+}
+
+// typed returns the ErrRealmResponse as the typed error of its ErrorCategory
+func (esr ErrRealmResponse) typed() error {
+	switch esr.Category() {
+	case ErrorCategoryNotFound:
+		return ErrNotFound{esr}
+	case ErrorCategoryUnauthorized:
+		return ErrUnauthorized{esr}
+	case ErrorCategoryForbidden:
+		return ErrForbidden{esr}
+	case ErrorCategoryValidation:
+		return ErrValidation{esr}
+	case ErrorCategoryConflict:
+		return ErrConflict{esr}
+	case ErrorCategoryRateLimited:
+		return ErrRateLimited{esr}
+	}
+
+	return esr
+}
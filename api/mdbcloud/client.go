@@ -17,6 +17,9 @@ import (
 
 var errCommonServerError = "an unexpected server error has occurred"
 
+// defaultTimeout is how long a request waits for a response unless a Client is given a timeout
+const defaultTimeout = 20 * time.Second
+
 type groupResponse struct {
 	Results []Group `json:"results"`
 }
@@ -43,6 +46,8 @@ type Client interface {
 
 type simpleClient struct {
 	transport       *digest.Transport
+	baseTransport   http.RoundTripper
+	timeout         time.Duration
 	atlasAPIBaseURL string
 }
 
@@ -54,9 +59,23 @@ func NewClient(atlasAPIBaseURL string) Client {
 	}
 }
 
+// NewClientWithTransport constructs and returns a new Client that sends requests with the provided
+// http.RoundTripper instead of http.DefaultTransport, and whose requests fail if they take longer than
+// the provided timeout. A timeout of zero keeps the default of 20 seconds
+func NewClientWithTransport(atlasAPIBaseURL string, transport http.RoundTripper, timeout time.Duration) Client {
+	return &simpleClient{
+		baseTransport:   transport,
+		timeout:         timeout,
+		atlasAPIBaseURL: atlasAPIBaseURL,
+	}
+}
+
 func (client simpleClient) WithAuth(username, apiKey string) Client {
 	// digest.NewTransport will use http.DefaultTransport
 	client.transport = digest.NewTransport(username, apiKey)
+	if client.baseTransport != nil {
+		client.transport.Transport = client.baseTransport
+	}
 	return &client
 }
 
@@ -165,11 +184,17 @@ func (client *simpleClient) do(
 	req.Header.Add("User-Agent", "MongoDB-BaaS-CLI")
 
 	cl := http.Client{}
-	cl.Timeout = time.Second * 20
+	cl.Timeout = defaultTimeout
+	if client.timeout > 0 {
+		cl.Timeout = client.timeout
+	}
 	if client.transport == nil {
 		if needAuth {
 			return nil, errors.New("expected to have auth context")
 		}
+		if client.baseTransport != nil {
+			cl.Transport = client.baseTransport
+		}
 		return cl.Do(req)
 	}
 	cl.Transport = client.transport
@@ -216,6 +216,22 @@ func (mr *MockRealmClientMockRecorder) ExportDependencies(groupID, appID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDependencies", reflect.TypeOf((*MockRealmClient)(nil).ExportDependencies), groupID, appID)
 }
 
+// ExportDeployment mocks base method
+func (m *MockRealmClient) ExportDeployment(groupID, appID, deploymentID string) (string, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportDeployment", groupID, appID, deploymentID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ExportDeployment indicates an expected call of ExportDeployment
+func (mr *MockRealmClientMockRecorder) ExportDeployment(groupID, appID, deploymentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDeployment", reflect.TypeOf((*MockRealmClient)(nil).ExportDeployment), groupID, appID, deploymentID)
+}
+
 // FetchAppByClientAppID mocks base method
 func (m *MockRealmClient) FetchAppByClientAppID(clientAppID string) (*models.App, error) {
 	m.ctrl.T.Helper()
@@ -334,6 +350,21 @@ func (mr *MockRealmClientMockRecorder) ListAssetsForAppID(groupID, appID interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAssetsForAppID", reflect.TypeOf((*MockRealmClient)(nil).ListAssetsForAppID), groupID, appID)
 }
 
+// ListDeployments mocks base method
+func (m *MockRealmClient) ListDeployments(groupID, appID string) ([]models.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeployments", groupID, appID)
+	ret0, _ := ret[0].([]models.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeployments indicates an expected call of ListDeployments
+func (mr *MockRealmClientMockRecorder) ListDeployments(groupID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeployments", reflect.TypeOf((*MockRealmClient)(nil).ListDeployments), groupID, appID)
+}
+
 // ListSecrets mocks base method
 func (m *MockRealmClient) ListSecrets(groupID, appID string) ([]secrets.Secret, error) {
 	m.ctrl.T.Helper()
@@ -363,6 +394,20 @@ func (mr *MockRealmClientMockRecorder) MoveAsset(groupID, appID, fromPath, toPat
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveAsset", reflect.TypeOf((*MockRealmClient)(nil).MoveAsset), groupID, appID, fromPath, toPath)
 }
 
+// RedeployDeployment mocks base method
+func (m *MockRealmClient) RedeployDeployment(groupID, appID, deploymentID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeployDeployment", groupID, appID, deploymentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RedeployDeployment indicates an expected call of RedeployDeployment
+func (mr *MockRealmClientMockRecorder) RedeployDeployment(groupID, appID, deploymentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeployDeployment", reflect.TypeOf((*MockRealmClient)(nil).RedeployDeployment), groupID, appID, deploymentID)
+}
+
 // RemoveSecretByID mocks base method
 func (m *MockRealmClient) RemoveSecretByID(groupID, appID, secretID string) error {
 	m.ctrl.T.Helper()
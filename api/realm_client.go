@@ -47,7 +47,9 @@ const (
 	deployDraftRoute = adminBaseURL + "/groups/%s/apps/%s/drafts/%s/deployment"
 	diffDraftRoute   = adminBaseURL + "/groups/%s/apps/%s/drafts/%s/diff"
 
+	deploymentsRoute    = adminBaseURL + "/groups/%s/apps/%s/deployments"
 	deploymentByIDRoute = adminBaseURL + "/groups/%s/apps/%s/deployments/%s"
+	redeployRoute       = adminBaseURL + "/groups/%s/apps/%s/deployments/%s/redeploy"
 
 	hostingInvalidateCacheRoute = adminBaseURL + "/groups/%s/apps/%s/hosting/cache"
 	hostingAssetsRoute          = adminBaseURL + "/groups/%s/apps/%s/hosting/assets"
@@ -104,6 +106,7 @@ type RealmClient interface {
 	DraftDiff(groupID, appID, draftID string) (*models.DraftDiff, error)
 	Export(groupID, appID string, strategy ExportStrategy) (string, io.ReadCloser, error)
 	ExportDependencies(groupID, appID string) (string, io.ReadCloser, error)
+	ExportDeployment(groupID, appID, deploymentID string) (string, io.ReadCloser, error)
 	FetchAppByClientAppID(clientAppID string) (*models.App, error)
 	FetchAppByGroupIDAndClientAppID(groupID, clientAppID string) (*models.App, error)
 	FetchAppsByGroupID(groupID string) ([]*models.App, error)
@@ -112,8 +115,10 @@ type RealmClient interface {
 	Import(groupID, appID string, appData []byte, strategy string) error
 	InvalidateCache(groupID, appID, path string) error
 	ListAssetsForAppID(groupID, appID string) ([]hosting.AssetMetadata, error)
+	ListDeployments(groupID, appID string) ([]models.Deployment, error)
 	ListSecrets(groupID, appID string) ([]secrets.Secret, error)
 	MoveAsset(groupID, appID, fromPath, toPath string) error
+	RedeployDeployment(groupID, appID, deploymentID string) error
 	RemoveSecretByID(groupID, appID, secretID string) error
 	RemoveSecretByName(groupID, appID, secretName string) error
 	SetAssetAttributes(groupID, appID, path string, attributes ...hosting.AssetAttribute) error
@@ -153,7 +158,7 @@ func (sc *basicRealmClient) Authenticate(authProvider auth.AuthenticationProvide
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: failed to authenticate: %s", res.Status, UnmarshalRealmError(res))
+		return nil, fmt.Errorf("%s: failed to authenticate: %w", res.Status, UnmarshalRealmError(res))
 	}
 
 	decoder := json.NewDecoder(res.Body)
@@ -174,9 +179,22 @@ func (sc *basicRealmClient) Export(groupID, appID string, strategy ExportStrateg
 	} else if strategy == ExportStrategySourceControl {
 		queryParams = append(queryParams, "source_control=true")
 	}
-	url := fmt.Sprintf(appExportRoute, groupID, appID, strings.Join(queryParams, "&"))
 
-	res, err := sc.ExecuteRequest(http.MethodGet, url, RequestOptions{})
+	return sc.export(fmt.Sprintf(appExportRoute, groupID, appID, strings.Join(queryParams, "&")))
+}
+
+// ExportDeployment will download the app as it was configured by a deployment as a .zip
+func (sc *basicRealmClient) ExportDeployment(groupID, appID, deploymentID string) (string, io.ReadCloser, error) {
+	queryParams := []string{
+		fmt.Sprintf("version=%s", configVersion),
+		fmt.Sprintf("deployment=%s", deploymentID),
+	}
+
+	return sc.export(fmt.Sprintf(appExportRoute, groupID, appID, strings.Join(queryParams, "&")))
+}
+
+func (sc *basicRealmClient) export(route string) (string, io.ReadCloser, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, route, RequestOptions{})
 	if err != nil {
 		return "", nil, err
 	}
@@ -361,6 +379,49 @@ func (sc *basicRealmClient) GetDeployment(groupID, appID, deploymentID string) (
 	return &deployment, nil
 }
 
+// ListDeployments returns the app's deployment history, most recent first
+func (sc *basicRealmClient) ListDeployments(groupID, appID string) ([]models.Deployment, error) {
+	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(deploymentsRoute, groupID, appID), RequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, UnmarshalRealmError(res)
+	}
+
+	bytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var deployments []models.Deployment
+	err = json.Unmarshal(bytes, &deployments)
+	if err != nil {
+		return nil, err
+	}
+
+	return deployments, nil
+}
+
+// RedeployDeployment deploys the app as it was configured by a prior deployment, as a new deployment
+func (sc *basicRealmClient) RedeployDeployment(groupID, appID, deploymentID string) error {
+	res, err := sc.ExecuteRequest(http.MethodPost, fmt.Sprintf(redeployRoute, groupID, appID, deploymentID), RequestOptions{})
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return UnmarshalRealmError(res)
+	}
+
+	return nil
+}
+
 func (sc *basicRealmClient) GetDrafts(groupID, appID string) ([]models.AppDraft, error) {
 	res, err := sc.ExecuteRequest(http.MethodGet, fmt.Sprintf(draftsRoute, groupID, appID), RequestOptions{})
 	if err != nil {
@@ -459,7 +520,9 @@ func (sc *basicRealmClient) FetchAppByClientAppID(clientAppID string) (*models.A
 	return sc.findProjectAppByClientAppID(profileData.AllGroupIDs(), clientAppID)
 }
 
-// UploadAsset creates a pipe and writes the asset to an http.POST along with its metadata
+// UploadAsset creates a pipe and writes the asset to an http.POST along with its metadata. If the body is an
+// io.Seeker, e.g. the asset's file, it is read again from the start when the upload is retried instead of
+// being buffered in memory
 func (sc *basicRealmClient) UploadAsset(groupID, appID, path, hash string, size int64, body io.Reader, attributes ...hosting.AssetAttribute) error {
 	// The upload request consists of a multipart body with two parts:
 	// 1) the metadata, as json, and 2) the file data itself.
@@ -476,55 +539,104 @@ func (sc *basicRealmClient) UploadAsset(groupID, appID, path, hash string, size
 		return err
 	}
 
-	// Construct a pipe stream: the reader side will be consumed and sent as the
-	// body of the outgoing request, and the writer side we can use to
-	// asynchronously populate it.
-	pipeReader, pipeWriter := io.Pipe()
+	// every attempt is sent with the same boundary, as it is set in the header
+	boundary := multipart.NewWriter(nil).Boundary()
 
-	bodyWriter := multipart.NewWriter(pipeWriter)
-	errChan := make(chan error, 2)
-	go func() {
-		defer func() {
-			bodyWriter.Close()
-			// If building the request failed, force the reader side to fail
-			// so that ExecuteRequest returns the error. This behaves equivalent to
-			// .Close() if err is nil.
-			errChan <- pipeWriter.CloseWithError(err)
-		}()
-		// Create the first part and write the metadata into it
-		metaWriter, formErr := bodyWriter.CreateFormField(metadataParam)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to create metadata multipart field: %s", formErr)
-		}
+	upload := &assetUpload{metaPart: metaPart, boundary: boundary, body: body}
+	defer upload.close()
 
-		if _, metaErr := metaWriter.Write(metaPart); metaErr != nil {
-			errChan <- fmt.Errorf("failed to write metadata to body: %s", metaErr)
-		}
+	options := RequestOptions{
+		Body:   upload.start(),
+		Header: http.Header{"Content-Type": {"multipart/mixed; boundary=" + boundary}},
+		// an asset is stored at its path, so sending the same upload again is safe
+		Idempotent: true,
+	}
 
-		// Create the second part, stream the file body into it, then close it.
-		fileWriter, fileErr := bodyWriter.CreateFormField(fileParam)
-		if fileErr != nil {
-			errChan <- fmt.Errorf("failed to create metadata multipart field: %s", fileErr)
+	if seeker, ok := body.(io.Seeker); ok {
+		offset, seekErr := seeker.Seek(0, io.SeekCurrent)
+		if seekErr == nil {
+			options.GetBody = func() (io.Reader, error) {
+				upload.close()
+				if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("failed to rewind asset: %s", err)
+				}
+				return upload.start(), nil
+			}
 		}
+	}
 
-		if _, copyErr := io.Copy(fileWriter, body); copyErr != nil {
-			errChan <- fmt.Errorf("failed to write file to body: %s", copyErr)
-		}
-		errChan <- nil
+	res, err := sc.ExecuteRequest(http.MethodPut, fmt.Sprintf(hostingAssetRoute, groupID, appID), options)
+	return checkStatusNoContent(res, err, "failed to upload asset")
+}
+
+// assetUpload streams the multipart body of an asset upload through a pipe, which is written to by a goroutine
+// for every attempt at the upload
+type assetUpload struct {
+	metaPart []byte
+	boundary string
+	body     io.Reader
+
+	pipeReader *io.PipeReader
+	done       chan struct{}
+}
+
+// start returns the reader side of a new pipe that the multipart body is written to
+func (au *assetUpload) start() io.Reader {
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan struct{})
+
+	au.pipeReader, au.done = pipeReader, done
+
+	go func() {
+		defer close(done)
+
+		// If building the request fails, force the reader side to fail so that ExecuteRequest
+		// returns the error. This behaves equivalent to .Close() if the error is nil.
+		pipeWriter.CloseWithError(au.write(pipeWriter))
 	}()
 
-	res, err := sc.ExecuteRequest(
-		http.MethodPut,
-		fmt.Sprintf(hostingAssetRoute, groupID, appID),
-		RequestOptions{
-			Body:   pipeReader,
-			Header: http.Header{"Content-Type": {"multipart/mixed; boundary=" + bodyWriter.Boundary()}},
-		},
-	)
-	if err := <-errChan; err != nil {
+	return pipeReader
+}
+
+// close stops the writing of the current attempt's body and waits for it, so that the asset is not read while
+// it is rewound for the next attempt
+func (au *assetUpload) close() {
+	if au.pipeReader == nil {
+		return
+	}
+
+	au.pipeReader.Close()
+	<-au.done
+	au.pipeReader = nil
+}
+
+func (au *assetUpload) write(w io.Writer) error {
+	bodyWriter := multipart.NewWriter(w)
+	if err := bodyWriter.SetBoundary(au.boundary); err != nil {
 		return err
 	}
-	return checkStatusNoContent(res, err, "failed to upload asset")
+
+	// Create the first part and write the metadata into it
+	metaWriter, err := bodyWriter.CreateFormField(metadataParam)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata multipart field: %s", err)
+	}
+
+	if _, err := metaWriter.Write(au.metaPart); err != nil {
+		return fmt.Errorf("failed to write metadata to body: %s", err)
+	}
+
+	// Create the second part, stream the file body into it, then close it.
+	fileWriter, err := bodyWriter.CreateFormField(fileParam)
+	if err != nil {
+		return fmt.Errorf("failed to create file multipart field: %s", err)
+	}
+
+	if _, err := io.Copy(fileWriter, au.body); err != nil {
+		return fmt.Errorf("failed to write file to body: %s", err)
+	}
+
+	return bodyWriter.Close()
 }
 
 // SetAssetAttributes sets the asset at the given path to have the provided AssetAttributes
@@ -849,7 +961,7 @@ func checkStatusNoContent(res *http.Response, requestErr error, errMessage strin
 		return requestErr
 	}
 	if res.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("%s: %s: %s", res.Status, errMessage, UnmarshalRealmError(res))
+		return fmt.Errorf("%s: %s: %w", res.Status, errMessage, UnmarshalRealmError(res))
 	}
 	return nil
 }
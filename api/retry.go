@@ -0,0 +1,144 @@
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults used by DefaultRetryPolicy
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy describes how a request that failed with a transient error is retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times a request is sent, so 1 disables retries
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each subsequent retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between retries, including delays requested with a Retry-After header
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used by the CLI unless it is configured otherwise
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: DefaultRetryAttempts,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+	}
+}
+
+// backoff returns the delay before the retry following the provided attempt, using exponential
+// backoff with full jitter
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	delay := rp.MaxDelay
+	if shift := uint(attempt - 1); shift < 32 {
+		if d := rp.BaseDelay << shift; d > 0 && d < rp.MaxDelay {
+			delay = d
+		}
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// retryableStatuses are the response statuses that indicate a request may succeed if sent again
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// idempotentMethods are the methods whose requests are retried without opting in
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// NewRetryClient returns a Client that retries requests sent with client according to the provided RetryPolicy.
+// Only GET, HEAD and OPTIONS requests are retried unless RequestOptions.Idempotent is set
+func NewRetryClient(client Client, policy RetryPolicy) Client {
+	return &retryClient{
+		Client: client,
+		policy: policy,
+	}
+}
+
+type retryClient struct {
+	Client
+	policy RetryPolicy
+}
+
+// ExecuteRequest makes an HTTP request to the provided path, sending it again after a delay if it fails
+// with a network error or a 429, 502, 503, or 504 response
+func (rc *retryClient) ExecuteRequest(method, path string, options RequestOptions) (*http.Response, error) {
+	if rc.policy.MaxAttempts <= 1 || !(options.Idempotent || idempotentMethods[method]) {
+		return rc.Client.ExecuteRequest(method, path, options)
+	}
+
+	options, err := options.replayable()
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := rc.Client.ExecuteRequest(method, path, options)
+		if attempt >= rc.policy.MaxAttempts || (err == nil && !retryableStatuses[res.StatusCode]) {
+			return res, err
+		}
+
+		delay := rc.policy.backoff(attempt)
+		if err == nil {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+				if delay > rc.policy.MaxDelay {
+					delay = rc.policy.MaxDelay
+				}
+			}
+			res.Body.Close()
+		}
+
+		time.Sleep(delay)
+
+		if options, err = options.rewound(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := time.Until(date); delay > 0 {
+		return delay, true
+	}
+
+	return 0, true
+}
@@ -0,0 +1,128 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/realm-cli/api"
+
+	u "github.com/10gen/realm-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryClient(t *testing.T) {
+	policy := api.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	setup := func(statuses ...int) (*httptest.Server, *[]string, func(http.Header)) {
+		var bodies []string
+		var header http.Header
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				panic(err)
+			}
+			bodies = append(bodies, string(b))
+
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+
+			w.WriteHeader(statuses[len(bodies)-1])
+		}))
+
+		return server, &bodies, func(h http.Header) { header = h }
+	}
+
+	t.Run("should retry a safe request until it succeeds", func(t *testing.T) {
+		server, bodies, _ := setup(http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusOK)
+		defer server.Close()
+
+		res, err := api.NewRetryClient(api.NewClient(server.URL), policy).ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusOK)
+		u.So(t, len(*bodies), gc.ShouldEqual, 3)
+	})
+
+	t.Run("should return the last response once attempts are exhausted", func(t *testing.T) {
+		server, bodies, _ := setup(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+		defer server.Close()
+
+		res, err := api.NewRetryClient(api.NewClient(server.URL), policy).ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusBadGateway)
+		u.So(t, len(*bodies), gc.ShouldEqual, 3)
+	})
+
+	t.Run("should not retry a status that is not transient", func(t *testing.T) {
+		server, bodies, _ := setup(http.StatusInternalServerError)
+		defer server.Close()
+
+		res, err := api.NewRetryClient(api.NewClient(server.URL), policy).ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusInternalServerError)
+		u.So(t, len(*bodies), gc.ShouldEqual, 1)
+	})
+
+	t.Run("should not retry an unsafe request unless it opts in", func(t *testing.T) {
+		server, bodies, _ := setup(http.StatusServiceUnavailable)
+		defer server.Close()
+
+		res, err := api.NewRetryClient(api.NewClient(server.URL), policy).ExecuteRequest(http.MethodPost, "/somewhere", api.RequestOptions{
+			Body: strings.NewReader("data"),
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusServiceUnavailable)
+		u.So(t, len(*bodies), gc.ShouldEqual, 1)
+	})
+
+	t.Run("should resend the body of a request that opts in", func(t *testing.T) {
+		server, bodies, _ := setup(http.StatusTooManyRequests, http.StatusNoContent)
+		defer server.Close()
+
+		res, err := api.NewRetryClient(api.NewClient(server.URL), policy).ExecuteRequest(http.MethodPut, "/somewhere", api.RequestOptions{
+			Body:       ioutil.NopCloser(strings.NewReader("asset")),
+			Idempotent: true,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusNoContent)
+		u.So(t, *bodies, gc.ShouldResemble, []string{"asset", "asset"})
+	})
+
+	t.Run("should cap the delay requested with Retry-After at the maximum delay", func(t *testing.T) {
+		server, bodies, setHeader := setup(http.StatusTooManyRequests, http.StatusOK)
+		defer server.Close()
+		setHeader(http.Header{"Retry-After": {"120"}})
+
+		start := time.Now()
+		res, err := api.NewRetryClient(api.NewClient(server.URL), policy).ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusOK)
+		u.So(t, len(*bodies), gc.ShouldEqual, 2)
+		u.So(t, time.Since(start), gc.ShouldBeLessThan, time.Second)
+	})
+
+	t.Run("should send a request once when retries are disabled", func(t *testing.T) {
+		server, bodies, _ := setup(http.StatusServiceUnavailable)
+		defer server.Close()
+
+		res, err := api.NewRetryClient(api.NewClient(server.URL), api.RetryPolicy{MaxAttempts: 1}).ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, res.StatusCode, gc.ShouldEqual, http.StatusServiceUnavailable)
+		u.So(t, len(*bodies), gc.ShouldEqual, 1)
+	})
+
+	t.Run("should time out slow requests", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		_, err := api.NewClientWithHTTPClient(server.URL, &http.Client{Timeout: 20 * time.Millisecond}).ExecuteRequest(http.MethodGet, "/somewhere", api.RequestOptions{})
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+}
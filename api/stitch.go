@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 // ErrAppNotFound is used when an app cannot be found by client app ID
@@ -18,12 +19,23 @@ func (eanf ErrAppNotFound) Error() string {
 
 // ErrRealmResponse represents a response from a Realm API call
 type ErrRealmResponse struct {
-	data errRealmResponseData
+	data   errRealmResponseData
+	status int
+	path   string
 }
 
 // Error returns a stringified error message
 func (esr ErrRealmResponse) Error() string {
-	return fmt.Sprintf("error: %s", esr.data.Error)
+	if len(esr.data.Details) == 0 {
+		return fmt.Sprintf("error: %s", esr.data.Error)
+	}
+
+	fields := make([]string, 0, len(esr.data.Details))
+	for _, field := range esr.data.Details {
+		fields = append(fields, fmt.Sprintf("  %s: %s", field.Path, field.Message))
+	}
+
+	return fmt.Sprintf("error: %s\n%s", esr.data.Error, strings.Join(fields, "\n"))
 }
 
 // ErrorCode returns this ErrorCode on the error
@@ -31,37 +43,65 @@ func (esr ErrRealmResponse) ErrorCode() string {
 	return esr.data.ErrorCode
 }
 
+// Status returns the HTTP status code of the response
+func (esr ErrRealmResponse) Status() int {
+	return esr.status
+}
+
+// Path returns the path of the request the response was sent for
+func (esr ErrRealmResponse) Path() string {
+	return esr.path
+}
+
+// Fields returns the invalid fields of a request that failed validation
+func (esr ErrRealmResponse) Fields() []FieldError {
+	return esr.data.Details
+}
+
+// Category returns the ErrorCategory of the response
+func (esr ErrRealmResponse) Category() ErrorCategory {
+	return categorize(esr.status, esr.data.ErrorCode)
+}
+
+// Hint returns how the error can be resolved, or an empty string if there is no suggestion
+func (esr ErrRealmResponse) Hint() string {
+	return errorCategoryHints[esr.Category()]
+}
+
 // UnmarshalJSON unmarshals JSON data into an ErrRealmResponse
 func (esr *ErrRealmResponse) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, &esr.data)
 }
 
 type errRealmResponseData struct {
-	Error     string `json:"error"`
-	ErrorCode string `json:"error_code"`
+	Error     string       `json:"error"`
+	ErrorCode string       `json:"error_code"`
+	Details   []FieldError `json:"error_details,omitempty"`
 }
 
-// UnmarshalRealmError unmarshals an *http.Response into an ErrRealmResponse. If the Body does not
-// contain content it uses the provided Status
+// UnmarshalRealmError unmarshals an *http.Response into the typed error of its ErrorCategory, such as
+// ErrNotFound or ErrConflict, falling back to an ErrRealmResponse. If the Body does not contain content
+// it uses the provided Status
 func UnmarshalRealmError(res *http.Response) error {
 	var buf bytes.Buffer
 	if _, err := buf.ReadFrom(res.Body); err != nil {
 		return err
 	}
 
+	realmResponse := ErrRealmResponse{status: res.StatusCode}
+	if res.Request != nil && res.Request.URL != nil {
+		realmResponse.path = res.Request.URL.Path
+	}
+
 	str := buf.String()
 	if str == "" {
-		return ErrRealmResponse{
-			data: errRealmResponseData{
-				Error: res.Status,
-			},
-		}
+		realmResponse.data.Error = res.Status
+		return realmResponse.typed()
 	}
 
-	var realmResponse ErrRealmResponse
 	if err := json.NewDecoder(&buf).Decode(&realmResponse); err != nil {
-		realmResponse.data.Error = str
+		realmResponse.data = errRealmResponseData{Error: str}
 	}
 
-	return realmResponse
+	return realmResponse.typed()
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 	"mime/multipart"
 	"net/http"
@@ -14,6 +16,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/10gen/realm-cli/api"
 	"github.com/10gen/realm-cli/hosting"
@@ -51,6 +54,107 @@ func TestErrRealmResponse(t *testing.T) {
 		})
 		u.So(t, err, gc.ShouldBeError, "error: something went horribly, horribly wrong")
 	})
+
+	for _, tc := range []struct {
+		description string
+		status      int
+		body        string
+		category    api.ErrorCategory
+		check       func(err error) bool
+	}{
+		{
+			description: "not found",
+			status:      http.StatusNotFound,
+			body:        `{"error":"app not found"}`,
+			category:    api.ErrorCategoryNotFound,
+			check:       func(err error) bool { var e api.ErrNotFound; return errors.As(err, &e) },
+		},
+		{
+			description: "unauthorized",
+			status:      http.StatusUnauthorized,
+			body:        `{"error":"invalid session","error_code":"InvalidSession"}`,
+			category:    api.ErrorCategoryUnauthorized,
+			check:       func(err error) bool { var e api.ErrUnauthorized; return errors.As(err, &e) },
+		},
+		{
+			description: "forbidden",
+			status:      http.StatusForbidden,
+			body:        `{"error":"forbidden"}`,
+			category:    api.ErrorCategoryForbidden,
+			check:       func(err error) bool { var e api.ErrForbidden; return errors.As(err, &e) },
+		},
+		{
+			description: "validation",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"invalid app"}`,
+			category:    api.ErrorCategoryValidation,
+			check:       func(err error) bool { var e api.ErrValidation; return errors.As(err, &e) },
+		},
+		{
+			description: "conflict",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"draft exists","error_code":"DraftAlreadyExists"}`,
+			category:    api.ErrorCategoryConflict,
+			check:       func(err error) bool { var e api.ErrConflict; return errors.As(err, &e) },
+		},
+		{
+			description: "rate limited",
+			status:      http.StatusTooManyRequests,
+			body:        ``,
+			category:    api.ErrorCategoryRateLimited,
+			check:       func(err error) bool { var e api.ErrRateLimited; return errors.As(err, &e) },
+		},
+	} {
+		t.Run(fmt.Sprintf("should return a typed error for a %s response", tc.description), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/v3.0/groups/g/apps/a", nil)
+			err := api.UnmarshalRealmError(&http.Response{
+				Status:     http.StatusText(tc.status),
+				StatusCode: tc.status,
+				Body:       u.NewResponseBody(strings.NewReader(tc.body)),
+				Request:    req,
+			})
+
+			u.So(t, tc.check(fmt.Errorf("wrapped: %w", err)), gc.ShouldBeTrue)
+
+			var categorized api.CategorizedError
+			u.So(t, errors.As(err, &categorized), gc.ShouldBeTrue)
+			u.So(t, categorized.Category(), gc.ShouldEqual, tc.category)
+			u.So(t, categorized.Hint(), gc.ShouldNotBeEmpty)
+
+			var realmErr api.RealmError
+			u.So(t, errors.As(err, &realmErr), gc.ShouldBeTrue)
+			u.So(t, realmErr.Status(), gc.ShouldEqual, tc.status)
+			u.So(t, realmErr.Path(), gc.ShouldEqual, "/api/admin/v3.0/groups/g/apps/a")
+		})
+	}
+
+	t.Run("should include the invalid fields of a validation failure", func(t *testing.T) {
+		err := api.UnmarshalRealmError(&http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body: u.NewResponseBody(strings.NewReader(`{
+				"error": "failed to validate app",
+				"error_code": "ValidationFailed",
+				"error_details": [{"path": "functions.0.name", "error": "name is required"}]
+			}`)),
+		})
+
+		var validation api.ErrValidation
+		u.So(t, errors.As(err, &validation), gc.ShouldBeTrue)
+		u.So(t, validation.Fields(), gc.ShouldResemble, []api.FieldError{{Path: "functions.0.name", Message: "name is required"}})
+		u.So(t, err, gc.ShouldBeError, "error: failed to validate app\n  functions.0.name: name is required")
+	})
+
+	t.Run("should not categorize an unexpected server error", func(t *testing.T) {
+		err := api.UnmarshalRealmError(&http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       u.NewResponseBody(strings.NewReader(`{"error":"oops"}`)),
+		})
+
+		realmErr, ok := err.(api.ErrRealmResponse)
+		u.So(t, ok, gc.ShouldBeTrue)
+		u.So(t, realmErr.Category(), gc.ShouldEqual, api.ErrorCategoryUnknown)
+		u.So(t, realmErr.Hint(), gc.ShouldBeEmpty)
+	})
 }
 
 // md5Sum returns the md5 hash sum of the input string
@@ -139,6 +243,84 @@ func TestUploadAsset(t *testing.T) {
 	})
 }
 
+// recordingClient is a Client that keeps the options of the last request it was given
+type recordingClient struct {
+	options api.RequestOptions
+}
+
+func (rc *recordingClient) ExecuteRequest(method, path string, options api.RequestOptions) (*http.Response, error) {
+	rc.options = options
+	return &http.Response{StatusCode: http.StatusNoContent, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestUploadAssetRetries(t *testing.T) {
+	testContents := "hello world\r\n"
+
+	setup := func(t *testing.T) (*os.File, func()) {
+		dir, err := ioutil.TempDir("", "realm-cli-upload")
+		u.So(t, err, gc.ShouldBeNil)
+
+		path := filepath.Join(dir, "index.html")
+		u.So(t, ioutil.WriteFile(path, []byte(testContents), 0600), gc.ShouldBeNil)
+
+		file, err := os.Open(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		return file, func() {
+			file.Close()
+			os.RemoveAll(dir)
+		}
+	}
+
+	t.Run("should read the asset file again rather than buffer it", func(t *testing.T) {
+		file, cleanup := setup(t)
+		defer cleanup()
+
+		client := &recordingClient{}
+		u.So(t, api.NewRealmClient(client).UploadAsset(groupID, appID, "/index.html", md5Sum(testContents), int64(len(testContents)), file), gc.ShouldBeNil)
+		u.So(t, client.options.GetBody, gc.ShouldNotBeNil)
+
+		for i := 0; i < 2; i++ {
+			body, err := client.options.GetBody()
+			u.So(t, err, gc.ShouldBeNil)
+
+			data, err := ioutil.ReadAll(body)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, string(data), gc.ShouldContainSubstring, testContents)
+		}
+	})
+
+	t.Run("should send the whole asset again when the upload is retried", func(t *testing.T) {
+		file, cleanup := setup(t)
+		defer cleanup()
+
+		var uploads []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			uploads = append(uploads, string(data))
+
+			if len(uploads) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		policy := api.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+		realmClient := api.NewRealmClient(api.NewRetryClient(api.NewClient(server.URL), policy))
+
+		u.So(t, realmClient.UploadAsset(groupID, appID, "/index.html", md5Sum(testContents), int64(len(testContents)), file), gc.ShouldBeNil)
+		u.So(t, uploads, gc.ShouldHaveLength, 2)
+		u.So(t, uploads[0], gc.ShouldContainSubstring, testContents)
+		u.So(t, uploads[1], gc.ShouldEqual, uploads[0])
+	})
+}
+
 func TestListAssetsForAppID(t *testing.T) {
 	t.Run("listing assets by AppID should work", func(t *testing.T) {
 		testContents := []hosting.AssetMetadata{
@@ -425,6 +607,63 @@ func TestGetDeployment(t *testing.T) {
 	})
 }
 
+func TestListDeployments(t *testing.T) {
+	t.Run("ListDeployments should work", func(t *testing.T) {
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.URL.Path, gc.ShouldEqual, "/api/admin/v3.0/groups/groupID/apps/appID/deployments")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[{ "_id": "2", "draft_id": "d2", "deployed_at": 1600000060, "origin": "UI", "status": "failed", "status_error_message": "bad rule" }, { "_id": "1", "status": "successful" }]`))
+		}
+
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+		testClient := api.NewRealmClient(api.NewClient(testServer.URL))
+		deployments, err := testClient.ListDeployments(groupID, appID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, deployments, gc.ShouldResemble, []models.Deployment{
+			{ID: "2", DraftID: "d2", DeployedAt: 1600000060, Origin: "UI", Status: models.DeploymentStatusFailed, StatusErrorMessage: "bad rule"},
+			{ID: "1", Status: models.DeploymentStatusSuccessful},
+		})
+	})
+}
+
+func TestExportDeployment(t *testing.T) {
+	t.Run("ExportDeployment should work", func(t *testing.T) {
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.URL.Path, gc.ShouldEqual, "/api/admin/v3.0/groups/groupID/apps/appID/export")
+			u.So(t, r.URL.Query().Get("deployment"), gc.ShouldEqual, "123")
+			w.Header().Set("Content-Disposition", `attachment; filename="my-app.zip"`)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("zip"))
+		}
+
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+		testClient := api.NewRealmClient(api.NewClient(testServer.URL))
+		filename, body, err := testClient.ExportDeployment(groupID, appID, "123")
+		u.So(t, err, gc.ShouldBeNil)
+		defer body.Close()
+
+		data, err := ioutil.ReadAll(body)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, filename, gc.ShouldEqual, "my-app.zip")
+		u.So(t, string(data), gc.ShouldEqual, "zip")
+	})
+}
+
+func TestRedeployDeployment(t *testing.T) {
+	t.Run("RedeployDeployment should work", func(t *testing.T) {
+		testHandler := func(w http.ResponseWriter, r *http.Request) {
+			u.So(t, r.Method, gc.ShouldEqual, http.MethodPost)
+			u.So(t, r.URL.Path, gc.ShouldEqual, "/api/admin/v3.0/groups/groupID/apps/appID/deployments/123/redeploy")
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		testServer := httptest.NewServer(http.HandlerFunc(testHandler))
+		testClient := api.NewRealmClient(api.NewClient(testServer.URL))
+		err := testClient.RedeployDeployment(groupID, appID, "123")
+		u.So(t, err, gc.ShouldBeNil)
+	})
+}
+
 func TestGetDrafts(t *testing.T) {
 	t.Run("GetDrafts should work", func(t *testing.T) {
 		testHandler := func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,246 @@
+// Package tracing records the HTTP requests made by the CLI as JSON lines with credentials redacted.
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces any sensitive value in a trace
+const Redacted = "[REDACTED]"
+
+// maxBodySize is the largest body recorded in a trace, larger bodies are truncated
+const maxBodySize = 64 * 1024
+
+// sensitiveHeaders are the headers whose values are always redacted
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFields are the JSON field names, lowercased with separators removed, whose values are always redacted
+var sensitiveFields = map[string]bool{
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"token":         true,
+	"password":      true,
+	"apikey":        true,
+	"privateapikey": true,
+	"secret":        true,
+	"clientsecret":  true,
+}
+
+// secretsPathSegment identifies requests to the secrets API, whose "value" fields hold secret values
+const secretsPathSegment = "/secrets"
+
+// Entry is a single traced HTTP exchange
+type Entry struct {
+	Time            time.Time   `json:"time"`
+	Client          string      `json:"client"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	Status          int         `json:"status,omitempty"`
+	LatencyMS       int64       `json:"latency_ms"`
+	RequestHeaders  http.Header `json:"request_headers,omitempty"`
+	RequestBody     interface{} `json:"request_body,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    interface{} `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// Tracer writes an Entry as a line of JSON for every request sent through one of its transports
+type Tracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTracer returns a new Tracer that writes to w
+func NewTracer(w io.Writer) *Tracer {
+	return &Tracer{w: w}
+}
+
+// Transport returns an http.RoundTripper that traces the requests it sends with base. The client name
+// is recorded with each Entry to tell apart the APIs the requests were sent to
+func (t *Tracer) Transport(base http.RoundTripper, client string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{
+		base:   base,
+		client: client,
+		tracer: t,
+	}
+}
+
+func (t *Tracer) write(entry Entry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.w.Write(append(b, '\n')) // nolint: errcheck
+}
+
+type transport struct {
+	base   http.RoundTripper
+	client string
+	tracer *Tracer
+}
+
+// RoundTrip sends the request with the underlying transport and traces the exchange
+func (tr *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry := Entry{
+		Time:           time.Now().UTC(),
+		Client:         tr.client,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: RedactHeader(req.Header),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		entry.RequestBody = traceBody(req.Header, body, req.URL.Path)
+	}
+
+	start := time.Now()
+	res, err := tr.base.RoundTrip(req)
+	entry.LatencyMS = time.Since(start).Nanoseconds() / int64(time.Millisecond)
+
+	if err != nil {
+		entry.Error = err.Error()
+		tr.tracer.write(entry)
+		return nil, err
+	}
+
+	entry.Status = res.StatusCode
+	entry.ResponseHeaders = RedactHeader(res.Header)
+
+	if res.Body != nil {
+		body, readErr := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		if readErr != nil {
+			entry.Error = readErr.Error()
+		}
+		entry.ResponseBody = traceBody(res.Header, body, req.URL.Path)
+	}
+
+	tr.tracer.write(entry)
+
+	return res, err
+}
+
+// RedactHeader returns a copy of the header with the values of credential headers redacted
+func RedactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	redacted := http.Header{}
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			redacted[name] = []string{Redacted}
+			continue
+		}
+		redacted[name] = values
+	}
+
+	return redacted
+}
+
+// RedactJSON returns the JSON body of a request to, or a response from, the provided path with API keys,
+// tokens, and secret values redacted. It reports false if the body is not JSON
+func RedactJSON(path string, body []byte) ([]byte, bool) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, false
+	}
+
+	redacted, err := json.Marshal(redactJSON(doc, redactsSecretValues(path)))
+	if err != nil {
+		return nil, false
+	}
+
+	return redacted, true
+}
+
+func redactsSecretValues(path string) bool {
+	return strings.Contains(path, secretsPathSegment)
+}
+
+// traceBody returns the body as it should be recorded: redacted JSON, text, or a note of its size
+func traceBody(header http.Header, body []byte, path string) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	if redacted, ok := RedactJSON(path, body); ok {
+		if len(redacted) > maxBodySize {
+			return truncate(redacted)
+		}
+		return json.RawMessage(redacted)
+	}
+
+	if strings.HasPrefix(mediaType, "text/") {
+		return truncate(body)
+	}
+
+	return map[string]interface{}{
+		"content_type": mediaType,
+		"size":         len(body),
+	}
+}
+
+func redactJSON(doc interface{}, redactValues bool) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitiveField(key) || (redactValues && key == "value") {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactJSON(value, redactValues)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(value, redactValues)
+		}
+	}
+
+	return doc
+}
+
+func isSensitiveField(name string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	return sensitiveFields[normalized]
+}
+
+func truncate(body []byte) string {
+	if len(body) <= maxBodySize {
+		return string(body)
+	}
+
+	return string(body[:maxBodySize]) + "...(truncated)"
+}
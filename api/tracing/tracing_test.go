@@ -0,0 +1,113 @@
+package tracing_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/10gen/realm-cli/api/tracing"
+
+	u "github.com/10gen/realm-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTracerTransport(t *testing.T) {
+	setup := func(status int, contentType, responseBody string) (*httptest.Server, *bytes.Buffer, *http.Client) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Set-Cookie", "session=my-session")
+			w.WriteHeader(status)
+			w.Write([]byte(responseBody)) // nolint: errcheck
+		}))
+
+		var buf bytes.Buffer
+		client := &http.Client{Transport: tracing.NewTracer(&buf).Transport(nil, "realm")}
+
+		return server, &buf, client
+	}
+
+	readEntries := func(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+		t.Helper()
+
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]interface{}
+			u.So(t, json.Unmarshal([]byte(line), &entry), gc.ShouldBeNil)
+			entries = append(entries, entry)
+		}
+
+		return entries
+	}
+
+	t.Run("should record the exchange and redact credentials", func(t *testing.T) {
+		server, buf, client := setup(http.StatusCreated, "application/json", `{"access_token":"my.access.token","user_id":"123"}`)
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/admin/v3.0/auth/providers/mongodb-cloud/login", strings.NewReader(`{"username":"my-public-key","apiKey":"my-private-key"}`))
+		u.So(t, err, gc.ShouldBeNil)
+		req.Header.Set("Authorization", "Bearer my.refresh.token")
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := client.Do(req)
+		u.So(t, err, gc.ShouldBeNil)
+
+		body, err := ioutil.ReadAll(res.Body)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(body), gc.ShouldEqual, `{"access_token":"my.access.token","user_id":"123"}`)
+
+		u.So(t, buf.String(), gc.ShouldNotContainSubstring, "my.access.token")
+		u.So(t, buf.String(), gc.ShouldNotContainSubstring, "my.refresh.token")
+		u.So(t, buf.String(), gc.ShouldNotContainSubstring, "my-private-key")
+		u.So(t, buf.String(), gc.ShouldNotContainSubstring, "my-session")
+
+		entries := readEntries(t, buf)
+		u.So(t, len(entries), gc.ShouldEqual, 1)
+
+		entry := entries[0]
+		u.So(t, entry["client"], gc.ShouldEqual, "realm")
+		u.So(t, entry["method"], gc.ShouldEqual, http.MethodPost)
+		u.So(t, entry["status"], gc.ShouldEqual, http.StatusCreated)
+		u.So(t, entry["url"], gc.ShouldEndWith, "/auth/providers/mongodb-cloud/login")
+		u.So(t, entry["request_body"], gc.ShouldResemble, map[string]interface{}{"username": "my-public-key", "apiKey": tracing.Redacted})
+		u.So(t, entry["response_body"], gc.ShouldResemble, map[string]interface{}{"access_token": tracing.Redacted, "user_id": "123"})
+		u.So(t, entry["request_headers"].(map[string]interface{})["Authorization"], gc.ShouldResemble, []interface{}{tracing.Redacted})
+		u.So(t, entry, gc.ShouldContainKey, "latency_ms")
+	})
+
+	t.Run("should redact secret values", func(t *testing.T) {
+		server, buf, client := setup(http.StatusCreated, "application/json", `{"_id":"1","name":"my-secret"}`)
+		defer server.Close()
+
+		_, err := client.Post(server.URL+"/api/admin/v3.0/groups/g/apps/a/secrets", "application/json", strings.NewReader(`{"name":"my-secret","value":"hunter2"}`))
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, buf.String(), gc.ShouldNotContainSubstring, "hunter2")
+		u.So(t, readEntries(t, buf)[0]["request_body"], gc.ShouldResemble, map[string]interface{}{"name": "my-secret", "value": tracing.Redacted})
+	})
+
+	t.Run("should record the size of binary bodies", func(t *testing.T) {
+		server, buf, client := setup(http.StatusOK, "application/zip", "PK\x03\x04binary")
+		defer server.Close()
+
+		_, err := client.Get(server.URL + "/export")
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, readEntries(t, buf)[0]["response_body"], gc.ShouldResemble, map[string]interface{}{"content_type": "application/zip", "size": float64(10)})
+	})
+
+	t.Run("should record failed requests", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := &http.Client{Transport: tracing.NewTracer(&buf).Transport(nil, "atlas")}
+
+		_, err := client.Get("http://127.0.0.1:0/unreachable")
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		entry := readEntries(t, &buf)[0]
+		u.So(t, entry["client"], gc.ShouldEqual, "atlas")
+		u.So(t, entry["error"], gc.ShouldNotBeEmpty)
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+var errClientCertAndKeyRequired = errors.New("a client certificate and its key must be supplied together")
+
+// TransportConfig configures how the CLI connects to the servers it sends requests to
+type TransportConfig struct {
+	// ProxyURL is the proxy every request is sent through. The proxy defined by the
+	// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables is used when it is empty
+	ProxyURL string
+
+	// CABundlePath is a PEM file of root certificates trusted in addition to the system's
+	CABundlePath string
+
+	// ClientCertPath and ClientKeyPath are the PEM files of the certificate, and its private key,
+	// presented to servers that require mutual TLS
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// InsecureSkipVerify disables verification of server certificates, e.g. for local servers with
+	// self-signed certificates
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns an *http.Transport with the defaults of http.DefaultTransport and the proxy and
+// TLS settings of the provided TransportConfig
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", config.ProxyURL)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify, // nolint: gosec
+	}
+
+	if config.CABundlePath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := ioutil.ReadFile(config.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %s", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %q", config.CABundlePath)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertPath != "" || config.ClientKeyPath != "" {
+		if config.ClientCertPath == "" || config.ClientKeyPath == "" {
+			return nil, errClientCertAndKeyRequired
+		}
+
+		cert, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %s", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/realm-cli/api"
+
+	u "github.com/10gen/realm-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewTransport(t *testing.T) {
+	setup := func(t *testing.T) (string, func()) {
+		dir, err := ioutil.TempDir("", "realm-cli-transport")
+		u.So(t, err, gc.ShouldBeNil)
+
+		return dir, func() { os.RemoveAll(dir) }
+	}
+
+	writePEM := func(t *testing.T, path, blockType string, der []byte) {
+		t.Helper()
+		u.So(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600), gc.ShouldBeNil)
+	}
+
+	get := func(transport http.RoundTripper, url string) (*http.Response, error) {
+		return (&http.Client{Transport: transport}).Get(url)
+	}
+
+	t.Run("should send requests through the proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+		}))
+		defer proxy.Close()
+
+		transport, err := api.NewTransport(api.TransportConfig{ProxyURL: proxy.URL})
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = get(transport, "http://realm.example.com/api/admin/v3.0/auth/profile")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, proxied, gc.ShouldEqual, "http://realm.example.com/api/admin/v3.0/auth/profile")
+	})
+
+	t.Run("should reject an invalid proxy URL", func(t *testing.T) {
+		_, err := api.NewTransport(api.TransportConfig{ProxyURL: "not a url"})
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+
+	t.Run("should trust the servers signed by the CA bundle", func(t *testing.T) {
+		dir, teardown := setup(t)
+		defer teardown()
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		transport, err := api.NewTransport(api.TransportConfig{})
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = get(transport, server.URL)
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		caBundle := filepath.Join(dir, "ca.pem")
+		writePEM(t, caBundle, "CERTIFICATE", server.Certificate().Raw)
+
+		transport, err = api.NewTransport(api.TransportConfig{CABundlePath: caBundle})
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = get(transport, server.URL)
+		u.So(t, err, gc.ShouldBeNil)
+	})
+
+	t.Run("should reject a CA bundle without certificates", func(t *testing.T) {
+		dir, teardown := setup(t)
+		defer teardown()
+
+		caBundle := filepath.Join(dir, "ca.pem")
+		u.So(t, ioutil.WriteFile(caBundle, []byte("not a certificate"), 0600), gc.ShouldBeNil)
+
+		_, err := api.NewTransport(api.TransportConfig{CABundlePath: caBundle})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "no certificates found")
+	})
+
+	t.Run("should skip verifying server certificates when insecure", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		transport, err := api.NewTransport(api.TransportConfig{InsecureSkipVerify: true})
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = get(transport, server.URL)
+		u.So(t, err, gc.ShouldBeNil)
+	})
+
+	t.Run("should present the client certificate to servers that require one", func(t *testing.T) {
+		dir, teardown := setup(t)
+		defer teardown()
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+		defer server.Close()
+
+		transport, err := api.NewTransport(api.TransportConfig{InsecureSkipVerify: true})
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = get(transport, server.URL)
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		// the server's own certificate doubles as the client certificate
+		serverCert := server.TLS.Certificates[0]
+		clientCert, clientKey := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+		writePEM(t, clientCert, "CERTIFICATE", serverCert.Certificate[0])
+
+		keyDER, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+		u.So(t, err, gc.ShouldBeNil)
+		writePEM(t, clientKey, "PRIVATE KEY", keyDER)
+
+		transport, err = api.NewTransport(api.TransportConfig{
+			ClientCertPath:     clientCert,
+			ClientKeyPath:      clientKey,
+			InsecureSkipVerify: true,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = get(transport, server.URL)
+		u.So(t, err, gc.ShouldBeNil)
+	})
+
+	t.Run("should require a client certificate and its key together", func(t *testing.T) {
+		_, err := api.NewTransport(api.TransportConfig{ClientCertPath: "client.pem"})
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+}
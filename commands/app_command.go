@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"github.com/10gen/realm-cli/models"
+	u "github.com/10gen/realm-cli/user"
+	"github.com/10gen/realm-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+// NewAppCommand returns a new *AppCommand
+func NewAppCommand(name, workingDirectory string, ui cli.Ui) *AppCommand {
+	return &AppCommand{
+		ProjectCommand:   NewProjectCommand(name, ui),
+		workingDirectory: workingDirectory,
+	}
+}
+
+// AppCommand handles the parsing and execution of a command run against an existing Realm App, given by --app-id
+// or by the app directory the command is run from
+type AppCommand struct {
+	*ProjectCommand
+
+	workingDirectory string
+
+	flagAppID string
+}
+
+// Help returns long-form help information for the AppCommand command
+func (ac *AppCommand) Help() string {
+	return `
+OPTIONAL:
+  --app-id [string]
+	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
+	Required if not being run from within a realm project directory.` +
+		ac.ProjectCommand.Help()
+}
+
+func (ac *AppCommand) run(args []string) error {
+	if ac.FlagSet == nil {
+		ac.NewFlagSet()
+	}
+
+	ac.FlagSet.StringVar(&ac.flagAppID, flagAppIDName, "", "")
+
+	if err := ac.ProjectCommand.run(args); err != nil {
+		return err
+	}
+
+	user, err := ac.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	return nil
+}
+
+func (ac *AppCommand) resolveApp() (*models.App, error) {
+	appID := ac.flagAppID
+	if ac.flagAppID == "" {
+		appPath, err := utils.ResolveAppDirectory("", ac.workingDirectory)
+		if err != nil {
+			return nil, err
+		}
+
+		appInstanceData, err := utils.ResolveAppInstanceData(ac.flagAppID, appPath)
+		if err != nil {
+			return nil, err
+		}
+		appID = appInstanceData.AppID()
+	}
+
+	realmClient, err := ac.RealmClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var app *models.App
+	if ac.flagProjectID == "" {
+		app, err = realmClient.FetchAppByClientAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		app, err = realmClient.FetchAppByGroupIDAndClientAppID(ac.flagProjectID, appID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return app, nil
+}
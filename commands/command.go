@@ -10,9 +10,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/api/cassette"
 	"github.com/10gen/realm-cli/api/mdbcloud"
+	"github.com/10gen/realm-cli/api/tracing"
+	"github.com/10gen/realm-cli/project"
 	"github.com/10gen/realm-cli/storage"
 	"github.com/10gen/realm-cli/user"
 	"github.com/10gen/realm-cli/utils"
@@ -23,7 +27,25 @@ import (
 )
 
 const (
-	flagAppIDName = "app-id"
+	flagAppIDName        = "app-id"
+	flagBaseURLName      = "base-url"
+	flagAtlasBaseURLName = "atlas-base-url"
+	flagProfileName      = "profile"
+	flagConfigPathName   = "config-path"
+	flagTraceFileName    = "trace-file"
+
+	flagYesName            = "yes"
+	flagNonInteractiveName = "non-interactive"
+	flagEnvName            = "env"
+
+	flagRetryAttemptsName  = "retry-attempts"
+	flagRequestTimeoutName = "request-timeout"
+
+	flagRecordCassetteName = "record-cassette"
+	flagReplayCassetteName = "replay-cassette"
+
+	traceClientRealm = "realm"
+	traceClientAtlas = "atlas"
 )
 
 var (
@@ -39,17 +61,56 @@ type BaseCommand struct {
 	CLI *cli.CLI
 	UI  cli.Ui
 
-	client      api.Client
-	atlasClient mdbcloud.Client
-	realmClient api.RealmClient
-	user        *user.User
-	storage     *storage.Storage
+	client        api.Client
+	atlasClient   mdbcloud.Client
+	realmClient   api.RealmClient
+	user          *user.User
+	storage       *storage.Storage
+	credentials   *credentials
+	stdin         io.Reader
+	tracer        *tracing.Tracer
+	cassette      http.RoundTripper
+	httpTransport http.RoundTripper
+	resultUI      cli.Ui
+
+	// progressWriter is the terminal progress lines are rewritten on, or nil when messages are not shown on one
+	progressWriter io.Writer
 
 	flagConfigPath    string
 	flagColorDisabled bool
 	flagBaseURL       string
 	flagAtlasBaseURL  string
+	flagProfile       string
 	flagYes           bool
+	flagOutputFormat  string
+
+	flagNonInteractive bool
+
+	flagEnv       string
+	explicitFlags map[string]bool
+	projectConfig *project.Config
+
+	flagRetryAttempts  int
+	flagRequestTimeout time.Duration
+
+	flagDebug     bool
+	flagTraceFile string
+
+	flagRecordCassette string
+	flagReplayCassette string
+
+	flagCredentialsStdin bool
+	flagEphemeral        bool
+
+	flagProxy              string
+	flagCABundle           string
+	flagClientCert         string
+	flagClientKey          string
+	flagInsecureSkipVerify bool
+
+	flagCredentialStore   string
+	flagCredentialKeyFile string
+	flagCredentialCommand string
 }
 
 // NewFlagSet builds and returns the default set of flags for all commands
@@ -58,11 +119,31 @@ func (c *BaseCommand) NewFlagSet() *flag.FlagSet {
 	set.Usage = func() {}
 
 	set.BoolVar(&c.flagColorDisabled, "disable-color", false, "")
-	set.BoolVar(&c.flagYes, "yes", false, "")
+	set.BoolVar(&c.flagYes, flagYesName, false, "")
 	set.BoolVar(&c.flagYes, "y", false, "")
-	set.StringVar(&c.flagBaseURL, "base-url", api.DefaultBaseURL, "")
-	set.StringVar(&c.flagAtlasBaseURL, "atlas-base-url", api.DefaultAtlasBaseURL, "")
-	set.StringVar(&c.flagConfigPath, "config-path", "", "")
+	set.BoolVar(&c.flagNonInteractive, flagNonInteractiveName, false, "")
+	set.StringVar(&c.flagEnv, flagEnvName, "", "")
+	set.StringVar(&c.flagOutputFormat, flagOutputFormatName, outputFormatTable, "")
+	set.StringVar(&c.flagBaseURL, flagBaseURLName, api.DefaultBaseURL, "")
+	set.StringVar(&c.flagAtlasBaseURL, flagAtlasBaseURLName, api.DefaultAtlasBaseURL, "")
+	set.StringVar(&c.flagConfigPath, flagConfigPathName, "", "")
+	set.StringVar(&c.flagProfile, flagProfileName, "", "")
+	set.IntVar(&c.flagRetryAttempts, flagRetryAttemptsName, api.DefaultRetryAttempts, "")
+	set.DurationVar(&c.flagRequestTimeout, flagRequestTimeoutName, 0, "")
+	set.BoolVar(&c.flagDebug, "debug", false, "")
+	set.StringVar(&c.flagTraceFile, flagTraceFileName, "", "")
+	set.StringVar(&c.flagRecordCassette, flagRecordCassetteName, "", "")
+	set.StringVar(&c.flagReplayCassette, flagReplayCassetteName, "", "")
+	set.BoolVar(&c.flagCredentialsStdin, flagCredentialsStdinName, false, "")
+	set.BoolVar(&c.flagEphemeral, flagEphemeralName, false, "")
+	set.StringVar(&c.flagCredentialStore, flagCredentialStoreName, "", "")
+	set.StringVar(&c.flagCredentialKeyFile, flagCredentialKeyFileName, "", "")
+	set.StringVar(&c.flagCredentialCommand, flagCredentialCommandName, "", "")
+	set.StringVar(&c.flagProxy, flagProxyName, "", "")
+	set.StringVar(&c.flagCABundle, flagCABundleName, "", "")
+	set.StringVar(&c.flagClientCert, flagClientCertName, "", "")
+	set.StringVar(&c.flagClientKey, flagClientKeyName, "", "")
+	set.BoolVar(&c.flagInsecureSkipVerify, flagInsecureSkipVerifyName, false, "")
 
 	c.FlagSet = set
 
@@ -75,7 +156,20 @@ func (c *BaseCommand) Client() (api.Client, error) {
 		return c.client, nil
 	}
 
-	c.client = api.NewClient(c.flagBaseURL)
+	baseURL, err := c.resolveBaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	policy := api.DefaultRetryPolicy()
+	policy.MaxAttempts = c.flagRetryAttempts
+
+	httpClient := &http.Client{
+		Timeout:   c.flagRequestTimeout,
+		Transport: c.transport(traceClientRealm),
+	}
+
+	c.client = api.NewRetryClient(api.NewClientWithHTTPClient(baseURL, httpClient), policy)
 
 	return c.client, nil
 }
@@ -91,7 +185,15 @@ func (c *BaseCommand) AtlasClient() (mdbcloud.Client, error) {
 		return nil, err
 	}
 
-	c.atlasClient = mdbcloud.NewClient(c.flagAtlasBaseURL).WithAuth(user.PublicAPIKey, user.PrivateAPIKey)
+	atlasBaseURL, ok := c.suppliedAtlasBaseURL()
+	if !ok {
+		atlasBaseURL = c.flagAtlasBaseURL
+		if user.AtlasBaseURL != "" {
+			atlasBaseURL = user.AtlasBaseURL
+		}
+	}
+
+	c.atlasClient = mdbcloud.NewClientWithTransport(atlasBaseURL, c.transport(traceClientAtlas), c.flagRequestTimeout).WithAuth(user.PublicAPIKey, user.PrivateAPIKey)
 
 	return c.atlasClient, nil
 }
@@ -109,7 +211,7 @@ func (c *BaseCommand) AuthClient() (api.Client, error) {
 		return nil, err
 	}
 
-	authClient := api.NewAuthClient(client, user)
+	authClient := api.NewAuthClient(client, user).WithUserWriter(c.storage)
 
 	tokenIsExpired, err := user.TokenIsExpired()
 	if err != nil {
@@ -117,14 +219,7 @@ func (c *BaseCommand) AuthClient() (api.Client, error) {
 	}
 
 	if tokenIsExpired {
-		authResponse, err := authClient.RefreshAuth()
-		if err != nil {
-			return nil, err
-		}
-
-		user.AccessToken = authResponse.AccessToken
-
-		if err := c.storage.WriteUserConfig(user); err != nil {
+		if err := authClient.RefreshAccessToken(); err != nil {
 			return nil, err
 		}
 	}
@@ -148,12 +243,24 @@ func (c *BaseCommand) RealmClient() (api.RealmClient, error) {
 	return c.realmClient, nil
 }
 
-// User returns the current user. It loads the user from storage if it is not available in memory
+// User returns the current user. It logs in with any API keys supplied through the environment or stdin,
+// otherwise it loads the user from storage if it is not available in memory
 func (c *BaseCommand) User() (*user.User, error) {
 	if c.user != nil {
 		return c.user, nil
 	}
 
+	if c.credentials != nil && c.credentials.hasAPIKey() {
+		u, err := c.logInWithCredentials()
+		if err != nil {
+			return nil, err
+		}
+
+		c.user = u
+
+		return u, nil
+	}
+
 	u, err := c.storage.ReadUserConfig()
 	if err != nil {
 		return nil, err
@@ -164,18 +271,111 @@ func (c *BaseCommand) User() (*user.User, error) {
 	return u, nil
 }
 
+// resolveBaseURL returns the Realm base URL supplied by flag, credentials document, or environment,
+// falling back to the one stored with the current profile
+func (c *BaseCommand) resolveBaseURL() (string, error) {
+	if baseURL, ok := c.suppliedBaseURL(); ok {
+		return baseURL, nil
+	}
+
+	// supplied API keys are authenticated against the resolved base URL, so storage is not consulted
+	if c.storage == nil || (c.credentials != nil && c.credentials.hasAPIKey()) {
+		return c.flagBaseURL, nil
+	}
+
+	user, err := c.User()
+	if err != nil {
+		return "", err
+	}
+
+	if user.BaseURL != "" {
+		return user.BaseURL, nil
+	}
+
+	return c.flagBaseURL, nil
+}
+
+// transport returns the http.RoundTripper requests to the named API are sent with, or nil to use
+// http.DefaultTransport
+func (c *BaseCommand) transport(client string) http.RoundTripper {
+	transport := c.httpTransport
+	if c.cassette != nil {
+		transport = c.cassette
+	}
+
+	if c.tracer != nil {
+		transport = c.tracer.Transport(transport, client)
+	}
+
+	return transport
+}
+
+// appendFileWriter appends every write to the file at its path, opening the file for each write and closing it
+// again, so that the file is not left open once the command finishes
+type appendFileWriter struct {
+	path string
+}
+
+func (w appendFileWriter) Write(p []byte) (int, error) {
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := file.Write(p)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return n, err
+}
+
+// flagIsSet returns whether the named flag was explicitly provided on the command line
+func (c *BaseCommand) flagIsSet(name string) bool {
+	if c.FlagSet == nil {
+		return false
+	}
+
+	var isSet bool
+	c.FlagSet.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			isSet = true
+		}
+	})
+
+	return isSet
+}
+
 func (c *BaseCommand) run(args []string) error {
 	if c.FlagSet == nil {
 		c.NewFlagSet()
 	}
 
+	c.registerOutputFlag()
+
 	// FlagSet uses flag.ExitOnError, so we let it handle flag-related errors
 	// to avoid duplicate error output
 	if err := c.Parse(args); err != nil {
 		return err
 	}
 
-	if !c.flagColorDisabled && isatty.IsTerminal(os.Stdout.Fd()) {
+	if err := c.applyProjectConfig(); err != nil {
+		return err
+	}
+
+	if !c.promptsFromTerminal() {
+		c.flagNonInteractive = true
+	}
+
+	if c.progressWriter == nil {
+		c.progressWriter = c.terminalWriter()
+	}
+
+	if err := c.setUpOutput(); err != nil {
+		return err
+	}
+
+	if c.colorEnabled() {
 		c.UI = &cli.ColoredUi{
 			ErrorColor: cli.UiColorRed,
 			WarnColor:  cli.UiColorYellow,
@@ -183,8 +383,31 @@ func (c *BaseCommand) run(args []string) error {
 		}
 	}
 
-	if url := utils.CheckForNewCLIVersion(http.DefaultClient); url != "" {
-		c.UI.Info(url)
+	switch {
+	case c.flagTraceFile != "":
+		path, err := homedir.Expand(c.flagTraceFile)
+		if err != nil {
+			return err
+		}
+
+		traceFile := appendFileWriter{path}
+		if _, err := traceFile.Write(nil); err != nil {
+			return fmt.Errorf("failed to open trace file: %s", err)
+		}
+
+		c.tracer = tracing.NewTracer(traceFile)
+	case c.flagDebug:
+		c.tracer = tracing.NewTracer(os.Stderr)
+	}
+
+	credentials, err := c.loadCredentials()
+	if err != nil {
+		return err
+	}
+	c.credentials = credentials
+
+	if c.flagEphemeral {
+		c.storage = storage.New(storage.NewMemoryStrategy(nil))
 	}
 
 	if c.storage == nil {
@@ -201,17 +424,86 @@ func (c *BaseCommand) run(args []string) error {
 			path = filepath.Join(home, ".config", "realm", "realm")
 		}
 
-		fileStrategy, err := storage.NewFileStrategy(path)
+		strategy, err := c.newStorageStrategy(path)
 		if err != nil {
 			return err
 		}
 
-		c.storage = storage.New(fileStrategy)
+		c.storage = storage.New(strategy)
+	}
+
+	if c.flagProfile != "" {
+		c.storage = c.storage.WithProfile(c.flagProfile)
+	}
+
+	httpTransport, err := c.newHTTPTransport()
+	if err != nil {
+		return err
+	}
+	c.httpTransport = httpTransport
+
+	switch {
+	case c.flagRecordCassette != "" && c.flagReplayCassette != "":
+		return fmt.Errorf("'%s' and '%s' flags are mutually exclusive", flagRecordCassetteName, flagReplayCassetteName)
+	case c.flagRecordCassette != "":
+		c.cassette = cassette.New(c.flagRecordCassette).Recorder(c.httpTransport)
+	case c.flagReplayCassette != "":
+		replayed, err := cassette.Load(c.flagReplayCassette)
+		if err != nil {
+			return err
+		}
+
+		c.cassette = replayed.Replayer()
+	}
+
+	if url := utils.CheckForNewCLIVersion(&http.Client{Transport: c.httpTransport}); url != "" {
+		c.UI.Info(url)
 	}
 
 	return nil
 }
 
+// promptsFromTerminal returns whether prompts can be answered by a person at a terminal, which is not
+// the case when the CLI's stdin is a pipe or file, e.g. in a CI job
+func (c *BaseCommand) promptsFromTerminal() bool {
+	basicUI, ok := c.UI.(*cli.BasicUi)
+	if !ok || basicUI.Reader != os.Stdin {
+		return true
+	}
+
+	return isatty.IsTerminal(os.Stdin.Fd())
+}
+
+// ask prompts the user for input, failing with ErrMissingInput in non-interactive mode or once stdin is exhausted
+func (c *BaseCommand) ask(query string, missingInput ErrMissingInput) (string, error) {
+	if c.flagNonInteractive {
+		return "", missingInput
+	}
+
+	res, err := c.UI.Ask(query)
+	if err == io.EOF {
+		return "", missingInput
+	}
+
+	return res, err
+}
+
+// AskSecret is used to prompt the user for input without echoing it. flagName names the flag that supplies
+// the answer instead
+func (c *BaseCommand) AskSecret(query, flagName string) (string, error) {
+	missingInput := ErrMissingInput{Prompt: strings.TrimSuffix(query, ":"), Flag: flagName}
+	if c.flagNonInteractive {
+		return "", missingInput
+	}
+
+	res, err := c.UI.AskSecret(query)
+	if err == io.EOF {
+		return "", missingInput
+	}
+
+	return res, err
+}
+
 // AskYesNo is used to prompt the user for yes/no input
 func (c *BaseCommand) AskYesNo(query string) (bool, error) {
 	if c.flagYes {
@@ -219,7 +511,9 @@ func (c *BaseCommand) AskYesNo(query string) (bool, error) {
 		return true, nil
 	}
 
-	res, err := c.UI.Ask(query + " [y/n]:")
+	missingInput := ErrMissingInput{Prompt: query, Flag: flagYesName}
+
+	res, err := c.ask(query+" [y/n]:", missingInput)
 	if err != nil {
 		return false, err
 	}
@@ -239,25 +533,27 @@ func (c *BaseCommand) AskYesNo(query string) (bool, error) {
 			return true, nil
 		}
 
-		res, err = c.UI.Ask("Could not understand response, try again [y/n]:")
-		if err != nil && err != io.EOF {
+		res, err = c.ask("Could not understand response, try again [y/n]:", missingInput)
+		if err != nil {
 			return false, err
 		}
 	}
 }
 
-// Ask is used to prompt the user for input
-func (c *BaseCommand) Ask(query string, defaultVal string) (string, error) {
+// Ask is used to prompt the user for input. flagName names the flag that supplies the answer instead, if any
+func (c *BaseCommand) Ask(query, defaultVal, flagName string) (string, error) {
 	if c.flagYes && defaultVal != "" {
 		c.UI.Info(fmt.Sprintf("%s [%s]: %s", query, defaultVal, defaultVal))
 		return defaultVal, nil
 	}
 
+	missingInput := ErrMissingInput{Prompt: query, Flag: flagName, HasDefault: defaultVal != ""}
+
 	var defaultClause string
 	if defaultVal != "" {
 		defaultClause = fmt.Sprintf(" [%s]", defaultVal)
 	}
-	res, err := c.UI.Ask(fmt.Sprintf("%s%s:", query, defaultClause))
+	res, err := c.ask(fmt.Sprintf("%s%s:", query, defaultClause), missingInput)
 	if err != nil {
 		return "", err
 	}
@@ -277,25 +573,28 @@ func (c *BaseCommand) Ask(query string, defaultVal string) (string, error) {
 			return answer, nil
 		}
 
-		res, err = c.UI.Ask(fmt.Sprintf("Could not understand response, try again%s:", defaultClause))
-		if err != nil && err != io.EOF {
+		res, err = c.ask(fmt.Sprintf("Could not understand response, try again%s:", defaultClause), missingInput)
+		if err != nil {
 			return "", err
 		}
 	}
 }
 
-// AskWithOptions is used to prompt user for input from a list of options
-func (c *BaseCommand) AskWithOptions(query, defaultValue string, options []string) (string, error) {
+// AskWithOptions is used to prompt user for input from a list of options. flagName names the flag that
+// supplies the answer instead, if any
+func (c *BaseCommand) AskWithOptions(query, defaultValue string, options []string, flagName string) (string, error) {
 	if c.flagYes && defaultValue != "" {
 		c.UI.Info(fmt.Sprintf("%s [%s]: %s", query, defaultValue, defaultValue))
 		return defaultValue, nil
 	}
 
+	missingInput := ErrMissingInput{Prompt: query, Flag: flagName, HasDefault: defaultValue != ""}
+
 	var defaultClause string
 	if defaultValue != "" {
 		defaultClause = fmt.Sprintf(" [%s]", defaultValue)
 	}
-	res, err := c.UI.Ask(fmt.Sprintf("%s%s:", query, defaultClause))
+	res, err := c.ask(fmt.Sprintf("%s%s:", query, defaultClause), missingInput)
 	if err != nil {
 		return "", err
 	}
@@ -315,8 +614,8 @@ func (c *BaseCommand) AskWithOptions(query, defaultValue string, options []strin
 			}
 		}
 
-		res, err = c.UI.Ask(fmt.Sprintf("Could not understand response, valid values are %s:", strings.Join(options, ", ")))
-		if err != nil && err != io.EOF {
+		res, err = c.ask(fmt.Sprintf("Could not understand response, valid values are %s:", strings.Join(options, ", ")), missingInput)
+		if err != nil {
 			return "", err
 		}
 	}
@@ -329,11 +628,87 @@ func (c *BaseCommand) Help() string {
   --config-path [string]
 	File to write user configuration data to (defaults to ~/.config/realm/realm)
 
+  --credential-store [file|encrypted|command] (default: $REALM_CLI_CREDENTIAL_STORE or file)
+	How user credentials are stored.
+	file - a plaintext file at --config-path.
+	encrypted - a file next to --config-path encrypted with a passphrase ($REALM_CLI_CREDENTIAL_PASSPHRASE, or prompted for) or --credential-key-file.
+	command - delegated to the secret-store command given by --credential-command.
+	Plaintext credentials are migrated to the selected store the first time it is used.
+
+  --credential-key-file [string] (default: $REALM_CLI_CREDENTIAL_KEY_FILE)
+	File containing the key used to encrypt credentials with the "encrypted" credential store.
+
+  --credential-command [string] (default: $REALM_CLI_CREDENTIAL_COMMAND)
+	Secret-store command used by the "command" credential store. It is run with a trailing "get" argument and
+	must print the stored credentials, and with a trailing "store" argument to save the credentials given on stdin.
+
+  --credentials-stdin
+	Read a JSON credentials document from stdin, e.g. {"public_api_key": "...", "private_api_key": "...", "base_url": "...", "atlas_base_url": "..."}.
+	Any value it omits is read from $REALM_CLI_PUBLIC_API_KEY, $REALM_CLI_PRIVATE_API_KEY, $REALM_CLI_BASE_URL, or $REALM_CLI_ATLAS_BASE_URL.
+	Commands other than 'login' authenticate with supplied API keys without writing them or their tokens to the config file.
+
+  --ephemeral
+	Keep user credentials in memory only. Nothing is read from or written to the config file.
+
+  --profile [string]
+	The named profile to read and write user credentials with (defaults to the profile selected with 'profiles use', or "default")
+
+  --retry-attempts [int] (default: 3)
+	The number of times a request is sent before giving up when the server is unavailable or rate limits the CLI.
+	Only requests that are safe to send again, such as reads and hosting uploads, are retried. Use 1 to disable retries.
+
+  --request-timeout [duration]
+	How long to wait for each request to Realm or Atlas before giving up, e.g. "30s" or "2m" (defaults to no
+	timeout for Realm and 20s for Atlas)
+
+  --debug
+	Log every request made to the server, and its response, to stderr as JSON lines.
+	Authorization headers, API keys, tokens, and secret values are redacted.
+
+  --trace-file [string]
+	Append the requests logged by --debug to the provided file instead of stderr, e.g. to attach to a support ticket.
+
+  --record-cassette [string]
+	Record every request made to the server, and its response, to the provided cassette file with credentials redacted.
+
+  --replay-cassette [string]
+	Respond to requests with the responses recorded in the provided cassette file instead of contacting the server.
+
+  --proxy [string] (default: $REALM_CLI_PROXY, or $HTTPS_PROXY and $HTTP_PROXY)
+	The URL of the proxy every request is sent through, e.g. "http://proxy.example.com:3128".
+
+  --ca-bundle [string] (default: $REALM_CLI_CA_BUNDLE)
+	PEM file of root certificates to trust in addition to the system's, e.g. a private corporate root CA.
+
+  --client-cert [string] (default: $REALM_CLI_CLIENT_CERT)
+	PEM file of the client certificate presented to servers that require mutual TLS. Requires --client-key.
+
+  --client-key [string] (default: $REALM_CLI_CLIENT_KEY)
+	PEM file of the private key of --client-cert.
+
+  --insecure-skip-verify (default: $REALM_CLI_INSECURE_SKIP_VERIFY)
+	Do not verify server certificates. Only use this with local servers that have self-signed certificates.
+
+  --output, --output-format [table|json|yaml] (default: table)
+	The format the result of the command is written to stdout in. With json or yaml, progress messages are written
+	to stderr so that stdout only holds the result. The 'export' command only accepts --output-format, as its
+	--output flag sets the destination directory.
+
   --disable-color
 	Disable the use of colors in terminal output.
 
+  --env [string]
+	The environment declared in the project config file (` + project.ConfigFileName + `) to target. Its app_id,
+	project_id and profile, and the flag values set for it, are used in place of the defaults, so requests are
+	sent to the base URL the profile was logged in with. 'import' and 'diff' merge the JSON files of the app's
+	environments/[string] directory over the app's own files.
+
   -y, --yes
-	Bypass prompts. Provide this parameter if you do not want to be prompted for input.`
+	Bypass prompts. Provide this parameter if you do not want to be prompted for input.
+
+  --non-interactive
+	Fail instead of prompting for input that was not supplied with flags, naming the flag to supply.
+	This is enabled automatically when stdin is not a terminal, e.g. in a CI job.`
 }
 
 func yay(s string) bool {
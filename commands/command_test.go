@@ -1,11 +1,19 @@
 package commands
 
 import (
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/10gen/realm-cli/api"
 	"github.com/10gen/realm-cli/auth"
+	"github.com/10gen/realm-cli/storage"
 	"github.com/10gen/realm-cli/user"
 	u "github.com/10gen/realm-cli/utils/test"
 	gc "github.com/smartystreets/goconvey/convey"
@@ -30,6 +38,35 @@ func TestBaseCommandClient(t *testing.T) {
 
 		u.So(t, base.client, gc.ShouldNotBeNil)
 	})
+
+	t.Run("should time out requests to Realm and Atlas after --request-timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer server.Close()
+
+		base := &BaseCommand{
+			UI:      cli.NewMockUi(),
+			storage: u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken()),
+		}
+		u.So(t, base.run([]string{"--base-url=" + server.URL, "--atlas-base-url=" + server.URL, "--request-timeout=20ms", "--retry-attempts=1"}), gc.ShouldBeNil)
+
+		client, err := base.Client()
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = client.ExecuteRequest(http.MethodGet, "/api/admin/v3.0/groups", api.RequestOptions{})
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		atlasClient, err := base.AtlasClient()
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = atlasClient.Groups()
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "request timed out after 20ms")
+	})
 }
 
 func TestBaseCommandUser(t *testing.T) {
@@ -237,3 +274,351 @@ func TestBaseCommandAsk(t *testing.T) {
 		}
 	})
 }
+
+func TestBaseCommandNonInteractive(t *testing.T) {
+	t.Run("should fail with the flag to supply instead of prompting", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		baseCommand := &BaseCommand{UI: mockUI, flagNonInteractive: true}
+
+		_, err := baseCommand.AskYesNo("continue?")
+		u.So(t, err, gc.ShouldResemble, ErrMissingInput{Prompt: "continue?", Flag: flagYesName})
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "continue?" without input: supply --yes to confirm`)
+
+		_, err = baseCommand.Ask("App name", "", importFlagAppName)
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "App name" without input: supply --app-name`)
+
+		_, err = baseCommand.AskWithOptions("Location", "US-VA", locationOptions, "")
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "Location" without input: supply --yes to accept the default`)
+
+		_, err = baseCommand.AskSecret("Password:", "password")
+		u.So(t, err.Error(), gc.ShouldEqual, `cannot prompt for "Password" without input: supply --password`)
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldBeEmpty)
+	})
+
+	t.Run("should still use --yes to answer prompts", func(t *testing.T) {
+		baseCommand := &BaseCommand{UI: cli.NewMockUi(), flagNonInteractive: true, flagYes: true}
+
+		answer, err := baseCommand.Ask("App name", "my-app", importFlagAppName)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, answer, gc.ShouldEqual, "my-app")
+	})
+
+	t.Run("should fail instead of prompting again once stdin is exhausted", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		mockUI.InputReader = strings.NewReader("nah\n")
+		baseCommand := &BaseCommand{UI: mockUI}
+
+		_, err := baseCommand.AskYesNo("continue?")
+		u.So(t, err, gc.ShouldResemble, ErrMissingInput{Prompt: "continue?", Flag: flagYesName})
+	})
+
+	t.Run("should exit with the missing input exit code", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		baseCommand := &BaseCommand{UI: mockUI}
+
+		exitCode := baseCommand.fail(fmt.Errorf("failed to create draft for import: %w", ErrMissingInput{Prompt: "continue?", Flag: flagYesName}))
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeMissingInput)
+
+		// the flag package exits with 2 for invalid flags
+		u.So(t, exitCode, gc.ShouldNotEqual, 2)
+	})
+}
+
+func TestBaseCommandStorageStrategy(t *testing.T) {
+	setup := func(t *testing.T) (*BaseCommand, *cli.MockUi, string) {
+		dir, err := ioutil.TempDir("", "realm-cli-config")
+		u.So(t, err, gc.ShouldBeNil)
+
+		path := filepath.Join(dir, "realm")
+		u.So(t, ioutil.WriteFile(path, []byte("private_api_key: my-private-api-key\n"), 0600), gc.ShouldBeNil)
+
+		mockUI := cli.NewMockUi()
+		return &BaseCommand{UI: mockUI}, mockUI, path
+	}
+
+	t.Run("should reject an unknown credential store", func(t *testing.T) {
+		base, _, path := setup(t)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		base.flagCredentialStore = "keychain"
+
+		_, err := base.newStorageStrategy(path)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, `unknown credential store "keychain"`)
+	})
+
+	t.Run("should migrate plaintext credentials into the encrypted credential store", func(t *testing.T) {
+		base, mockUI, path := setup(t)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		base.flagCredentialStore = credentialStoreEncrypted
+		mockUI.InputReader = strings.NewReader("my-passphrase\n")
+
+		strategy, err := base.newStorageStrategy(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Migrated credentials from "+path)
+
+		_, err = os.Stat(path)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		storedUser, err := storage.New(strategy).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "my-private-api-key")
+	})
+
+	t.Run("should back up plaintext credentials when the credential store already holds other ones", func(t *testing.T) {
+		base, mockUI, path := setup(t)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		base.flagCredentialStore = credentialStoreEncrypted
+		mockUI.InputReader = strings.NewReader("my-passphrase\n")
+
+		strategy, err := base.newStorageStrategy(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storage.New(strategy).WriteUserConfig(&user.User{PrivateAPIKey: "newer-private-api-key"}), gc.ShouldBeNil)
+
+		u.So(t, ioutil.WriteFile(path, []byte("private_api_key: my-private-api-key\n"), 0600), gc.ShouldBeNil)
+
+		laterUI := cli.NewMockUi()
+		laterUI.InputReader = strings.NewReader("my-passphrase\n")
+
+		strategy, err = (&BaseCommand{UI: laterUI}).newStorageStrategy(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, laterUI.ErrorWriter.String(), gc.ShouldContainSubstring, "Moved the plaintext credentials at "+path+" to "+path+".bak")
+
+		_, err = os.Stat(path)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		backup, err := ioutil.ReadFile(path + ".bak")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(backup), gc.ShouldEqual, "private_api_key: my-private-api-key\n")
+
+		storedUser, err := storage.New(strategy).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "newer-private-api-key")
+	})
+
+	t.Run("should keep using the credential store last selected", func(t *testing.T) {
+		base, mockUI, path := setup(t)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		keyFile := filepath.Join(filepath.Dir(path), "key")
+		u.So(t, ioutil.WriteFile(keyFile, []byte("my-key\n"), 0600), gc.ShouldBeNil)
+
+		base.flagCredentialStore = credentialStoreEncrypted
+		base.flagCredentialKeyFile = keyFile
+
+		_, err := base.newStorageStrategy(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Migrated credentials from "+path)
+
+		strategy, err := (&BaseCommand{UI: cli.NewMockUi()}).newStorageStrategy(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, strategy, gc.ShouldHaveSameTypeAs, &storage.EncryptedFileStrategy{})
+
+		_, err = os.Stat(path)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		storedUser, err := storage.New(strategy).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "my-private-api-key")
+
+		t.Run("until the file credential store is selected again", func(t *testing.T) {
+			strategy, err := (&BaseCommand{UI: cli.NewMockUi(), flagCredentialStore: credentialStoreFile}).newStorageStrategy(path)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, strategy, gc.ShouldHaveSameTypeAs, &storage.FileStrategy{})
+
+			strategy, err = (&BaseCommand{UI: cli.NewMockUi()}).newStorageStrategy(path)
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, strategy, gc.ShouldHaveSameTypeAs, &storage.FileStrategy{})
+		})
+	})
+}
+
+func TestBaseCommandSuppliedCredentials(t *testing.T) {
+	setup := func() (*BaseCommand, *storage.Storage) {
+		fileStorage := u.NewPopulatedStorage("stored-api-key", "stored.refresh.token", u.GenerateValidAccessToken())
+
+		return &BaseCommand{
+			UI:      cli.NewMockUi(),
+			storage: fileStorage,
+			client: u.NewMockClient([]*http.Response{
+				{
+					StatusCode: http.StatusOK,
+					Body: u.NewAuthResponseBody(auth.Response{
+						AccessToken:  "env.access.token",
+						RefreshToken: "env.refresh.token",
+					}),
+				},
+			}),
+		}, fileStorage
+	}
+
+	t.Run("should log in with API keys from the environment without writing to storage", func(t *testing.T) {
+		os.Setenv(envPublicAPIKey, "env-public-key")
+		os.Setenv(envPrivateAPIKey, "env-private-key")
+		defer os.Unsetenv(envPublicAPIKey)
+		defer os.Unsetenv(envPrivateAPIKey)
+
+		base, fileStorage := setup()
+		u.So(t, base.run([]string{}), gc.ShouldBeNil)
+
+		currentUser, err := base.User()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, currentUser, gc.ShouldResemble, &user.User{
+			PublicAPIKey:  "env-public-key",
+			PrivateAPIKey: "env-private-key",
+			AccessToken:   "env.access.token",
+			RefreshToken:  "env.refresh.token",
+		})
+
+		storedUser, err := fileStorage.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "stored-api-key")
+	})
+
+	t.Run("should read a credentials document from stdin", func(t *testing.T) {
+		base, _ := setup()
+		base.stdin = strings.NewReader(`{"public_api_key": "stdin-public-key", "private_api_key": "stdin-private-key", "base_url": "https://example.com"}`)
+		u.So(t, base.run([]string{"--credentials-stdin"}), gc.ShouldBeNil)
+
+		baseURL, err := base.resolveBaseURL()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, baseURL, gc.ShouldEqual, "https://example.com")
+
+		currentUser, err := base.User()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, currentUser.PublicAPIKey, gc.ShouldEqual, "stdin-public-key")
+		u.So(t, currentUser.AccessToken, gc.ShouldEqual, "env.access.token")
+	})
+
+	t.Run("should reject an invalid credentials document", func(t *testing.T) {
+		base, _ := setup()
+		base.stdin = strings.NewReader(`not json`)
+		err := base.run([]string{"--credentials-stdin"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "failed to read credentials from stdin")
+	})
+
+	t.Run("should not read from storage in ephemeral mode", func(t *testing.T) {
+		base, fileStorage := setup()
+		u.So(t, base.run([]string{"--ephemeral"}), gc.ShouldBeNil)
+
+		currentUser, err := base.User()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, currentUser, gc.ShouldResemble, &user.User{})
+		u.So(t, base.storage, gc.ShouldNotEqual, fileStorage)
+	})
+}
+
+func TestBaseCommandTraceFile(t *testing.T) {
+	t.Run("should trace requests to the provided file", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		dir, err := ioutil.TempDir("", "realm-cli-trace")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		tracePath := filepath.Join(dir, "trace.jsonl")
+
+		base := &BaseCommand{UI: cli.NewMockUi(), storage: u.NewEmptyStorage()}
+		u.So(t, base.run([]string{"--trace-file=" + tracePath, "--base-url=" + server.URL}), gc.ShouldBeNil)
+
+		client, err := base.Client()
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = client.ExecuteRequest(http.MethodGet, "/api/admin/v3.0/groups", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+
+		trace, err := ioutil.ReadFile(tracePath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(trace), gc.ShouldContainSubstring, `"url":"`+server.URL+`/api/admin/v3.0/groups"`)
+		u.So(t, string(trace), gc.ShouldContainSubstring, `"status":204`)
+
+		fds, err := ioutil.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Skip("open files cannot be listed on this platform")
+		}
+		for _, fd := range fds {
+			target, _ := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+			u.So(t, target, gc.ShouldNotEqual, tracePath)
+		}
+	})
+
+	t.Run("should fail when the trace file cannot be opened", func(t *testing.T) {
+		base := &BaseCommand{UI: cli.NewMockUi(), storage: u.NewEmptyStorage()}
+
+		err := base.run([]string{"--trace-file=" + filepath.Join(os.TempDir(), "realm-cli-missing", "trace.jsonl")})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldStartWith, "failed to open trace file")
+	})
+}
+
+func TestBaseCommandTransport(t *testing.T) {
+	setup := func(t *testing.T, profile *user.User) *BaseCommand {
+		store := u.NewEmptyStorage()
+		u.So(t, store.WriteUserConfig(profile), gc.ShouldBeNil)
+
+		return &BaseCommand{UI: cli.NewMockUi(), storage: store}
+	}
+
+	t.Run("should send requests through the proxy stored with the profile", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer proxy.Close()
+
+		base := setup(t, &user.User{ProxyURL: proxy.URL})
+		u.So(t, base.run([]string{"--base-url=http://realm.example.com"}), gc.ShouldBeNil)
+
+		client, err := base.Client()
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = client.ExecuteRequest(http.MethodGet, "/api/admin/v3.0/groups", api.RequestOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, proxied, gc.ShouldEqual, "http://realm.example.com/api/admin/v3.0/groups")
+	})
+
+	t.Run("should prefer flags to the environment and the environment to the profile", func(t *testing.T) {
+		os.Setenv(envProxy, "http://env-proxy.example.com")
+		os.Setenv(envInsecureSkipVerify, "false")
+		defer os.Unsetenv(envProxy)
+		defer os.Unsetenv(envInsecureSkipVerify)
+
+		base := setup(t, &user.User{ProxyURL: "http://profile-proxy.example.com", ClientCert: "client.pem", InsecureSkipVerify: true})
+		base.NewFlagSet()
+		u.So(t, base.Parse([]string{"--client-cert=flag-client.pem"}), gc.ShouldBeNil)
+
+		config, err := base.transportConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, config, gc.ShouldResemble, api.TransportConfig{
+			ProxyURL:       "http://env-proxy.example.com",
+			ClientCertPath: "flag-client.pem",
+		})
+	})
+
+	t.Run("should reject an invalid insecure-skip-verify environment variable", func(t *testing.T) {
+		os.Setenv(envInsecureSkipVerify, "maybe")
+		defer os.Unsetenv(envInsecureSkipVerify)
+
+		base := setup(t, &user.User{})
+		err := base.run([]string{})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, envInsecureSkipVerify)
+	})
+
+	t.Run("should fail when the CA bundle cannot be read", func(t *testing.T) {
+		base := setup(t, &user.User{})
+		err := base.run([]string{"--ca-bundle=/does/not/exist.pem"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "failed to read CA bundle")
+	})
+}
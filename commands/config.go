@@ -0,0 +1,260 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/10gen/realm-cli/project"
+
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	configFlagCommand = "command"
+)
+
+// projectConfigBlockedFlags are the flags a project config file cannot set. The file comes with the directory it is
+// in, so it must not choose where credentials are sent, stored or read from, nor how requests are made or recorded.
+// Its hooks can run any command, but only during an import, which prints each of them before running it, while
+// these flags would apply silently to every command run in the directory, e.g. 'whoami' or 'secrets list'
+var projectConfigBlockedFlags = map[string]bool{
+	flagBaseURLName:            true,
+	flagAtlasBaseURLName:       true,
+	flagProfileName:            true,
+	flagConfigPathName:         true,
+	flagCredentialStoreName:    true,
+	flagCredentialKeyFileName:  true,
+	flagCredentialCommandName:  true,
+	flagProxyName:              true,
+	flagCABundleName:           true,
+	flagClientCertName:         true,
+	flagClientKeyName:          true,
+	flagInsecureSkipVerifyName: true,
+	flagTraceFileName:          true,
+	flagRecordCassetteName:     true,
+	flagReplayCassetteName:     true,
+}
+
+// projectDirectory returns the directory the project config file is searched for from: the app directory given
+// by --path, if the command has one, or the working directory
+func (c *BaseCommand) projectDirectory() (string, error) {
+	if pathFlag := c.Lookup(importFlagPath); pathFlag != nil && pathFlag.Value.String() != "" {
+		return homedir.Expand(pathFlag.Value.String())
+	}
+
+	return os.Getwd()
+}
+
+// applyProjectConfig gives every flag that was not supplied the value set for it by the project config file, if any
+func (c *BaseCommand) applyProjectConfig() error {
+	c.explicitFlags = map[string]bool{}
+	c.Visit(func(f *flag.Flag) {
+		c.explicitFlags[f.Name] = true
+	})
+
+	dir, err := c.projectDirectory()
+	if err != nil {
+		return err
+	}
+
+	config, err := project.Find(dir)
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		if c.flagEnv != "" {
+			return fmt.Errorf("--%s requires a %s project config file in the app directory", flagEnvName, project.ConfigFileName)
+		}
+		return nil
+	}
+	c.projectConfig = config
+
+	settings, err := config.Resolve(c.Name, c.flagEnv)
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range settings {
+		// an environment may name the profile its app is reached with, as that only chooses among the base URLs
+		// and credentials that were stored by logging in
+		if projectConfigBlockedFlags[setting.Flag] && !(setting.DeployTarget && setting.Flag == flagProfileName) {
+			err := fmt.Errorf("%s: %s sets --%s, which can only be set by flag, environment variable or profile", config.Path, setting.Source, setting.Flag)
+			if setting.Flag == flagBaseURLName || setting.Flag == flagAtlasBaseURLName {
+				err = fmt.Errorf(
+					"%s; to reach another server, log in to it with 'realm-cli login --profile=[name] --%s=[url]' and set the environment's profile instead",
+					err,
+					setting.Flag,
+				)
+			}
+			return err
+		}
+
+		if c.Lookup(setting.Flag) == nil {
+			if setting.CommandSpecific {
+				return fmt.Errorf("%s: %s sets --%s, which is not a flag of '%s'", config.Path, setting.Source, setting.Flag, c.Name)
+			}
+			continue
+		}
+
+		if c.explicitFlags[setting.Flag] {
+			continue
+		}
+
+		if err := c.Set(setting.Flag, setting.Value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for --%s in %s: %s", config.Path, setting.Value, setting.Flag, setting.Source, err)
+		}
+	}
+
+	return nil
+}
+
+// NewConfigCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewConfigCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &ConfigCommand{
+			BaseCommand: &BaseCommand{
+				Name: "config",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// ConfigCommand is used to inspect the project config file
+type ConfigCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (cc *ConfigCommand) Synopsis() string {
+	return "Inspect the settings of the project config file."
+}
+
+// Help returns long-form help information for this command
+func (cc *ConfigCommand) Help() string {
+	return cc.Synopsis()
+}
+
+// Run executes the command
+func (cc *ConfigCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// NewConfigShowCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewConfigShowCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &ConfigShowCommand{
+			BaseCommand: &BaseCommand{
+				Name: "config show",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// ConfigShowCommand is used to print the settings a command is run with, and where each came from
+type ConfigShowCommand struct {
+	*BaseCommand
+
+	flagAppPath string
+	flagCommand string
+}
+
+// configShowResult describes the resolved settings of a command in structured output
+type configShowResult struct {
+	Path        string          `json:"path" yaml:"path"`
+	Command     string          `json:"command,omitempty" yaml:"command,omitempty"`
+	Environment string          `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Settings    []settingResult `json:"settings" yaml:"settings"`
+}
+
+// settingResult describes a resolved flag value, and where it came from, in structured output
+type settingResult struct {
+	Flag   string `json:"flag" yaml:"flag"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source" yaml:"source"`
+}
+
+// Synopsis returns a one-liner description for this command
+func (csc *ConfigShowCommand) Synopsis() string {
+	return "Print the settings a command is run with, and where each came from."
+}
+
+// Help returns long-form help information for this command
+func (csc *ConfigShowCommand) Help() string {
+	return `Print the flag values the project config file (` + project.ConfigFileName + `) gives a command, and the section
+of the file each came from. Global flags supplied to 'config show' are shown in place of the values they override.
+
+Usage: realm-cli config show [options]
+
+OPTIONS:
+  --command [string]
+	The command to show the settings of, e.g. "import" or "secrets list" (defaults to the settings every command shares)
+
+  --path [string]
+	A path to the local directory containing your app (defaults to the working directory)` +
+		csc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (csc *ConfigShowCommand) Run(args []string) int {
+	set := csc.NewFlagSet()
+
+	set.StringVar(&csc.flagAppPath, importFlagPath, "", "")
+	set.StringVar(&csc.flagCommand, configFlagCommand, "", "")
+
+	if err := csc.BaseCommand.run(args); err != nil {
+		return csc.fail(err)
+	}
+
+	if err := csc.show(); err != nil {
+		return csc.fail(err)
+	}
+
+	return 0
+}
+
+func (csc *ConfigShowCommand) show() error {
+	if csc.projectConfig == nil {
+		return fmt.Errorf("no %s project config file was found", project.ConfigFileName)
+	}
+
+	settings, err := csc.projectConfig.Resolve(csc.flagCommand, csc.flagEnv)
+	if err != nil {
+		return err
+	}
+
+	result := configShowResult{
+		Path:        csc.projectConfig.Path,
+		Command:     csc.flagCommand,
+		Environment: csc.flagEnv,
+		Settings:    make([]settingResult, 0, len(settings)),
+	}
+
+	for _, setting := range settings {
+		value, source := setting.Value, setting.Source
+		if f := csc.Lookup(setting.Flag); f != nil && csc.explicitFlags[setting.Flag] {
+			value, source = f.Value.String(), "flag"
+		}
+
+		result.Settings = append(result.Settings, settingResult{Flag: setting.Flag, Value: value, Source: source})
+	}
+
+	if csc.structuredOutput() {
+		return csc.writeResult(result)
+	}
+
+	csc.UI.Info(fmt.Sprintf("Project config: %s", result.Path))
+	if result.Environment != "" {
+		csc.UI.Info(fmt.Sprintf("Environment: %s", result.Environment))
+	}
+
+	for _, setting := range result.Settings {
+		csc.UI.Info(fmt.Sprintf("  --%s=%s (%s)", setting.Flag, setting.Value, setting.Source))
+	}
+
+	return nil
+}
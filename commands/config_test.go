@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/project"
+	"github.com/10gen/realm-cli/user"
+	u "github.com/10gen/realm-cli/utils/test"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+const testProjectConfig = `defaults:
+  include-hosting: true
+commands:
+  import:
+    strategy: replace-by-name
+environments:
+  staging:
+    defaults:
+      app-id: my-app-staging
+    commands:
+      import:
+        strategy: merge
+`
+
+func setUpProjectConfig(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "realm-cli-project")
+	u.So(t, err, gc.ShouldBeNil)
+
+	u.So(t, ioutil.WriteFile(filepath.Join(dir, project.ConfigFileName), []byte(contents), 0600), gc.ShouldBeNil)
+
+	return dir
+}
+
+func TestBaseCommandProjectConfig(t *testing.T) {
+	setup := func(name string) (*BaseCommand, *string, *bool) {
+		base := &BaseCommand{Name: name, UI: cli.NewMockUi(), storage: u.NewEmptyStorage()}
+
+		var strategy, path string
+		var includeHosting bool
+
+		set := base.NewFlagSet()
+		set.StringVar(&path, importFlagPath, "", "")
+		set.StringVar(&strategy, importFlagStrategy, importStrategyMerge, "")
+		set.BoolVar(&includeHosting, importFlagIncludeHosting, false, "")
+
+		return base, &strategy, &includeHosting
+	}
+
+	dir := setUpProjectConfig(t, testProjectConfig)
+	defer os.RemoveAll(dir)
+
+	t.Run("should give flags that are not supplied the values of the project config", func(t *testing.T) {
+		base, strategy, includeHosting := setup("import")
+
+		u.So(t, base.run([]string{"--path=" + dir}), gc.ShouldBeNil)
+		u.So(t, *strategy, gc.ShouldEqual, importStrategyReplaceByName)
+		u.So(t, *includeHosting, gc.ShouldBeTrue)
+	})
+
+	t.Run("should use the values of the selected environment", func(t *testing.T) {
+		base, strategy, _ := setup("import")
+
+		u.So(t, base.run([]string{"--path=" + dir, "--env=staging"}), gc.ShouldBeNil)
+		u.So(t, *strategy, gc.ShouldEqual, importStrategyMerge)
+	})
+
+	t.Run("should not override supplied flags", func(t *testing.T) {
+		base, strategy, includeHosting := setup("import")
+
+		u.So(t, base.run([]string{"--path=" + dir, "--strategy=replace", "--include-hosting=false"}), gc.ShouldBeNil)
+		u.So(t, *strategy, gc.ShouldEqual, importStrategyReplace)
+		u.So(t, *includeHosting, gc.ShouldBeFalse)
+	})
+
+	t.Run("should fail when a command is given a flag it does not have", func(t *testing.T) {
+		badDir := setUpProjectConfig(t, "commands:\n  import:\n    strateggy: merge\n")
+		defer os.RemoveAll(badDir)
+
+		base, _, _ := setup("import")
+
+		err := base.run([]string{"--path=" + badDir})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "commands.import sets --strateggy, which is not a flag of 'import'")
+	})
+
+	t.Run("should fail when the project config sets a flag only a user can set", func(t *testing.T) {
+		for _, tc := range []struct {
+			contents string
+			args     []string
+		}{
+			{contents: "defaults:\n  credential-store: command\n  credential-command: ./steal-credentials.sh\n"},
+			{contents: "defaults:\n  base-url: https://realm.example.com\n"},
+			{contents: "commands:\n  import:\n    trace-file: /tmp/trace.log\n"},
+			{
+				contents: "environments:\n  staging:\n    defaults:\n      insecure-skip-verify: true\n",
+				args:     []string{"--env=staging"},
+			},
+		} {
+			blockedDir := setUpProjectConfig(t, tc.contents)
+			defer os.RemoveAll(blockedDir)
+
+			base, _, _ := setup("import")
+
+			err := base.run(append([]string{"--path=" + blockedDir}, tc.args...))
+			u.So(t, err, gc.ShouldNotBeNil)
+			u.So(t, err.Error(), gc.ShouldContainSubstring, "which can only be set by flag, environment variable or profile")
+		}
+	})
+
+	t.Run("should suggest an environment's profile when the project config sets a base URL", func(t *testing.T) {
+		blockedDir := setUpProjectConfig(t, "environments:\n  staging:\n    defaults:\n      base-url: https://staging.example.com\n")
+		defer os.RemoveAll(blockedDir)
+
+		base, _, _ := setup("import")
+
+		err := base.run([]string{"--path=" + blockedDir, "--env=staging"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldEndWith, "log in to it with 'realm-cli login --profile=[name] --base-url=[url]' and set the environment's profile instead")
+	})
+
+	t.Run("should send requests to the base URL of the profile the selected environment names", func(t *testing.T) {
+		var requested []string
+		newServer := func(name string) *httptest.Server {
+			return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = append(requested, name+" "+r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+		}
+
+		prodServer := newServer("prod")
+		defer prodServer.Close()
+		stagingServer := newServer("staging")
+		defer stagingServer.Close()
+
+		envDir := setUpProjectConfig(t, "environments:\n  staging:\n    app_id: my-app-staging\n    profile: staging\n")
+		defer os.RemoveAll(envDir)
+
+		store := u.NewEmptyStorage()
+		u.So(t, store.WriteUserConfig(&user.User{PublicAPIKey: "prod", PrivateAPIKey: "prod-key", BaseURL: prodServer.URL}), gc.ShouldBeNil)
+		u.So(t, store.WithProfile("staging").WriteUserConfig(&user.User{PublicAPIKey: "staging", PrivateAPIKey: "staging-key", BaseURL: stagingServer.URL}), gc.ShouldBeNil)
+
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{args: []string{"--env=staging"}, expected: "staging /api/admin/v3.0/groups"},
+			{expected: "prod /api/admin/v3.0/groups"},
+		} {
+			requested = nil
+
+			base, _, _ := setup("import")
+			base.storage = store
+
+			u.So(t, base.run(append([]string{"--path=" + envDir}, tc.args...)), gc.ShouldBeNil)
+
+			client, err := base.Client()
+			u.So(t, err, gc.ShouldBeNil)
+
+			_, err = client.ExecuteRequest(http.MethodGet, "/api/admin/v3.0/groups", api.RequestOptions{})
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, requested, gc.ShouldResemble, []string{tc.expected})
+		}
+	})
+
+	t.Run("should only take the profile from the fields naming the environment's deploy target", func(t *testing.T) {
+		blockedDir := setUpProjectConfig(t, "environments:\n  staging:\n    defaults:\n      profile: staging\n")
+		defer os.RemoveAll(blockedDir)
+
+		base, _, _ := setup("import")
+
+		err := base.run([]string{"--path=" + blockedDir, "--env=staging"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "environments.staging.defaults sets --profile")
+	})
+
+	t.Run("should fail when --env is supplied without a project config", func(t *testing.T) {
+		emptyDir, err := ioutil.TempDir("", "realm-cli-project")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(emptyDir)
+
+		base, _, _ := setup("import")
+
+		err = base.run([]string{"--path=" + emptyDir, "--env=staging"})
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "--env requires a .realm-cli.yaml project config file")
+	})
+}
+
+func TestConfigShowCommand(t *testing.T) {
+	setup := func() (*ConfigShowCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewConfigShowCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		showCommand := cmd.(*ConfigShowCommand)
+		showCommand.storage = u.NewEmptyStorage()
+
+		return showCommand, mockUI
+	}
+
+	dir := setUpProjectConfig(t, testProjectConfig)
+	defer os.RemoveAll(dir)
+
+	t.Run("should print the settings of the command and where each came from", func(t *testing.T) {
+		showCommand, mockUI := setup()
+
+		exitCode := showCommand.Run([]string{"--path=" + dir, "--command=import", "--env=staging"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Project config: "+filepath.Join(dir, project.ConfigFileName)+`
+Environment: staging
+  --app-id=my-app-staging (environments.staging.defaults)
+  --include-hosting=true (defaults)
+  --strategy=merge (environments.staging.commands.import)
+`)
+	})
+
+	t.Run("should write the settings as JSON with supplied flags taking precedence", func(t *testing.T) {
+		overrideDir := setUpProjectConfig(t, "defaults:\n  include-hosting: true\n  retry-attempts: 3\n")
+		defer os.RemoveAll(overrideDir)
+
+		showCommand, mockUI := setup()
+
+		exitCode := showCommand.Run([]string{"--path=" + overrideDir, "--retry-attempts=5", "--output=json"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result configShowResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Settings, gc.ShouldResemble, []settingResult{
+			{Flag: "include-hosting", Value: "true", Source: "defaults"},
+			{Flag: "retry-attempts", Value: "5", Source: "flag"},
+		})
+	})
+
+	t.Run("should fail without a project config", func(t *testing.T) {
+		emptyDir, err := ioutil.TempDir("", "realm-cli-project")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(emptyDir)
+
+		showCommand, mockUI := setup()
+
+		exitCode := showCommand.Run([]string{"--path=" + emptyDir})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "no .realm-cli.yaml project config file was found")
+	})
+}
@@ -0,0 +1,209 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/10gen/realm-cli/storage"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	flagCredentialStoreName   = "credential-store"
+	flagCredentialKeyFileName = "credential-key-file"
+	flagCredentialCommandName = "credential-command"
+
+	credentialStoreFile      = "file"
+	credentialStoreEncrypted = "encrypted"
+	credentialStoreCommand   = "command"
+
+	envCredentialStore      = "REALM_CLI_CREDENTIAL_STORE"
+	envCredentialKeyFile    = "REALM_CLI_CREDENTIAL_KEY_FILE"
+	envCredentialPassphrase = "REALM_CLI_CREDENTIAL_PASSPHRASE"
+	envCredentialCommand    = "REALM_CLI_CREDENTIAL_COMMAND"
+
+	encryptedCredentialsExt = ".enc"
+
+	// credentialStoreSettingsExt is the extension of the file next to the config path that remembers the
+	// selected credential store
+	credentialStoreSettingsExt = ".store"
+)
+
+// credentialStoreSettings is the credential store selected by flag or environment variable, which is
+// remembered so that later commands keep using it. The passphrase is never remembered
+type credentialStoreSettings struct {
+	Store   string `yaml:"store"`
+	KeyFile string `yaml:"key_file,omitempty"`
+	Command string `yaml:"command,omitempty"`
+}
+
+// newStorageStrategy returns the storage.Strategy selected by the credential store flags or
+// their environment variables, or else the one last selected, migrating any plaintext credentials
+// found at path into it
+func (c *BaseCommand) newStorageStrategy(path string) (storage.Strategy, error) {
+	settings, err := c.credentialStoreSettings(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var strategy storage.Strategy
+
+	switch settings.Store {
+	case "", credentialStoreFile:
+		return storage.NewFileStrategy(path)
+	case credentialStoreEncrypted:
+		secret, secretErr := c.credentialSecret(settings.KeyFile)
+		if secretErr != nil {
+			return nil, secretErr
+		}
+
+		strategy, err = storage.NewEncryptedFileStrategy(path+encryptedCredentialsExt, secret)
+	case credentialStoreCommand:
+		strategy, err = storage.NewCommandStrategy(settings.Command)
+	default:
+		return nil, fmt.Errorf(
+			"unknown credential store %q; accepted values are [%s|%s|%s]",
+			settings.Store,
+			credentialStoreFile,
+			credentialStoreEncrypted,
+			credentialStoreCommand,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := storage.MigrateFile(path, strategy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate credentials from %s: %s", path, err)
+	}
+
+	switch result {
+	case storage.MigrationMoved:
+		c.UI.Info(fmt.Sprintf("Migrated credentials from %s to the %s credential store", path, settings.Store))
+	case storage.MigrationBackedUp:
+		c.UI.Warn(fmt.Sprintf(
+			"Moved the plaintext credentials at %s to %s, since the %s credential store already holds other credentials",
+			path,
+			storage.MigrationBackupPath(path),
+			settings.Store,
+		))
+	}
+
+	return strategy, nil
+}
+
+// credentialStoreSettings returns the credential store selected by the flags or their environment
+// variables, remembering it next to path. Without either, it returns the store last selected
+func (c *BaseCommand) credentialStoreSettings(path string) (credentialStoreSettings, error) {
+	settingsPath := path + credentialStoreSettingsExt
+
+	settings := credentialStoreSettings{
+		Store:   flagOrEnv(c.flagCredentialStore, envCredentialStore),
+		KeyFile: flagOrEnv(c.flagCredentialKeyFile, envCredentialKeyFile),
+		Command: flagOrEnv(c.flagCredentialCommand, envCredentialCommand),
+	}
+
+	if settings.Store == "" {
+		data, err := ioutil.ReadFile(settingsPath)
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		if err != nil {
+			return settings, fmt.Errorf("failed to read the selected credential store: %s", err)
+		}
+
+		var saved credentialStoreSettings
+		if err := yaml.Unmarshal(data, &saved); err != nil {
+			return settings, fmt.Errorf("failed to read the selected credential store from %s: %s", settingsPath, err)
+		}
+
+		settings.Store = saved.Store
+		if settings.KeyFile == "" {
+			settings.KeyFile = saved.KeyFile
+		}
+		if settings.Command == "" {
+			settings.Command = saved.Command
+		}
+		return settings, nil
+	}
+
+	if settings.KeyFile != "" {
+		keyFile, err := homedir.Expand(settings.KeyFile)
+		if err != nil {
+			return settings, err
+		}
+
+		if settings.KeyFile, err = filepath.Abs(keyFile); err != nil {
+			return settings, err
+		}
+	}
+
+	if settings.Store == credentialStoreFile {
+		if err := os.Remove(settingsPath); err != nil && !os.IsNotExist(err) {
+			return settings, err
+		}
+		return settings, nil
+	}
+
+	if settings.Store != credentialStoreEncrypted && settings.Store != credentialStoreCommand {
+		return settings, nil
+	}
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return settings, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0700); err != nil {
+		return settings, err
+	}
+
+	if err := ioutil.WriteFile(settingsPath, data, 0600); err != nil {
+		return settings, fmt.Errorf("failed to remember the selected credential store: %s", err)
+	}
+
+	return settings, nil
+}
+
+// credentialSecret returns the contents of the credential key file if there is one, otherwise
+// the credential passphrase, prompting for it if it is not set in the environment
+func (c *BaseCommand) credentialSecret(keyFile string) ([]byte, error) {
+	if keyFile != "" {
+		path, err := homedir.Expand(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credential key file: %s", err)
+		}
+
+		return bytes.TrimSpace(key), nil
+	}
+
+	if passphrase := os.Getenv(envCredentialPassphrase); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	passphrase, err := c.AskSecret("Credential store passphrase:", flagCredentialKeyFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(passphrase), nil
+}
+
+func flagOrEnv(flagValue, envName string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	return os.Getenv(envName)
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/auth"
+	"github.com/10gen/realm-cli/storage"
+	"github.com/10gen/realm-cli/user"
+)
+
+const (
+	flagCredentialsStdinName = "credentials-stdin"
+	flagEphemeralName        = "ephemeral"
+
+	envPublicAPIKey  = "REALM_CLI_PUBLIC_API_KEY"
+	envPrivateAPIKey = "REALM_CLI_PRIVATE_API_KEY"
+	envBaseURL       = "REALM_CLI_BASE_URL"
+	envAtlasBaseURL  = "REALM_CLI_ATLAS_BASE_URL"
+)
+
+// credentials are the API keys and server URLs supplied through the environment or a JSON document
+// on stdin instead of through flags or storage
+type credentials struct {
+	PublicAPIKey  string `json:"public_api_key"`
+	PrivateAPIKey string `json:"private_api_key"`
+	BaseURL       string `json:"base_url"`
+	AtlasBaseURL  string `json:"atlas_base_url"`
+}
+
+func (cr *credentials) hasAPIKey() bool {
+	return cr.PublicAPIKey != "" || cr.PrivateAPIKey != ""
+}
+
+// loadCredentials reads the credentials document from stdin if --credentials-stdin was provided,
+// filling in any values it omits from the environment. It returns nil if no credentials were supplied
+func (c *BaseCommand) loadCredentials() (*credentials, error) {
+	var creds credentials
+
+	if c.flagCredentialsStdin {
+		stdin := c.stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+
+		if err := json.NewDecoder(stdin).Decode(&creds); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read credentials from stdin: %s", err)
+		}
+	}
+
+	creds.PublicAPIKey = flagOrEnv(creds.PublicAPIKey, envPublicAPIKey)
+	creds.PrivateAPIKey = flagOrEnv(creds.PrivateAPIKey, envPrivateAPIKey)
+	creds.BaseURL = flagOrEnv(creds.BaseURL, envBaseURL)
+	creds.AtlasBaseURL = flagOrEnv(creds.AtlasBaseURL, envAtlasBaseURL)
+
+	if creds == (credentials{}) {
+		return nil, nil
+	}
+
+	return &creds, nil
+}
+
+// logInWithCredentials authenticates with the supplied API keys. The resulting user is kept in
+// memory only so that supplied credentials and their tokens are never written to the config file
+func (c *BaseCommand) logInWithCredentials() (*user.User, error) {
+	provider := auth.NewAPIKeyProvider(c.credentials.PublicAPIKey, c.credentials.PrivateAPIKey)
+	if err := provider.Validate(); err != nil {
+		return nil, err
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	authResponse, err := api.NewRealmClient(client).Authenticate(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &user.User{
+		PublicAPIKey:  c.credentials.PublicAPIKey,
+		PrivateAPIKey: c.credentials.PrivateAPIKey,
+		AccessToken:   authResponse.AccessToken,
+		RefreshToken:  authResponse.RefreshToken,
+	}
+
+	c.storage = storage.New(storage.NewMemoryStrategy(nil))
+	if err := c.storage.WriteUserConfig(u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// suppliedBaseURL returns the Realm base URL supplied by flag, credentials document, or environment
+func (c *BaseCommand) suppliedBaseURL() (string, bool) {
+	if c.flagIsSet(flagBaseURLName) {
+		return c.flagBaseURL, true
+	}
+
+	if c.credentials != nil && c.credentials.BaseURL != "" {
+		return c.credentials.BaseURL, true
+	}
+
+	return "", false
+}
+
+// suppliedAtlasBaseURL returns the Atlas base URL supplied by flag, credentials document, or environment
+func (c *BaseCommand) suppliedAtlasBaseURL() (string, bool) {
+	if c.flagIsSet(flagAtlasBaseURLName) {
+		return c.flagAtlasBaseURL, true
+	}
+
+	if c.credentials != nil && c.credentials.AtlasBaseURL != "" {
+		return c.credentials.AtlasBaseURL, true
+	}
+
+	return "", false
+}
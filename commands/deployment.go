@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/models"
+)
+
+// defaultDeployTimeout is how long a deployment is waited for by default
+const defaultDeployTimeout = 10 * time.Minute
+
+// deploymentPollInterval is how often the status of a deployment in progress is checked
+var deploymentPollInterval = time.Second
+
+// ErrDeploymentFailed is returned when a deployment finishes with the failed status
+type ErrDeploymentFailed struct {
+	ID      string
+	Message string
+}
+
+func (err ErrDeploymentFailed) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("deployment %s failed", err.ID)
+	}
+	return fmt.Sprintf("deployment %s failed: %s", err.ID, err.Message)
+}
+
+// ErrDeploymentTimeout is returned when a deployment is still in progress once the deploy timeout has passed
+type ErrDeploymentTimeout struct {
+	ID      string
+	Status  models.DeploymentStatus
+	Timeout time.Duration
+}
+
+func (err ErrDeploymentTimeout) Error() string {
+	return fmt.Sprintf("deployment %s is still %s after %s; it may yet finish, so check its status before deploying again", err.ID, err.Status, err.Timeout)
+}
+
+func deploymentInProgress(deployment *models.Deployment) bool {
+	return deployment.Status == models.DeploymentStatusCreated || deployment.Status == models.DeploymentStatusPending
+}
+
+// waitForDeployment polls the deployment until it is no longer in progress, showing how long it has taken. It fails
+// when the deployment fails, or when it is still in progress once the timeout has passed, unless the timeout is 0
+func (c *BaseCommand) waitForDeployment(realmClient api.RealmClient, groupID, appID string, deployment *models.Deployment, timeout time.Duration) (*models.Deployment, error) {
+	progress := c.startProgress("Deploying app...")
+	started := time.Now()
+
+	for deploymentInProgress(deployment) {
+		elapsed := time.Since(started)
+		if timeout > 0 && elapsed >= timeout {
+			progress.Finish(fmt.Sprintf("timed out after %s", elapsed.Round(time.Second)))
+			return deployment, ErrDeploymentTimeout{ID: deployment.ID, Status: deployment.Status, Timeout: timeout}
+		}
+
+		progress.Update(fmt.Sprintf("%s (%s)", elapsed.Round(time.Second), deployment.Status))
+		time.Sleep(deploymentPollInterval)
+
+		polled, err := realmClient.GetDeployment(groupID, appID, deployment.ID)
+		if err != nil {
+			progress.Finish("failed")
+			return deployment, fmt.Errorf("failed to get the status of deployment %s: %w", deployment.ID, err)
+		}
+		deployment = polled
+	}
+
+	elapsed := time.Since(started).Round(time.Second)
+	if deployment.Status == models.DeploymentStatusFailed {
+		progress.Finish(fmt.Sprintf("failed after %s", elapsed))
+		return deployment, ErrDeploymentFailed{ID: deployment.ID, Message: deployment.StatusErrorMessage}
+	}
+
+	progress.Finish(fmt.Sprintf("done in %s", elapsed))
+
+	return deployment, nil
+}
@@ -0,0 +1,599 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	deploymentsFlagTimeout = "timeout"
+)
+
+var (
+	errDeploymentIDRequired = errors.New("a deployment ID must be supplied")
+)
+
+// NewDeploymentsCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDeploymentsCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &DeploymentsCommand{
+			BaseCommand: &BaseCommand{
+				Name: "deployments",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// DeploymentsCommand is used to inspect the deployments of a Realm App
+type DeploymentsCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (dc *DeploymentsCommand) Synopsis() string {
+	return "List, inspect, wait for or roll back to the deployments of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (dc *DeploymentsCommand) Help() string {
+	return dc.Synopsis()
+}
+
+// Run executes the command
+func (dc *DeploymentsCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// deploymentResult describes a deployment in structured output
+type deploymentResult struct {
+	ID                 string `json:"id" yaml:"id"`
+	Status             string `json:"status" yaml:"status"`
+	StatusErrorMessage string `json:"status_error_message,omitempty" yaml:"status_error_message,omitempty"`
+	DeployedAt         string `json:"deployed_at,omitempty" yaml:"deployed_at,omitempty"`
+	Origin             string `json:"origin,omitempty" yaml:"origin,omitempty"`
+	DraftID            string `json:"draft_id,omitempty" yaml:"draft_id,omitempty"`
+	Commit             string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	DiffURL            string `json:"diff_url,omitempty" yaml:"diff_url,omitempty"`
+}
+
+func newDeploymentResult(deployment models.Deployment) deploymentResult {
+	return deploymentResult{
+		ID:                 deployment.ID,
+		Status:             string(deployment.Status),
+		StatusErrorMessage: deployment.StatusErrorMessage,
+		DeployedAt:         formatDeployedAt(deployment.DeployedAt),
+		Origin:             deployment.Origin,
+		DraftID:            deployment.DraftID,
+		Commit:             deployment.Commit,
+		DiffURL:            deployment.DiffURL,
+	}
+}
+
+// formatDeployedAt formats the seconds since the epoch a deployment was made at, which is 0 when unknown
+func formatDeployedAt(deployedAt int64) string {
+	if deployedAt == 0 {
+		return ""
+	}
+	return time.Unix(deployedAt, 0).UTC().Format(time.RFC3339)
+}
+
+// NewDeploymentsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDeploymentsListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeploymentsListCommand{
+			AppCommand: NewAppCommand("deployments list", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DeploymentsListCommand is used to list the deployment history of a Realm app
+type DeploymentsListCommand struct {
+	*AppCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (dlc *DeploymentsListCommand) Synopsis() string {
+	return "List the deployments of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (dlc *DeploymentsListCommand) Help() string {
+	return `List the deployments of your Realm Application, most recent first.
+
+Usage: realm-cli deployments list [options]
+` +
+		dlc.AppCommand.Help()
+}
+
+// Run executes the command
+func (dlc *DeploymentsListCommand) Run(args []string) int {
+	if err := dlc.AppCommand.run(args); err != nil {
+		return dlc.fail(err)
+	}
+
+	if err := dlc.listDeployments(); err != nil {
+		return dlc.fail(err)
+	}
+
+	return 0
+}
+
+func (dlc *DeploymentsListCommand) listDeployments() error {
+	app, err := dlc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := dlc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	deployments, err := realmClient.ListDeployments(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+
+	if dlc.structuredOutput() {
+		results := make([]deploymentResult, 0, len(deployments))
+		for _, deployment := range deployments {
+			results = append(results, newDeploymentResult(deployment))
+		}
+
+		return dlc.writeResult(results)
+	}
+
+	if len(deployments) == 0 {
+		dlc.UI.Info("No deployments found for this app")
+		return nil
+	}
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tStatus\tDeployed At\tOrigin\tDraft ID")
+	for _, deployment := range deployments {
+		result := newDeploymentResult(deployment)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.ID, result.Status, result.DeployedAt, result.Origin, result.DraftID)
+	}
+	w.Flush()
+
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		dlc.UI.Info(strings.TrimRight(line, " "))
+	}
+
+	return nil
+}
+
+// NewDeploymentsGetCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDeploymentsGetCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeploymentsGetCommand{
+			AppCommand: NewAppCommand("deployments get", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DeploymentsGetCommand is used to show a deployment of a Realm app
+type DeploymentsGetCommand struct {
+	*AppCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (dgc *DeploymentsGetCommand) Synopsis() string {
+	return "Show a deployment of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (dgc *DeploymentsGetCommand) Help() string {
+	return `Show the status of a deployment of your Realm Application, and why it failed if it did.
+
+Usage: realm-cli deployments get [options] [id]
+` +
+		dgc.AppCommand.Help()
+}
+
+// Run executes the command
+func (dgc *DeploymentsGetCommand) Run(args []string) int {
+	if err := dgc.AppCommand.run(args); err != nil {
+		return dgc.fail(err)
+	}
+
+	if err := dgc.getDeployment(dgc.FlagSet.Arg(0)); err != nil {
+		return dgc.fail(err)
+	}
+
+	return 0
+}
+
+func (dgc *DeploymentsGetCommand) getDeployment(deploymentID string) error {
+	if deploymentID == "" {
+		return errDeploymentIDRequired
+	}
+
+	app, err := dgc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := dgc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	deployment, err := realmClient.GetDeployment(app.GroupID, app.ID, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	result := newDeploymentResult(*deployment)
+	if dgc.structuredOutput() {
+		return dgc.writeResult(result)
+	}
+
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"ID", result.ID},
+		{"Status", result.Status},
+		{"Error", result.StatusErrorMessage},
+		{"Deployed At", result.DeployedAt},
+		{"Origin", result.Origin},
+		{"Draft ID", result.DraftID},
+		{"Commit", result.Commit},
+		{"Diff URL", result.DiffURL},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			dgc.UI.Info(fmt.Sprintf("%-12s%s", field.name+":", field.value))
+		}
+	}
+
+	return nil
+}
+
+// NewDeploymentsWaitCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDeploymentsWaitCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeploymentsWaitCommand{
+			AppCommand: NewAppCommand("deployments wait", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DeploymentsWaitCommand is used to wait for a deployment of a Realm app to finish
+type DeploymentsWaitCommand struct {
+	*AppCommand
+
+	flagTimeout time.Duration
+}
+
+// Synopsis returns a one-liner description for this command
+func (dwc *DeploymentsWaitCommand) Synopsis() string {
+	return "Wait for a deployment of your Realm App to finish."
+}
+
+// Help returns long-form help information for this command
+func (dwc *DeploymentsWaitCommand) Help() string {
+	return `Wait for a deployment of your Realm Application to finish, failing if the deployment fails.
+
+Usage: realm-cli deployments wait [options] [id]
+
+OPTIONS:
+  --timeout [duration] (default: 10m)
+	How long to wait for the deployment to finish before failing, e.g. "90s" or "15m". Use 0 to wait
+	for as long as it takes.
+` +
+		dwc.AppCommand.Help()
+}
+
+// Run executes the command
+func (dwc *DeploymentsWaitCommand) Run(args []string) int {
+	dwc.NewFlagSet()
+
+	dwc.FlagSet.DurationVar(&dwc.flagTimeout, deploymentsFlagTimeout, defaultDeployTimeout, "")
+
+	if err := dwc.AppCommand.run(args); err != nil {
+		return dwc.fail(err)
+	}
+
+	if err := dwc.waitForDeploymentByID(dwc.FlagSet.Arg(0)); err != nil {
+		return dwc.fail(err)
+	}
+
+	return 0
+}
+
+func (dwc *DeploymentsWaitCommand) waitForDeploymentByID(deploymentID string) error {
+	if deploymentID == "" {
+		return errDeploymentIDRequired
+	}
+
+	app, err := dwc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := dwc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	deployment, err := realmClient.GetDeployment(app.GroupID, app.ID, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	deployment, err = dwc.waitForDeployment(realmClient, app.GroupID, app.ID, deployment, dwc.flagTimeout)
+	if err != nil {
+		return err
+	}
+
+	if dwc.structuredOutput() {
+		return dwc.writeResult(newDeploymentResult(*deployment))
+	}
+
+	return nil
+}
+
+// Set of statuses a rollback can finish with
+const (
+	rollbackStatusUnchanged  = "unchanged"
+	rollbackStatusCancelled  = "cancelled"
+	rollbackStatusRolledBack = "rolled_back"
+)
+
+// rollbackResult describes the outcome of a rollback in structured output
+type rollbackResult struct {
+	RolledBackTo     string          `json:"rolled_back_to" yaml:"rolled_back_to"`
+	Status           string          `json:"status" yaml:"status"`
+	Diffs            []string        `json:"diffs,omitempty" yaml:"diffs,omitempty"`
+	Changes          []models.Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+	DeploymentID     string          `json:"deployment_id,omitempty" yaml:"deployment_id,omitempty"`
+	DeploymentStatus string          `json:"deployment_status,omitempty" yaml:"deployment_status,omitempty"`
+}
+
+// NewDeploymentsRollbackCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDeploymentsRollbackCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeploymentsRollbackCommand{
+			AppCommand: NewAppCommand("deployments rollback", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DeploymentsRollbackCommand is used to redeploy the configuration of a prior deployment of a Realm app
+type DeploymentsRollbackCommand struct {
+	*AppCommand
+
+	flagTimeout time.Duration
+}
+
+// Synopsis returns a one-liner description for this command
+func (drc *DeploymentsRollbackCommand) Synopsis() string {
+	return "Roll your Realm App back to a prior deployment."
+}
+
+// Help returns long-form help information for this command
+func (drc *DeploymentsRollbackCommand) Help() string {
+	return `Roll your Realm Application back to a prior successful deployment by deploying its configuration again.
+The changes this makes to the deployed app are shown and must be confirmed first. Hosting assets and
+dependencies are not rolled back.
+
+Usage: realm-cli deployments rollback [options] [id]
+
+OPTIONS:
+  --timeout [duration] (default: 10m)
+	How long to wait for the new deployment to finish before failing, e.g. "90s" or "15m". Use 0 to wait
+	for as long as it takes.
+` +
+		drc.AppCommand.Help()
+}
+
+// Run executes the command
+func (drc *DeploymentsRollbackCommand) Run(args []string) int {
+	drc.NewFlagSet()
+
+	drc.FlagSet.DurationVar(&drc.flagTimeout, deploymentsFlagTimeout, defaultDeployTimeout, "")
+
+	if err := drc.AppCommand.run(args); err != nil {
+		return drc.fail(err)
+	}
+
+	if err := drc.rollback(drc.FlagSet.Arg(0)); err != nil {
+		return drc.fail(err)
+	}
+
+	return 0
+}
+
+func (drc *DeploymentsRollbackCommand) rollback(deploymentID string) error {
+	if deploymentID == "" {
+		return errDeploymentIDRequired
+	}
+
+	app, err := drc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := drc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	deployment, err := realmClient.GetDeployment(app.GroupID, app.ID, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	if deployment.Status != models.DeploymentStatusSuccessful {
+		return fmt.Errorf("deployment %s is %s; only a successful deployment can be rolled back to", deploymentID, deployment.Status)
+	}
+
+	result := rollbackResult{RolledBackTo: deploymentID}
+
+	diffs, err := drc.diffDeployment(realmClient, app, deploymentID)
+	if err != nil {
+		return err
+	}
+	result.Diffs = diffs
+	result.Changes = models.ParseDiff(diffs)
+
+	if len(diffs) == 0 {
+		result.Status = rollbackStatusUnchanged
+		return drc.report(result, fmt.Sprintf("Deployed app is identical to deployment %s, nothing to do.", deploymentID))
+	}
+
+	for _, diff := range diffs {
+		drc.UI.Info(diff)
+	}
+
+	confirm, err := drc.AskYesNo(fmt.Sprintf("Please confirm rolling back to deployment %s with the changes shown above:", deploymentID))
+	if err != nil {
+		return err
+	}
+
+	if !confirm {
+		result.Status = rollbackStatusCancelled
+		return drc.report(result, "Cancelling rollback.")
+	}
+
+	// the redeploy starts a new deployment without returning it, so it is found in the history as the one that
+	// was not there before
+	deployments, err := realmClient.ListDeployments(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+
+	priorIDs := make(map[string]bool, len(deployments))
+	for _, prior := range deployments {
+		priorIDs[prior.ID] = true
+	}
+
+	if err := realmClient.RedeployDeployment(app.GroupID, app.ID, deploymentID); err != nil {
+		return fmt.Errorf("failed to redeploy deployment %s: %w", deploymentID, err)
+	}
+
+	started := time.Now()
+	redeployment, err := drc.findNewDeployment(realmClient, app, priorIDs)
+	if err != nil {
+		return err
+	}
+
+	timeout := drc.flagTimeout
+	if timeout > 0 {
+		if timeout -= time.Since(started); timeout <= 0 {
+			timeout = time.Nanosecond
+		}
+	}
+
+	redeployment, err = drc.waitForDeployment(realmClient, app.GroupID, app.ID, redeployment, timeout)
+	if err != nil {
+		return err
+	}
+
+	result.DeploymentID = redeployment.ID
+	result.DeploymentStatus = string(redeployment.Status)
+
+	result.Status = rollbackStatusRolledBack
+	return drc.report(result, fmt.Sprintf("Successfully rolled '%s' back to deployment %s", app.ClientAppID, deploymentID))
+}
+
+// findNewDeployment polls the deployment history until it lists a deployment that is not one of the prior ones,
+// failing when none is listed once the timeout has passed, unless the timeout is 0
+func (drc *DeploymentsRollbackCommand) findNewDeployment(realmClient api.RealmClient, app *models.App, priorIDs map[string]bool) (*models.Deployment, error) {
+	started := time.Now()
+
+	for {
+		deployments, err := realmClient.ListDeployments(app.GroupID, app.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range deployments {
+			if !priorIDs[deployments[i].ID] {
+				return &deployments[i], nil
+			}
+		}
+
+		if elapsed := time.Since(started); drc.flagTimeout > 0 && elapsed >= drc.flagTimeout {
+			return nil, fmt.Errorf(
+				"the redeployed deployment is still not listed after %s; check the app's deployments before rolling back again",
+				elapsed.Round(time.Second),
+			)
+		}
+
+		time.Sleep(deploymentPollInterval)
+	}
+}
+
+// diffDeployment returns the changes deploying the configuration of the deployment would make to the deployed app
+func (drc *DeploymentsRollbackCommand) diffDeployment(realmClient api.RealmClient, app *models.App, deploymentID string) ([]string, error) {
+	_, body, err := realmClient.ExportDeployment(app.GroupID, app.ID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export deployment %s: %w", deploymentID, err)
+	}
+	defer body.Close()
+
+	dir, err := ioutil.TempDir("", "realm-cli-rollback")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := utils.WriteZipToDir(dir, body, true); err != nil {
+		return nil, fmt.Errorf("failed to export deployment %s: %w", deploymentID, err)
+	}
+
+	deployedApp, err := utils.UnmarshalFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	appData, err := json.Marshal(deployedApp)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := realmClient.Diff(app.GroupID, app.ID, appData, importStrategyReplace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff app with deployment %s: %w", deploymentID, err)
+	}
+
+	return diffs, nil
+}
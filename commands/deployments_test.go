@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/storage"
+	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeploymentsCommandsAgainstFakeAPI(t *testing.T) {
+	defer func(interval time.Duration) { deploymentPollInterval = interval }(deploymentPollInterval)
+	deploymentPollInterval = time.Millisecond
+
+	newStorage := func() *storage.Storage {
+		return u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+	}
+
+	importNoWait := func(t *testing.T, server *fakeapi.Server) (int, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = newStorage()
+		importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+			return nil
+		}
+
+		exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=../testdata/simple_app_with_instance_data", "--no-wait", "--output=json", "-y"})
+		return exitCode, mockUI
+	}
+
+	t.Run("should import without waiting and then wait for the deployment", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+		server.DeploymentPolls = 2
+
+		exitCode, mockUI := importNoWait(t, server)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result importResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Status, gc.ShouldEqual, importStatusDeploying)
+		u.So(t, result.DeploymentStatus, gc.ShouldEqual, "created")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, fmt.Sprintf("run 'realm-cli deployments wait %s'", result.DeploymentID))
+
+		mockUI = cli.NewMockUi()
+		cmd, err := NewDeploymentsWaitCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		waitCommand := cmd.(*DeploymentsWaitCommand)
+		waitCommand.storage = newStorage()
+
+		exitCode = waitCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", result.DeploymentID})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Deploying app...\nDeploying app... done in 0s\n")
+	})
+
+	t.Run("should fail to wait for a failed deployment", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+		server.FailDeployments = true
+
+		exitCode, _ := importNoWait(t, server)
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+
+		deploymentID := server.App("my-app-abcdef").Deployments[0].ID
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDeploymentsWaitCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		waitCommand := cmd.(*DeploymentsWaitCommand)
+		waitCommand.storage = newStorage()
+
+		exitCode = waitCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", deploymentID})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, fmt.Sprintf("deployment %s failed: %s\n", deploymentID, fakeapi.DeploymentErrorMessage))
+	})
+
+	t.Run("should list and get deployments", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		for i := 0; i < 2; i++ {
+			exitCode, _ := importNoWait(t, server)
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+		}
+		deployments := server.App("my-app-abcdef").Deployments
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDeploymentsListCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		listCommand := cmd.(*DeploymentsListCommand)
+		listCommand.storage = newStorage()
+
+		exitCode := listCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		lines := strings.Split(strings.TrimSpace(mockUI.OutputWriter.String()), "\n")
+		u.So(t, lines, gc.ShouldHaveLength, 3)
+		u.So(t, strings.Fields(lines[0]), gc.ShouldResemble, []string{"ID", "Status", "Deployed", "At", "Origin", "Draft", "ID"})
+		u.So(t, strings.Fields(lines[1])[0], gc.ShouldEqual, deployments[1].ID)
+		u.So(t, strings.Fields(lines[2])[0], gc.ShouldEqual, deployments[0].ID)
+
+		mockUI = cli.NewMockUi()
+		cmd, err = NewDeploymentsGetCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		getCommand := cmd.(*DeploymentsGetCommand)
+		getCommand.storage = newStorage()
+
+		exitCode = getCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "--output=json", deployments[0].ID})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result deploymentResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldResemble, deploymentResult{
+			ID:         deployments[0].ID,
+			Status:     "successful",
+			DeployedAt: formatDeployedAt(deployments[0].DeployedAt),
+			Origin:     "Admin API",
+			DraftID:    deployments[0].DraftID,
+		})
+	})
+
+	t.Run("should require a deployment ID", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDeploymentsGetCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		getCommand := cmd.(*DeploymentsGetCommand)
+		getCommand.storage = newStorage()
+
+		exitCode := getCommand.Run([]string{"--app-id=my-app-abcdef"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, errDeploymentIDRequired.Error()+"\n")
+	})
+
+	t.Run("should roll back to a prior deployment", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		deploy := func(origins string) {
+			appConfig := `{"app_id": "my-app-abcdef", "name": "simple-app", "config_version": 20200603, "security": {"allowed_request_origins": [` + origins + `]}}`
+			u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), []byte(appConfig), 0600), gc.ShouldBeNil)
+
+			mockUI := cli.NewMockUi()
+			cmd, err := NewImportCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			importCommand := cmd.(*ImportCommand)
+			importCommand.storage = newStorage()
+			importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+				return nil
+			}
+
+			exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=" + dir, "-y"})
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+		}
+
+		rollback := func(args ...string) (int, *cli.MockUi) {
+			mockUI := cli.NewMockUi()
+			cmd, err := NewDeploymentsRollbackCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			rollbackCommand := cmd.(*DeploymentsRollbackCommand)
+			rollbackCommand.storage = newStorage()
+
+			return rollbackCommand.Run(append([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef"}, args...)), mockUI
+		}
+
+		deploy(`"https://good.example.com"`)
+		goodConfig := server.App("my-app-abcdef").Config
+		deploy(`"https://bad.example.com"`)
+
+		goodDeploymentID := server.App("my-app-abcdef").Deployments[0].ID
+
+		exitCode, mockUI := rollback("--non-interactive", goodDeploymentID)
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeMissingInput)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "~~~ security\n")
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 2)
+
+		exitCode, mockUI = rollback("-y", goodDeploymentID)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEndWith, fmt.Sprintf("Successfully rolled 'my-app-abcdef' back to deployment %s\n", goodDeploymentID))
+
+		deployments := server.App("my-app-abcdef").Deployments
+		u.So(t, deployments, gc.ShouldHaveLength, 3)
+		u.So(t, deployments[2].Status, gc.ShouldEqual, models.DeploymentStatusSuccessful)
+		u.So(t, server.App("my-app-abcdef").Config, gc.ShouldResemble, goodConfig)
+
+		exitCode, mockUI = rollback("-y", "--output=json", goodDeploymentID)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result rollbackResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldResemble, rollbackResult{RolledBackTo: goodDeploymentID, Status: rollbackStatusUnchanged})
+	})
+
+	t.Run("should wait for the new deployment when the history lags behind the redeploy", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		exitCode, _ := importNoWait(t, server)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		app := server.App("my-app-abcdef")
+		app.Config = map[string]interface{}{"app_id": "my-app-abcdef", "name": "simple-app", "config_version": 20200603}
+		app.Deployments = append(app.Deployments, &models.Deployment{
+			ID:     "5e00000000000000000000ff",
+			AppID:  app.ID,
+			Status: models.DeploymentStatusSuccessful,
+		})
+
+		server.DeploymentPolls = 2
+		server.DeploymentListLag = 2
+
+		deploymentID := app.Deployments[0].ID
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDeploymentsRollbackCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		rollbackCommand := cmd.(*DeploymentsRollbackCommand)
+		rollbackCommand.storage = newStorage()
+
+		exitCode = rollbackCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "-y", "--output=json", deploymentID})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		deployments := server.App("my-app-abcdef").Deployments
+		u.So(t, deployments, gc.ShouldHaveLength, 3)
+
+		var result rollbackResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Status, gc.ShouldEqual, rollbackStatusRolledBack)
+		u.So(t, result.DeploymentID, gc.ShouldEqual, deployments[2].ID)
+		u.So(t, result.DeploymentStatus, gc.ShouldEqual, string(models.DeploymentStatusSuccessful))
+	})
+
+	t.Run("should not roll back to a failed deployment", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+		server.FailDeployments = true
+
+		exitCode, _ := importNoWait(t, server)
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+
+		deploymentID := server.App("my-app-abcdef").Deployments[0].ID
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDeploymentsRollbackCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		rollbackCommand := cmd.(*DeploymentsRollbackCommand)
+		rollbackCommand.storage = newStorage()
+
+		exitCode = rollbackCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "-y", deploymentID})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, fmt.Sprintf("deployment %s is failed; only a successful deployment can be rolled back to\n", deploymentID))
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 1)
+	})
+}
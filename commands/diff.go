@@ -45,6 +45,11 @@ type DiffCommand struct {
 	flagGroupID        string
 	flagStrategy       string
 	flagIncludeHosting bool
+	flagVars           varsFlag
+	flagVarsFile       string
+	flagStrictVars     bool
+	flagSkipLint       bool
+	flagLocal          bool
 }
 
 // Help returns long-form help information for this command
@@ -64,6 +69,13 @@ OPTIONS:
 
   --include-hosting
 	Upload static assets from "/hosting" directory.
+
+  --local
+	Compare the directory with an export of the deployed app instead of asking the server for the diff of a dry-run
+	import. JSON files are compared by their values, other files such as function sources line by line, and each
+	change is shown as a unified diff of the file at its path in the directory. Placeholders and environment
+	overlays are compared as they are written.
+` + varsHelp + lintHelp + `
 	` +
 		dc.BaseCommand.Help()
 }
@@ -82,10 +94,15 @@ func (dc *DiffCommand) Run(args []string) int {
 	flags.StringVar(&dc.flagGroupID, flagProjectIDName, "", "")
 	flags.BoolVar(&dc.flagIncludeHosting, importFlagIncludeHosting, false, "")
 	flags.StringVar(&dc.flagStrategy, importFlagStrategy, importStrategyMerge, "")
+	dc.flagVars = varsFlag{}
+	flags.Var(dc.flagVars, importFlagVar, "")
+	flags.StringVar(&dc.flagVarsFile, importFlagVarsFile, "", "")
+	flags.BoolVar(&dc.flagStrictVars, importFlagStrictVars, false, "")
+	flags.BoolVar(&dc.flagSkipLint, importFlagSkipLint, false, "")
+	flags.BoolVar(&dc.flagLocal, diffFlagLocal, false, "")
 
 	if err := dc.BaseCommand.run(args); err != nil {
-		dc.UI.Error(err.Error())
-		return 1
+		return dc.fail(err)
 	}
 
 	ic := &ImportCommand{
@@ -94,6 +111,7 @@ func (dc *DiffCommand) Run(args []string) int {
 		writeToDirectory:     dc.writeToDirectory,
 		writeAppConfigToFile: dc.writeAppConfigToFile,
 		workingDirectory:     dc.workingDirectory,
+		lintTranspiler:       lintTranspiler,
 
 		flagAppID:          dc.flagAppID,
 		flagAppPath:        dc.flagAppPath,
@@ -101,12 +119,25 @@ func (dc *DiffCommand) Run(args []string) int {
 		flagGroupID:        dc.flagGroupID,
 		flagStrategy:       dc.flagStrategy,
 		flagIncludeHosting: dc.flagIncludeHosting,
+		flagVars:           dc.flagVars,
+		flagVarsFile:       dc.flagVarsFile,
+		flagStrictVars:     dc.flagStrictVars,
+		flagSkipLint:       dc.flagSkipLint,
+	}
+
+	if dc.flagLocal {
+		if err := ic.diffLocal(); err != nil {
+			return dc.fail(err)
+		}
+	} else {
+		dryRun := true
+		if err := ic.importApp(dryRun); err != nil {
+			return dc.fail(err)
+		}
 	}
 
-	dryRun := true
-	if err := ic.importApp(dryRun); err != nil {
-		dc.UI.Error(err.Error())
-		return 1
+	if err := ic.writeResult(); err != nil {
+		return dc.fail(err)
 	}
 	return 0
 }
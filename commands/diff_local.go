@@ -0,0 +1,429 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/project"
+	u "github.com/10gen/realm-cli/user"
+	"github.com/10gen/realm-cli/utils"
+
+	"github.com/mitchellh/cli"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	diffFlagLocal = "local"
+
+	// localDiffContext is the number of unchanged lines shown around each change to a file
+	localDiffContext = 3
+
+	// localDiffDeployedLabel and localDiffLocalLabel tell the two sides of a file's diff apart
+	localDiffDeployedLabel = "(deployed)"
+	localDiffLocalLabel    = "(local)"
+
+	// localDiffAppKind is the kind of the changes to the files at the root of an app directory
+	localDiffAppKind = "app"
+)
+
+// localDiffIgnored reports whether the file or directory at the path, relative to an app directory, is left out of a
+// local diff. Hosting files are compared by their metadata instead, and an export holds neither the dependencies,
+// nor their archive, the environment overlays nor the project config
+func localDiffIgnored(path string) bool {
+	if path == utils.HostingFilesDirectory || path == utils.EnvironmentsRoot || path == project.ConfigFileName {
+		return true
+	}
+
+	base := filepath.Base(path)
+	return base == "node_modules" || utils.IsDependenciesArchive(path) || strings.HasPrefix(base, ".")
+}
+
+// diffLocal compares the app directory with an export of the deployed app, rather than asking the server for the
+// diff of a dry-run import. The local files are read as import reads them, with the overlay of the selected environment
+// and the placeholders resolved
+func (ic *ImportCommand) diffLocal() error {
+	user, err := ic.User()
+	if err != nil {
+		return err
+	}
+
+	if !user.LoggedIn() {
+		return u.ErrNotLoggedIn
+	}
+
+	appPath, err := utils.ResolveAppDirectory(ic.flagAppPath, ic.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	appInstanceData, err := utils.ResolveAppInstanceData(ic.flagAppID, appPath)
+	if err != nil {
+		return err
+	}
+
+	unmarshalOptions, err := ic.unmarshalOptions(appPath)
+	if err != nil {
+		return err
+	}
+
+	ic.result = importResult{AppID: appInstanceData.AppID(), Environment: ic.flagEnv, Status: importStatusNotFound}
+
+	realmClient, err := ic.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	app, err := ic.fetchAppByClientAppID(appInstanceData.AppID())
+	if err != nil {
+		if _, ok := err.(api.ErrAppNotFound); ok {
+			ic.UI.Info(fmt.Sprintf("%s. To create a new app, use the 'import' command", err.Error()))
+			return nil
+		}
+		return err
+	}
+
+	ic.result.AppID = app.ClientAppID
+	ic.result.GroupID = app.GroupID
+
+	deployedPath, err := ioutil.TempDir("", "realm-cli-diff")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(deployedPath)
+
+	_, body, err := realmClient.Export(app.GroupID, app.ID, api.ExportStrategyNone)
+	if err != nil {
+		return fmt.Errorf("failed to export the deployed app: %w", err)
+	}
+	defer body.Close()
+
+	if err := utils.WriteZipToDir(deployedPath, body, true); err != nil {
+		return fmt.Errorf("failed to export the deployed app: %w", err)
+	}
+
+	changes, err := diffAppDirectories(deployedPath, appPath, unmarshalOptions)
+	if err != nil {
+		return err
+	}
+	ic.warnUnresolvedPlaceholders()
+
+	if ic.flagIncludeHosting {
+		rootDir, err := filepath.Abs(filepath.Join(appPath, utils.HostingFilesDirectory))
+		if err != nil {
+			return err
+		}
+
+		assetMetadataDiffs, err := ic.diffHostingAssets(realmClient, app, appPath, rootDir, appInstanceData.AppID())
+		if err != nil {
+			return err
+		}
+		changes = append(changes, assetMetadataDiffs.Changes()...)
+	}
+
+	ic.result.Changes = changes
+	ic.result.Diffs = models.RenderChanges(changes)
+	if len(changes) == 0 {
+		ic.result.Status = importStatusUnchanged
+		ic.UI.Info("Deployed app is identical to proposed version, nothing to do.")
+		return nil
+	}
+	ic.result.Status = importStatusChanged
+
+	colored := ic.colorEnabled()
+	for _, line := range ic.result.Diffs {
+		if colored {
+			line = colorizeDiffLine(line)
+		}
+		ic.UI.Info(line)
+	}
+
+	return nil
+}
+
+// diffAppDirectories compares the files of the deployed app directory with those of the local one, read with the
+// options, in the order of their paths, which are relative to the app directory
+func diffAppDirectories(deployedPath, localPath string, options utils.UnmarshalOptions) ([]models.Change, error) {
+	deployedFiles, err := listAppFiles(deployedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	localFiles, err := listAppFiles(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(options.OverlayPath); err == nil && info.IsDir() {
+		overlayFiles, err := listAppFiles(options.OverlayPath)
+		if err != nil {
+			return nil, err
+		}
+		for path := range overlayFiles {
+			localFiles[path] = true
+		}
+	}
+
+	paths := make([]string, 0, len(localFiles))
+	for path := range localFiles {
+		paths = append(paths, path)
+	}
+	for path := range deployedFiles {
+		if !localFiles[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []models.Change
+	for _, path := range paths {
+		var deployed, local []byte
+		if deployedFiles[path] {
+			if deployed, err = ioutil.ReadFile(filepath.Join(deployedPath, filepath.FromSlash(path))); err != nil {
+				return nil, err
+			}
+		}
+		if localFiles[path] {
+			if local, err = utils.ReadAppFile(localPath, path, options); err != nil {
+				return nil, err
+			}
+		}
+
+		change, err := diffAppFile(path, deployed, local, deployedFiles[path], localFiles[path])
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	return changes, nil
+}
+
+// listAppFiles returns the paths of the files of an app directory, relative to it and separated by slashes
+func listAppFiles(appPath string) (map[string]bool, error) {
+	files := map[string]bool{}
+
+	err := filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath == "." {
+			return nil
+		}
+
+		if localDiffIgnored(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() {
+			files[relPath] = true
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// diffAppFile returns the change from the deployed file at the path to the local one, or nil if they are the same.
+// JSON files are compared by their values, so that neither formatting nor the order of keys makes a difference
+func diffAppFile(path string, deployed, local []byte, inDeployed, inLocal bool) (*models.Change, error) {
+	if inDeployed && inLocal && bytes.Equal(deployed, local) {
+		return nil, nil
+	}
+
+	change := models.Change{Operation: models.ChangeOperationModify}
+	switch {
+	case !inDeployed:
+		change.Operation = models.ChangeOperationAdd
+	case !inLocal:
+		change.Operation = models.ChangeOperationRemove
+	}
+
+	if i := strings.Index(path, "/"); i != -1 {
+		change.Kind, change.Name = path[:i], path[i+1:]
+	} else {
+		change.Kind, change.Name = localDiffAppKind, path
+	}
+
+	if isBinary(deployed) || isBinary(local) {
+		change.Lines = []string{binaryDiffLine(path, inDeployed, inLocal)}
+		return &change, nil
+	}
+
+	deployedText, localText := string(deployed), string(local)
+	if filepath.Ext(path) == ".json" {
+		var deployedValue, localValue interface{}
+		deployedErr := json.Unmarshal(deployed, &deployedValue)
+		localErr := json.Unmarshal(local, &localValue)
+
+		if (!inDeployed || deployedErr == nil) && (!inLocal || localErr == nil) {
+			if inDeployed && inLocal {
+				if reflect.DeepEqual(deployedValue, localValue) {
+					return nil, nil
+				}
+				change.Fields = jsonFieldChanges(deployedValue, localValue)
+			}
+
+			var err error
+			if deployedText, err = canonicalJSON(deployedValue, inDeployed); err != nil {
+				return nil, err
+			}
+			if localText, err = canonicalJSON(localValue, inLocal); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	lines, err := unifiedDiff(path, deployedText, localText, inDeployed, inLocal)
+	if err != nil {
+		return nil, err
+	}
+	change.Lines = lines
+
+	return &change, nil
+}
+
+// isBinary reports whether the contents of a file are not text, i.e. they are not UTF-8 or hold a NUL byte
+func isBinary(data []byte) bool {
+	return !utf8.Valid(data) || bytes.IndexByte(data, 0) != -1
+}
+
+// binaryDiffLine describes a change to a binary file, whose contents are not shown
+func binaryDiffLine(path string, inDeployed, inLocal bool) string {
+	from, to := "/dev/null", "/dev/null"
+	if inDeployed {
+		from = path + " " + localDiffDeployedLabel
+	}
+	if inLocal {
+		to = path + " " + localDiffLocalLabel
+	}
+	return fmt.Sprintf("Binary files %s and %s differ", from, to)
+}
+
+// canonicalJSON writes a JSON value with its keys sorted and indented the way an export writes them
+func canonicalJSON(value interface{}, exists bool) (string, error) {
+	if !exists {
+		return "", nil
+	}
+
+	data, err := json.MarshalIndent(value, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// jsonFieldChanges returns the changes to the top-level fields of a JSON object, in the order of their names
+func jsonFieldChanges(deployed, local interface{}) []models.FieldChange {
+	deployedObject, deployedOK := deployed.(map[string]interface{})
+	localObject, localOK := local.(map[string]interface{})
+	if !deployedOK || !localOK {
+		return nil
+	}
+
+	fields := make([]string, 0, len(localObject))
+	for field := range localObject {
+		fields = append(fields, field)
+	}
+	for field := range deployedObject {
+		if _, ok := localObject[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []models.FieldChange
+	for _, field := range fields {
+		if !reflect.DeepEqual(deployedObject[field], localObject[field]) {
+			changes = append(changes, models.FieldChange{Field: field, Before: deployedObject[field], After: localObject[field]})
+		}
+	}
+	return changes
+}
+
+// unifiedDiff returns the lines of the unified diff from the deployed text of the file at the path to the local one
+func unifiedDiff(path, deployed, local string, inDeployed, inLocal bool) ([]string, error) {
+	diff := difflib.UnifiedDiff{
+		FromFile: "/dev/null",
+		ToFile:   "/dev/null",
+		Context:  localDiffContext,
+	}
+	if inDeployed {
+		diff.A = splitLines(deployed)
+		diff.FromFile, diff.FromDate = path, localDiffDeployedLabel
+	}
+	if inLocal {
+		diff.B = splitLines(local)
+		diff.ToFile, diff.ToDate = path, localDiffLocalLabel
+	}
+
+	text, err := difflib.GetUnifiedDiffString(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), nil
+}
+
+// splitLines splits the text into lines that each end with a newline, which a unified diff expects
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// colorizeDiffLine colors a line of a unified diff: bold file headers, cyan hunk headers, green additions and red
+// removals, with the hosting files listed the same way
+func colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+		return colorize(line, cli.UiColor{Code: 39, Bold: true})
+	case strings.HasPrefix(line, "@@"):
+		return colorize(line, cli.UiColorCyan)
+	case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "\t+ "):
+		return colorize(line, cli.UiColorGreen)
+	case strings.HasPrefix(line, "-"), strings.HasPrefix(line, "\t- "):
+		return colorize(line, cli.UiColorRed)
+	case strings.HasPrefix(line, "\t* "):
+		return colorize(line, cli.UiColorYellow)
+	}
+	return line
+}
+
+// colorize wraps the text in the escape codes of the color, as a cli.ColoredUi does
+func colorize(text string, color cli.UiColor) string {
+	attr := 0
+	if color.Bold {
+		attr = 1
+	}
+	return fmt.Sprintf("\033[%d;%dm%s\033[0m", attr, color.Code, text)
+}
@@ -1,12 +1,19 @@
 package commands
 
 import (
+	"encoding/json"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/project"
 	"github.com/10gen/realm-cli/user"
 	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
 	"github.com/mitchellh/cli"
 	gc "github.com/smartystreets/goconvey/convey"
 )
@@ -113,3 +120,212 @@ func TestDiffCommand(t *testing.T) {
 	})
 
 }
+
+func TestDiffCommandLocal(t *testing.T) {
+	source := "exports = function() {\n  return 1;\n};\n"
+
+	setup := func(t *testing.T) (*fakeapi.Server, string) {
+		server := fakeapi.NewServer()
+
+		app := server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+		app.Config = map[string]interface{}{
+			"app_id":         "my-app-abcdef",
+			"name":           "simple-app",
+			"config_version": 20200603,
+			"functions": []interface{}{
+				map[string]interface{}{
+					"config": map[string]interface{}{"name": "myFunc", "private": false},
+					"source": source,
+				},
+			},
+		}
+
+		dir, err := ioutil.TempDir("", "realm-cli-diff-local")
+		u.So(t, err, gc.ShouldBeNil)
+
+		files := map[string]string{
+			"config.json":                  `{"name": "simple-app", "config_version": 20200603, "app_id": "my-app-abcdef"}`,
+			"functions/myFunc/config.json": `{"name": "myFunc", "private": false}`,
+			"functions/myFunc/source.js":   source,
+			project.ConfigFileName:         "defaults:\n",
+		}
+		for path, contents := range files {
+			path = filepath.Join(dir, filepath.FromSlash(path))
+			u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(path, []byte(contents), 0600), gc.ShouldBeNil)
+		}
+
+		return server, dir
+	}
+
+	run := func(server *fakeapi.Server, dir string, args ...string) (int, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDiffCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		diffCommand := cmd.(*DiffCommand)
+		diffCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		args = append([]string{"--base-url=" + server.URL, "--path=" + dir, "--local"}, args...)
+		return diffCommand.Run(args), mockUI
+	}
+
+	t.Run("should find no changes when the files only differ by their formatting", func(t *testing.T) {
+		server, dir := setup(t)
+		defer server.Close()
+		defer os.RemoveAll(dir)
+
+		exitCode, mockUI := run(server, dir)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Deployed app is identical to proposed version, nothing to do.\n")
+	})
+
+	t.Run("should show the changes to the local files as unified diffs", func(t *testing.T) {
+		server, dir := setup(t)
+		defer server.Close()
+		defer os.RemoveAll(dir)
+
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "functions", "myFunc", "config.json"), []byte(`{"name": "myFunc", "private": true}`), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "functions", "myFunc", "source.js"), []byte("exports = function() {\n  return 2;\n};\n"), 0600), gc.ShouldBeNil)
+		u.So(t, os.MkdirAll(filepath.Join(dir, "values"), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "values", "myValue.json"), []byte(`{"name": "myValue", "value": "hello"}`), 0600), gc.ShouldBeNil)
+
+		exitCode, mockUI := run(server, dir)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, strings.Join([]string{
+			"--- functions/myFunc/config.json\t(deployed)",
+			"+++ functions/myFunc/config.json\t(local)",
+			"@@ -1,4 +1,4 @@",
+			" {",
+			`     "name": "myFunc",`,
+			`-    "private": false`,
+			`+    "private": true`,
+			" }",
+			"--- functions/myFunc/source.js\t(deployed)",
+			"+++ functions/myFunc/source.js\t(local)",
+			"@@ -1,3 +1,3 @@",
+			" exports = function() {",
+			"-  return 1;",
+			"+  return 2;",
+			" };",
+			"--- /dev/null",
+			"+++ values/myValue.json\t(local)",
+			"@@ -0,0 +1,4 @@",
+			"+{",
+			`+    "name": "myValue",`,
+			`+    "value": "hello"`,
+			"+}",
+			"",
+		}, "\n"))
+	})
+
+	t.Run("should leave out the dependencies archive and not show the contents of binary files", func(t *testing.T) {
+		server, dir := setup(t)
+		defer server.Close()
+		defer os.RemoveAll(dir)
+
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "functions", "node_modules.zip"), []byte("PK\x03\x04\x00\xff"), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "functions", "myFunc", "data.bin"), []byte("\x00\x01\xfe\xff"), 0600), gc.ShouldBeNil)
+
+		exitCode, mockUI := run(server, dir)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Binary files /dev/null and functions/myFunc/data.bin (local) differ\n")
+	})
+
+	t.Run("should compare the files with the overlay of the selected environment and its placeholders resolved", func(t *testing.T) {
+		server, dir := setup(t)
+		defer server.Close()
+		defer os.RemoveAll(dir)
+
+		staging := server.AddApp("5e0000000000000000000001", "my-app-staging", "simple-app")
+		staging.Config = map[string]interface{}{
+			"app_id":         "my-app-staging",
+			"name":           "simple-app",
+			"config_version": 20200603,
+			"functions": []interface{}{
+				map[string]interface{}{
+					"config": map[string]interface{}{"name": "myFunc", "private": true},
+					"source": source,
+				},
+			},
+			"values": []interface{}{
+				map[string]interface{}{"name": "host", "value": "staging.example.com"},
+			},
+		}
+
+		files := map[string]string{
+			project.ConfigFileName:                              "environments:\n  staging:\n    app_id: my-app-staging\n",
+			"values/host.json":                                  `{"name": "host", "value": "${HOST}"}`,
+			"environments/staging/config.json":                  `{"app_id": "my-app-staging"}`,
+			"environments/staging/functions/myFunc/config.json": `{"private": true}`,
+		}
+		for path, contents := range files {
+			path = filepath.Join(dir, filepath.FromSlash(path))
+			u.So(t, os.MkdirAll(filepath.Dir(path), 0755), gc.ShouldBeNil)
+			u.So(t, ioutil.WriteFile(path, []byte(contents), 0600), gc.ShouldBeNil)
+		}
+
+		exitCode, mockUI := run(server, dir, "--env=staging", "--var=HOST=staging.example.com")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "Deployed app is identical to proposed version, nothing to do.\n")
+	})
+
+	t.Run("should fail with --strict-vars when a placeholder has no value", func(t *testing.T) {
+		server, dir := setup(t)
+		defer server.Close()
+		defer os.RemoveAll(dir)
+
+		u.So(t, os.MkdirAll(filepath.Join(dir, "values"), 0755), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "values", "host.json"), []byte(`{"name": "host", "value": "${HOST}"}`), 0600), gc.ShouldBeNil)
+
+		exitCode, mockUI := run(server, dir, "--strict-vars")
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "values/host.json: ${HOST}")
+	})
+
+	t.Run("should write the changes as JSON", func(t *testing.T) {
+		server, dir := setup(t)
+		defer server.Close()
+		defer os.RemoveAll(dir)
+
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "functions", "myFunc", "config.json"), []byte(`{"name": "myFunc", "private": true}`), 0600), gc.ShouldBeNil)
+		u.So(t, os.Remove(filepath.Join(dir, "functions", "myFunc", "source.js")), gc.ShouldBeNil)
+
+		exitCode, mockUI := run(server, dir, "--output=json")
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result importResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Status, gc.ShouldEqual, importStatusChanged)
+		u.So(t, result.Changes, gc.ShouldHaveLength, 2)
+
+		u.So(t, result.Changes[0].Kind, gc.ShouldEqual, "functions")
+		u.So(t, result.Changes[0].Name, gc.ShouldEqual, "myFunc/config.json")
+		u.So(t, result.Changes[0].Operation, gc.ShouldEqual, models.ChangeOperationModify)
+		u.So(t, result.Changes[0].Fields, gc.ShouldResemble, []models.FieldChange{{Field: "private", Before: false, After: true}})
+
+		u.So(t, result.Changes[1].Name, gc.ShouldEqual, "myFunc/source.js")
+		u.So(t, result.Changes[1].Operation, gc.ShouldEqual, models.ChangeOperationRemove)
+	})
+}
+
+func TestColorizeDiffLine(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected string
+	}{
+		{"--- functions/myFunc/source.js\t(deployed)", "\033[1;39m--- functions/myFunc/source.js\t(deployed)\033[0m"},
+		{"@@ -1,3 +1,3 @@", "\033[0;36m@@ -1,3 +1,3 @@\033[0m"},
+		{"+  return 2;", "\033[0;32m+  return 2;\033[0m"},
+		{"-  return 1;", "\033[0;31m-  return 1;\033[0m"},
+		{"\t* /index.html", "\033[0;33m\t* /index.html\033[0m"},
+		{" };", " };"},
+		{"Modified Files:", "Modified Files:"},
+	} {
+		u.So(t, colorizeDiffLine(tc.line), gc.ShouldEqual, tc.expected)
+	}
+}
@@ -0,0 +1,465 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/models"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	draftsFlagTimeout = "timeout"
+)
+
+// The statuses reported for a draft that was changed
+const (
+	draftStatusDiscarded = "discarded"
+	draftStatusDeployed  = "deployed"
+	draftStatusUnchanged = "unchanged"
+	draftStatusCancelled = "cancelled"
+)
+
+var (
+	errNoDraft = errors.New("the app has no draft; stage one with 'realm-cli import --draft-only'")
+)
+
+// NewDraftsCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDraftsCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &DraftsCommand{
+			BaseCommand: &BaseCommand{
+				Name: "drafts",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// DraftsCommand is used to review, deploy or discard the drafts of a Realm App
+type DraftsCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (dc *DraftsCommand) Synopsis() string {
+	return "Review, deploy or discard the draft of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (dc *DraftsCommand) Help() string {
+	return dc.Synopsis()
+}
+
+// Run executes the command
+func (dc *DraftsCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// draftResult describes a draft in structured output, and the change made to it
+type draftResult struct {
+	ID               string              `json:"id" yaml:"id"`
+	Status           string              `json:"status,omitempty" yaml:"status,omitempty"`
+	Diffs            []string            `json:"diffs,omitempty" yaml:"diffs,omitempty"`
+	HostingFilesDiff *models.HostingDiff `json:"hosting_files_diff,omitempty" yaml:"hosting_files_diff,omitempty"`
+	Changes          []models.Change     `json:"changes,omitempty" yaml:"changes,omitempty"`
+	DeploymentID     string              `json:"deployment_id,omitempty" yaml:"deployment_id,omitempty"`
+	DeploymentStatus string              `json:"deployment_status,omitempty" yaml:"deployment_status,omitempty"`
+}
+
+// resolveDraftID returns the draft ID given, or that of the app's draft when none is given, since an app has at
+// most one draft
+func resolveDraftID(realmClient api.RealmClient, app *models.App, draftID string) (string, error) {
+	if draftID != "" {
+		return draftID, nil
+	}
+
+	drafts, err := realmClient.GetDrafts(app.GroupID, app.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(drafts) == 0 {
+		return "", errNoDraft
+	}
+
+	return drafts[0].ID, nil
+}
+
+// draftChanges returns the changes of a draft, including those to its hosting files
+func draftChanges(diff models.DraftDiff) []models.Change {
+	return append(models.ParseDiff(diff.Diffs), diff.HostingFilesDiff.Changes()...)
+}
+
+// draftDiffLines describes the changes of a draft, listing its hosting files the way an import does
+func draftDiffLines(diff models.DraftDiff) []string {
+	return models.RenderChanges(draftChanges(diff))
+}
+
+// NewDraftsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDraftsListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DraftsListCommand{
+			AppCommand: NewAppCommand("drafts list", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DraftsListCommand is used to list the drafts of a Realm app
+type DraftsListCommand struct {
+	*AppCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (dlc *DraftsListCommand) Synopsis() string {
+	return "List the drafts of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (dlc *DraftsListCommand) Help() string {
+	return `List the drafts of your Realm Application. An app has at most one draft.
+
+Usage: realm-cli drafts list [options]
+` +
+		dlc.AppCommand.Help()
+}
+
+// Run executes the command
+func (dlc *DraftsListCommand) Run(args []string) int {
+	if err := dlc.AppCommand.run(args); err != nil {
+		return dlc.fail(err)
+	}
+
+	if err := dlc.listDrafts(); err != nil {
+		return dlc.fail(err)
+	}
+
+	return 0
+}
+
+func (dlc *DraftsListCommand) listDrafts() error {
+	app, err := dlc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := dlc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	drafts, err := realmClient.GetDrafts(app.GroupID, app.ID)
+	if err != nil {
+		return err
+	}
+
+	if dlc.structuredOutput() {
+		results := make([]draftResult, 0, len(drafts))
+		for _, draft := range drafts {
+			results = append(results, draftResult{ID: draft.ID})
+		}
+
+		return dlc.writeResult(results)
+	}
+
+	if len(drafts) == 0 {
+		dlc.UI.Info("No drafts found for this app")
+		return nil
+	}
+
+	dlc.UI.Info("ID")
+	for _, draft := range drafts {
+		dlc.UI.Info(draft.ID)
+	}
+
+	return nil
+}
+
+// NewDraftsDiffCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDraftsDiffCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DraftsDiffCommand{
+			AppCommand: NewAppCommand("drafts diff", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DraftsDiffCommand is used to show the changes a draft of a Realm app makes
+type DraftsDiffCommand struct {
+	*AppCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (ddc *DraftsDiffCommand) Synopsis() string {
+	return "Show the changes staged in the draft of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (ddc *DraftsDiffCommand) Help() string {
+	return `Show the changes staged in a draft of your Realm Application, including its hosting files. The app's
+draft is used when no ID is given.
+
+Usage: realm-cli drafts diff [options] [id]
+` +
+		ddc.AppCommand.Help()
+}
+
+// Run executes the command
+func (ddc *DraftsDiffCommand) Run(args []string) int {
+	if err := ddc.AppCommand.run(args); err != nil {
+		return ddc.fail(err)
+	}
+
+	if err := ddc.diffDraft(ddc.FlagSet.Arg(0)); err != nil {
+		return ddc.fail(err)
+	}
+
+	return 0
+}
+
+func (ddc *DraftsDiffCommand) diffDraft(draftID string) error {
+	app, err := ddc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := ddc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	draftID, err = resolveDraftID(realmClient, app, draftID)
+	if err != nil {
+		return err
+	}
+
+	diff, err := realmClient.DraftDiff(app.GroupID, app.ID, draftID)
+	if err != nil {
+		return err
+	}
+
+	if ddc.structuredOutput() {
+		return ddc.writeResult(draftResult{ID: draftID, Diffs: diff.Diffs, HostingFilesDiff: &diff.HostingFilesDiff, Changes: draftChanges(*diff)})
+	}
+
+	if !diff.HasChanges() {
+		ddc.UI.Info(fmt.Sprintf("Draft %s has no changes", draftID))
+		return nil
+	}
+
+	for _, line := range draftDiffLines(*diff) {
+		ddc.UI.Info(line)
+	}
+
+	return nil
+}
+
+// NewDraftsDiscardCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDraftsDiscardCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DraftsDiscardCommand{
+			AppCommand: NewAppCommand("drafts discard", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DraftsDiscardCommand is used to discard a draft of a Realm app
+type DraftsDiscardCommand struct {
+	*AppCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (ddc *DraftsDiscardCommand) Synopsis() string {
+	return "Discard the draft of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (ddc *DraftsDiscardCommand) Help() string {
+	return `Discard a draft of your Realm Application and the changes staged in it. The app's draft is used when
+no ID is given.
+
+Usage: realm-cli drafts discard [options] [id]
+` +
+		ddc.AppCommand.Help()
+}
+
+// Run executes the command
+func (ddc *DraftsDiscardCommand) Run(args []string) int {
+	if err := ddc.AppCommand.run(args); err != nil {
+		return ddc.fail(err)
+	}
+
+	if err := ddc.discardDraft(ddc.FlagSet.Arg(0)); err != nil {
+		return ddc.fail(err)
+	}
+
+	return 0
+}
+
+func (ddc *DraftsDiscardCommand) discardDraft(draftID string) error {
+	app, err := ddc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := ddc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	draftID, err = resolveDraftID(realmClient, app, draftID)
+	if err != nil {
+		return err
+	}
+
+	confirm, err := ddc.AskYesNo(fmt.Sprintf("Are you sure you want to discard draft %s and the changes staged in it?", draftID))
+	if err != nil {
+		return err
+	}
+
+	if !confirm {
+		return ddc.report(draftResult{ID: draftID, Status: draftStatusCancelled}, "Cancelling discard.")
+	}
+
+	if err := realmClient.DiscardDraft(app.GroupID, app.ID, draftID); err != nil {
+		return fmt.Errorf("failed to discard draft %s: %w", draftID, err)
+	}
+
+	return ddc.report(draftResult{ID: draftID, Status: draftStatusDiscarded}, fmt.Sprintf("Discarded draft %s", draftID))
+}
+
+// NewDraftsDeployCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewDraftsDeployCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &DraftsDeployCommand{
+			AppCommand: NewAppCommand("drafts deploy", workingDirectory, ui),
+		}, nil
+	}
+}
+
+// DraftsDeployCommand is used to deploy a draft of a Realm app
+type DraftsDeployCommand struct {
+	*AppCommand
+
+	flagTimeout time.Duration
+}
+
+// Synopsis returns a one-liner description for this command
+func (ddc *DraftsDeployCommand) Synopsis() string {
+	return "Deploy the draft of your Realm App."
+}
+
+// Help returns long-form help information for this command
+func (ddc *DraftsDeployCommand) Help() string {
+	return `Deploy a draft of your Realm Application once its changes, which are shown first, are confirmed. The
+app's draft is used when no ID is given.
+
+Usage: realm-cli drafts deploy [options] [id]
+
+OPTIONS:
+  --timeout [duration] (default: 10m)
+	How long to wait for the deployment to finish before failing, e.g. "90s" or "15m". Use 0 to wait
+	for as long as it takes.
+` +
+		ddc.AppCommand.Help()
+}
+
+// Run executes the command
+func (ddc *DraftsDeployCommand) Run(args []string) int {
+	ddc.NewFlagSet()
+
+	ddc.FlagSet.DurationVar(&ddc.flagTimeout, draftsFlagTimeout, defaultDeployTimeout, "")
+
+	if err := ddc.AppCommand.run(args); err != nil {
+		return ddc.fail(err)
+	}
+
+	if err := ddc.deployDraft(ddc.FlagSet.Arg(0)); err != nil {
+		return ddc.fail(err)
+	}
+
+	return 0
+}
+
+func (ddc *DraftsDeployCommand) deployDraft(draftID string) error {
+	app, err := ddc.resolveApp()
+	if err != nil {
+		return err
+	}
+
+	realmClient, err := ddc.RealmClient()
+	if err != nil {
+		return err
+	}
+
+	draftID, err = resolveDraftID(realmClient, app, draftID)
+	if err != nil {
+		return err
+	}
+
+	diff, err := realmClient.DraftDiff(app.GroupID, app.ID, draftID)
+	if err != nil {
+		return err
+	}
+
+	result := draftResult{ID: draftID, Diffs: diff.Diffs, HostingFilesDiff: &diff.HostingFilesDiff, Changes: draftChanges(*diff)}
+
+	if !diff.HasChanges() {
+		result.Status = draftStatusUnchanged
+		return ddc.report(result, fmt.Sprintf("Draft %s has no changes, nothing to do.", draftID))
+	}
+
+	for _, line := range draftDiffLines(*diff) {
+		ddc.UI.Info(line)
+	}
+
+	confirm, err := ddc.AskYesNo("Please confirm the changes shown above:")
+	if err != nil {
+		return err
+	}
+
+	if !confirm {
+		result.Status = draftStatusCancelled
+		return ddc.report(result, "Cancelling deploy.")
+	}
+
+	deployment, err := realmClient.DeployDraft(app.GroupID, app.ID, draftID)
+	if err != nil {
+		return fmt.Errorf("failed to deploy draft %s: %w", draftID, err)
+	}
+
+	deployment, err = ddc.waitForDeployment(realmClient, app.GroupID, app.ID, deployment, ddc.flagTimeout)
+	if err != nil {
+		return err
+	}
+
+	result.Status = draftStatusDeployed
+	result.DeploymentID = deployment.ID
+	result.DeploymentStatus = string(deployment.Status)
+
+	return ddc.report(result, fmt.Sprintf("Successfully deployed draft %s of '%s'", draftID, app.ClientAppID))
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/10gen/realm-cli/models"
+	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDraftDiffLines(t *testing.T) {
+	t.Run("should list the hosting files after the app's changes", func(t *testing.T) {
+		lines := draftDiffLines(models.DraftDiff{
+			Diffs: []string{"~~~ security"},
+			HostingFilesDiff: models.HostingDiff{
+				Added:    []string{"/index.html"},
+				Modified: []string{"/app.js", "/app.css"},
+			},
+		})
+
+		u.So(t, lines, gc.ShouldResemble, []string{
+			"~~~ security",
+			"New Files:",
+			"\t+ /index.html",
+			"Modified Files:",
+			"\t* /app.js",
+			"\t* /app.css",
+		})
+	})
+}
+
+func TestDraftsCommandsAgainstFakeAPI(t *testing.T) {
+	defer func(interval time.Duration) { deploymentPollInterval = interval }(deploymentPollInterval)
+	deploymentPollInterval = time.Millisecond
+
+	setup := func(t *testing.T) *fakeapi.Server {
+		server := fakeapi.NewServer()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+			return nil
+		}
+
+		exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=../testdata/simple_app_with_instance_data", "--draft-only", "-y"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 0)
+		u.So(t, server.App("my-app-abcdef").Draft, gc.ShouldNotBeNil)
+
+		return server
+	}
+
+	run := func(server *fakeapi.Server, factory func(cli.Ui) cli.CommandFactory, args ...string) (int, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := factory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		var appCommand *AppCommand
+		switch c := cmd.(type) {
+		case *DraftsListCommand:
+			appCommand = c.AppCommand
+		case *DraftsDiffCommand:
+			appCommand = c.AppCommand
+		case *DraftsDiscardCommand:
+			appCommand = c.AppCommand
+		case *DraftsDeployCommand:
+			appCommand = c.AppCommand
+		}
+		appCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		return cmd.Run(append([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef"}, args...)), mockUI
+	}
+
+	t.Run("should stage an import in a draft that can be reviewed and deployed", func(t *testing.T) {
+		server := setup(t)
+		defer server.Close()
+
+		draftID := server.App("my-app-abcdef").Draft.ID
+
+		exitCode, mockUI := run(server, NewDraftsListCommandFactory)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "ID\n"+draftID+"\n")
+
+		exitCode, mockUI = run(server, NewDraftsDiffCommandFactory, "--output=json")
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result draftResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.ID, gc.ShouldEqual, draftID)
+		u.So(t, result.Diffs, gc.ShouldNotBeEmpty)
+		u.So(t, result.Changes, gc.ShouldHaveLength, len(result.Diffs))
+
+		exitCode, mockUI = run(server, NewDraftsDeployCommandFactory, "-y", draftID)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEndWith, "Successfully deployed draft "+draftID+" of 'my-app-abcdef'\n")
+
+		app := server.App("my-app-abcdef")
+		u.So(t, app.Draft, gc.ShouldBeNil)
+		u.So(t, app.Deployments, gc.ShouldHaveLength, 1)
+		u.So(t, app.Deployments[0].Status, gc.ShouldEqual, models.DeploymentStatusSuccessful)
+		u.So(t, app.Config["name"], gc.ShouldEqual, "simple-app")
+
+		exitCode, mockUI = run(server, NewDraftsDiffCommandFactory)
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, errNoDraft.Error()+"\n")
+	})
+
+	t.Run("should discard a staged draft once confirmed", func(t *testing.T) {
+		server := setup(t)
+		defer server.Close()
+
+		exitCode, mockUI := run(server, NewDraftsDiscardCommandFactory, "--non-interactive")
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeMissingInput)
+		u.So(t, server.App("my-app-abcdef").Draft, gc.ShouldNotBeNil)
+
+		exitCode, mockUI = run(server, NewDraftsDiscardCommandFactory, "-y")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Draft, gc.ShouldBeNil)
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 0)
+	})
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/10gen/realm-cli/api"
+)
+
+// Exit codes returned by commands so that scripts can branch on the kind of failure. Exit code 2 is left
+// to the flag package, which exits with it for invalid flags
+const (
+	exitCodeError        = 1
+	exitCodeUnauthorized = 3
+	exitCodeForbidden    = 4
+	exitCodeNotFound     = 5
+	exitCodeValidation   = 6
+	exitCodeConflict     = 7
+	exitCodeRateLimited  = 8
+	exitCodeMissingInput = 9
+)
+
+var errorCategoryExitCodes = map[api.ErrorCategory]int{
+	api.ErrorCategoryUnauthorized: exitCodeUnauthorized,
+	api.ErrorCategoryForbidden:    exitCodeForbidden,
+	api.ErrorCategoryNotFound:     exitCodeNotFound,
+	api.ErrorCategoryValidation:   exitCodeValidation,
+	api.ErrorCategoryConflict:     exitCodeConflict,
+	api.ErrorCategoryRateLimited:  exitCodeRateLimited,
+}
+
+// ErrMissingInput is returned when a prompt cannot be answered, either because the command is
+// non-interactive or because stdin was exhausted
+type ErrMissingInput struct {
+	Prompt     string
+	Flag       string
+	HasDefault bool
+}
+
+func (err ErrMissingInput) Error() string {
+	var supply string
+	switch {
+	case err.Flag == flagYesName:
+		supply = fmt.Sprintf("supply --%s to confirm", flagYesName)
+	case err.Flag != "" && err.HasDefault:
+		supply = fmt.Sprintf("supply --%s, or --%s to accept the default", err.Flag, flagYesName)
+	case err.Flag != "":
+		supply = fmt.Sprintf("supply --%s", err.Flag)
+	default:
+		supply = fmt.Sprintf("supply --%s to accept the default", flagYesName)
+	}
+
+	return fmt.Sprintf("cannot prompt for %q without input: %s", err.Prompt, supply)
+}
+
+// ErrAppInvalid is returned when validating an app directory finds problems
+type ErrAppInvalid struct {
+	Problems int
+}
+
+func (err ErrAppInvalid) Error() string {
+	return fmt.Sprintf("found %d problem(s) in the app directory", err.Problems)
+}
+
+// fail reports the error, and how it can be resolved, to the user and returns the exit code of its category
+func (c *BaseCommand) fail(err error) int {
+	c.UI.Error(err.Error())
+
+	var missingInput ErrMissingInput
+	if errors.As(err, &missingInput) {
+		return exitCodeMissingInput
+	}
+
+	var appInvalid ErrAppInvalid
+	if errors.As(err, &appInvalid) {
+		return exitCodeValidation
+	}
+
+	var categorized api.CategorizedError
+	if !errors.As(err, &categorized) {
+		return exitCodeError
+	}
+
+	if hint := categorized.Hint(); hint != "" {
+		c.UI.Warn(fmt.Sprintf("hint: %s", hint))
+	}
+
+	if exitCode, ok := errorCategoryExitCodes[categorized.Category()]; ok {
+		return exitCode
+	}
+
+	return exitCodeError
+}
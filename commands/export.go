@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,15 +27,19 @@ func NewExportCommandFactory(ui cli.Ui) cli.CommandFactory {
 			return nil, err
 		}
 
+		baseCommand := &BaseCommand{
+			Name: "export",
+			UI:   ui,
+		}
+
 		return &ExportCommand{
 			workingDirectory:     workingDirectory,
 			exportToDirectory:    utils.WriteZipToDir,
 			writeFileToDirectory: utils.WriteFileToDir,
-			getAssetAtURL:        getAssetAtURL,
-			BaseCommand: &BaseCommand{
-				Name: "export",
-				UI:   ui,
+			getAssetAtURL: func(url string) (io.ReadCloser, error) {
+				return getAssetAtURL(&http.Client{Transport: baseCommand.transport(traceClientHosting)}, url)
 			},
+			BaseCommand: baseCommand,
 		}, nil
 	}
 }
@@ -105,13 +110,11 @@ func (ec *ExportCommand) Run(args []string) int {
 	set.BoolVar(&ec.flagIncludeHosting, "include-hosting", false, "")
 
 	if err := ec.BaseCommand.run(args); err != nil {
-		ec.UI.Error(err.Error())
-		return 1
+		return ec.fail(err)
 	}
 
 	if err := ec.run(); err != nil {
-		ec.UI.Error(err.Error())
-		return 1
+		return ec.fail(err)
 	}
 
 	return 0
@@ -198,5 +201,16 @@ func (ec *ExportCommand) run() error {
 			return err
 		}
 	}
+
+	if ec.structuredOutput() {
+		return ec.writeResult(exportResult{AppID: app.ClientAppID, GroupID: app.GroupID, Path: filename})
+	}
 	return nil
 }
+
+// exportResult describes an exported app in structured output
+type exportResult struct {
+	AppID   string `json:"app_id" yaml:"app_id"`
+	GroupID string `json:"group_id" yaml:"group_id"`
+	Path    string `json:"path" yaml:"path"`
+}
@@ -16,8 +16,8 @@ import (
 	"github.com/10gen/realm-cli/utils"
 )
 
-func getAssetAtURL(url string) (io.ReadCloser, error) {
-	resp, err := http.Get(url)
+func getAssetAtURL(client *http.Client, url string) (io.ReadCloser, error) {
+	resp, err := client.Get(url)
 	if err != nil {
 		return nil, err
 	}
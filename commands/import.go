@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/dependency/transpiler"
 	"github.com/10gen/realm-cli/hosting"
 	"github.com/10gen/realm-cli/models"
 	u "github.com/10gen/realm-cli/user"
@@ -29,6 +30,20 @@ const (
 	importStrategyReplace         = "replace"
 	importStrategyReplaceByName   = "replace-by-name"
 	importFlagIncludeDependencies = "include-dependencies"
+	importFlagDeployTimeout       = "deploy-timeout"
+	importFlagNoWait              = "no-wait"
+	importFlagDraftOnly           = "draft-only"
+)
+
+// Set of statuses an import or diff can finish with
+const (
+	importStatusNotFound  = "not_found"
+	importStatusUnchanged = "unchanged"
+	importStatusChanged   = "changed"
+	importStatusCancelled = "cancelled"
+	importStatusImported  = "imported"
+	importStatusDeploying = "deploying"
+	importStatusDrafted   = "drafted"
 )
 
 // Set of location and deployment model options supported by Realm backend
@@ -38,15 +53,15 @@ var (
 )
 
 func errCreateAppSyncFailure(err error) error {
-	return fmt.Errorf("failed to sync app with local directory after creation: %s", err)
+	return fmt.Errorf("failed to sync app with local directory after creation: %w", err)
 }
 
 func errImportAppSyncFailure(err error) error {
-	return fmt.Errorf("failed to sync app with local directory after import: %s", err)
+	return fmt.Errorf("failed to sync app with local directory after import: %w", err)
 }
 
 func errIncludeHosting(err error) error {
-	return fmt.Errorf("--include-hosting error: %s", err)
+	return fmt.Errorf("--include-hosting error: %w", err)
 }
 
 // NewImportCommandFactory returns a new cli.CommandFactory given a cli.Ui
@@ -64,6 +79,7 @@ func NewImportCommandFactory(ui cli.Ui) cli.CommandFactory {
 			},
 			workingDirectory: workingDirectory,
 			writeToDirectory: utils.WriteZipToDir,
+			lintTranspiler:   lintTranspiler,
 			writeAppConfigToFile: func(dest string, app models.AppInstanceData) error {
 				return app.MarshalFile(dest)
 			},
@@ -87,6 +103,44 @@ type ImportCommand struct {
 	flagIncludeHosting      bool
 	flagResetCDNCache       bool
 	flagIncludeDependencies bool
+	flagVars                varsFlag
+	flagVarsFile            string
+	flagStrictVars          bool
+	flagSkipLint            bool
+	flagDeployTimeout       time.Duration
+	flagNoWait              bool
+	flagDraftOnly           bool
+
+	// lintTranspiler returns the transpiler function sources are compiled with before they are linted
+	lintTranspiler func() transpiler.Transpiler
+
+	// placeholders records whether each placeholder in the app's JSON files was resolved
+	placeholders map[string]bool
+
+	result importResult
+}
+
+// importResult describes the outcome of an import or diff in structured output
+type importResult struct {
+	AppID            string          `json:"app_id,omitempty" yaml:"app_id,omitempty"`
+	GroupID          string          `json:"group_id,omitempty" yaml:"group_id,omitempty"`
+	Environment      string          `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Status           string          `json:"status" yaml:"status"`
+	Created          bool            `json:"created,omitempty" yaml:"created,omitempty"`
+	Diffs            []string        `json:"diffs,omitempty" yaml:"diffs,omitempty"`
+	Changes          []models.Change `json:"changes,omitempty" yaml:"changes,omitempty"`
+	DraftID          string          `json:"draft_id,omitempty" yaml:"draft_id,omitempty"`
+	DeploymentID     string          `json:"deployment_id,omitempty" yaml:"deployment_id,omitempty"`
+	DeploymentStatus string          `json:"deployment_status,omitempty" yaml:"deployment_status,omitempty"`
+}
+
+// writeResult writes the outcome of the import in the selected structured output format
+func (ic *ImportCommand) writeResult() error {
+	if !ic.structuredOutput() {
+		return nil
+	}
+
+	return ic.BaseCommand.writeResult(ic.result)
 }
 
 // Help returns long-form help information for this command
@@ -123,6 +177,19 @@ OPTIONS:
   --include-dependencies
 	Upload the node_modules archive within the "/functions" directory.
 	The supported formats are: TAR, GZIP, and ZIP
+
+  --deploy-timeout [duration] (default: 10m)
+	How long to wait for the deployment to finish before failing, e.g. "90s" or "15m". A deployment still
+	in progress is not cancelled. Use 0 to wait for as long as it takes.
+
+  --no-wait
+	Return as soon as the deployment has started instead of waiting for it to finish. Check on it with
+	"realm-cli deployments wait [id]". The app directory is not synced, and post_deploy hooks are not run.
+
+  --draft-only
+	Stage the changes, along with any hosting assets and dependencies, in a draft without deploying it,
+	so that they can be reviewed with "realm-cli drafts diff" and deployed with "realm-cli drafts deploy".
+` + varsHelp + lintHelp + `
 	` +
 		ic.BaseCommand.Help()
 }
@@ -144,10 +211,17 @@ func (ic *ImportCommand) Run(args []string) int {
 	flags.BoolVar(&ic.flagIncludeHosting, importFlagIncludeHosting, false, "")
 	flags.BoolVar(&ic.flagResetCDNCache, importFlagResetCDNCache, false, "")
 	flags.BoolVar(&ic.flagIncludeDependencies, importFlagIncludeDependencies, false, "")
+	ic.flagVars = varsFlag{}
+	flags.Var(ic.flagVars, importFlagVar, "")
+	flags.StringVar(&ic.flagVarsFile, importFlagVarsFile, "", "")
+	flags.BoolVar(&ic.flagStrictVars, importFlagStrictVars, false, "")
+	flags.BoolVar(&ic.flagSkipLint, importFlagSkipLint, false, "")
+	flags.DurationVar(&ic.flagDeployTimeout, importFlagDeployTimeout, defaultDeployTimeout, "")
+	flags.BoolVar(&ic.flagNoWait, importFlagNoWait, false, "")
+	flags.BoolVar(&ic.flagDraftOnly, importFlagDraftOnly, false, "")
 
 	if err := ic.BaseCommand.run(args); err != nil {
-		ic.UI.Error(err.Error())
-		return 1
+		return ic.fail(err)
 	}
 
 	switch ic.flagStrategy {
@@ -159,14 +233,28 @@ func (ic *ImportCommand) Run(args []string) int {
 
 	dryRun := false
 	if err := ic.importApp(dryRun); err != nil {
-		ic.UI.Error(err.Error())
-		return 1
+		return ic.fail(err)
+	}
+
+	if err := ic.writeResult(); err != nil {
+		return ic.fail(err)
 	}
 
 	return 0
 }
 
+// importApp imports the app, running the on_failure hook when the import fails
 func (ic *ImportCommand) importApp(dryRun bool) error {
+	err := ic.runImport(dryRun)
+	if err != nil && !dryRun && ic.projectConfig != nil {
+		appPath, _ := utils.ResolveAppDirectory(ic.flagAppPath, ic.workingDirectory)
+		ic.runFailureHook(appPath, err)
+	}
+
+	return err
+}
+
+func (ic *ImportCommand) runImport(dryRun bool) error {
 	user, err := ic.User()
 	if err != nil {
 		return err
@@ -186,10 +274,22 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 		return err
 	}
 
-	loadedApp, err := utils.UnmarshalFromDir(appPath)
+	unmarshalOptions, err := ic.unmarshalOptions(appPath)
+	if err != nil {
+		return err
+	}
+
+	loadedApp, err := utils.UnmarshalFromDirWithOptions(appPath, unmarshalOptions)
 	if err != nil {
 		return err
 	}
+	ic.warnUnresolvedPlaceholders()
+
+	if !ic.flagSkipLint {
+		if err := ic.lintSources(appPath, unmarshalOptions, loadedApp); err != nil {
+			return err
+		}
+	}
 
 	appData, err := json.Marshal(loadedApp)
 	if err != nil {
@@ -217,8 +317,15 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 
 	var skipDiff bool
 
+	ic.result = importResult{AppID: appInstanceData.AppID(), Environment: ic.flagEnv, Status: importStatusCancelled}
+	if app != nil {
+		ic.result.AppID = app.ClientAppID
+		ic.result.GroupID = app.GroupID
+	}
+
 	if appNotFound {
 		if dryRun {
+			ic.result.Status = importStatusNotFound
 			ic.UI.Info(fmt.Sprintf("%s. To create a new app, use the 'import' command", err.Error()))
 			return nil
 		}
@@ -238,73 +345,61 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 		appInstanceData[models.AppIDField] = app.ClientAppID
 		appInstanceData[models.AppNameField] = app.Name
 
-		if writeErr := ic.writeAppConfigToFile(appPath, appInstanceData); writeErr != nil {
+		ic.result.AppID = app.ClientAppID
+		ic.result.GroupID = app.GroupID
+		ic.result.Created = true
+
+		if ic.flagEnv != "" {
+			ic.UI.Info(fmt.Sprintf("Set 'app_id: %s' for environment %q in %s to import to the new app from now on", app.ClientAppID, ic.flagEnv, ic.projectConfig.Path))
+		} else if writeErr := ic.writeAppConfigToFile(appPath, appInstanceData); writeErr != nil {
 			return errCreateAppSyncFailure(writeErr)
 		}
 	}
 
+	if !dryRun {
+		if err := ic.runHook(hookPreDiff, appPath); err != nil {
+			return err
+		}
+	}
+
 	var assetMetadataDiffs *hosting.AssetMetadataDiffs
 	rootDir, dirErr := filepath.Abs(filepath.Join(appPath, utils.HostingFilesDirectory))
 	if dirErr != nil {
 		return dirErr
 	}
 	if ic.flagIncludeHosting {
-		assetDescs, fileErr := hosting.MetadataFileToAssetDescriptions(filepath.Join(appPath, utils.HostingAttributes))
-		if fileErr != nil {
-			return errIncludeHosting(fmt.Errorf("error loading metadata.json file: %v", fileErr))
-		}
-
-		cachePath, cPErr := getAssetCachePath(ic.flagConfigPath)
-		if cPErr != nil {
-			return cPErr
-		}
-
-		assetCache, cErr := hosting.CacheFileToAssetCache(cachePath)
-		if cErr != nil {
-			if !os.IsNotExist(cErr) {
-				return cErr
-			}
-			assetCache = hosting.NewAssetCache()
-		}
-
-		localAssetMetadata, aMErr :=
-			hosting.ListLocalAssetMetadata(appInstanceData.AppID(), rootDir, assetDescs, assetCache)
-
-		if aMErr != nil {
-			return errIncludeHosting(fmt.Errorf("error processing local assets %s: %s", rootDir, aMErr))
-		}
-
-		if assetCache.Dirty() {
-			if uError := hosting.UpdateCacheFile(cachePath, assetCache); uError != nil {
-				ic.UI.Error(uError.Error())
-			}
-		}
-
-		remoteAssetMetadata, rAMErr := realmClient.ListAssetsForAppID(app.GroupID, app.ID)
-		if rAMErr != nil {
-			return errIncludeHosting(fmt.Errorf("error retrieving remote assets: %s", rAMErr))
+		if assetMetadataDiffs, err = ic.diffHostingAssets(realmClient, app, appPath, rootDir, appInstanceData.AppID()); err != nil {
+			return err
 		}
-
-		assetMetadataDiffs = hosting.DiffAssetMetadata(localAssetMetadata, remoteAssetMetadata, ic.flagStrategy == importStrategyMerge)
 	}
 
 	// Diff changes unless -y flag has been provided or if this is a new app
 	if !ic.flagYes && !skipDiff {
 		diffs, diffErr := realmClient.Diff(app.GroupID, app.ID, appData, ic.flagStrategy)
 		if diffErr != nil {
-			return fmt.Errorf("failed to diff app with currently deployed instance: %s", diffErr)
+			return fmt.Errorf("failed to diff app with currently deployed instance: %w", diffErr)
 		}
 
+		changes := models.ParseDiff(diffs)
+
 		if ic.flagIncludeHosting && assetMetadataDiffs != nil {
-			hostingDiff := assetMetadataDiffs.Diff()
-			diffs = append(diffs, hostingDiff...)
+			changes = append(changes, assetMetadataDiffs.Changes()...)
 		}
 
 		if ic.flagIncludeDependencies {
-			diffs = append(diffs, "Import dependencies")
+			changes = append(changes, models.Change{
+				Kind:      models.ChangeKindDependencies,
+				Operation: models.ChangeOperationModify,
+				Lines:     []string{"Import dependencies"},
+			})
 		}
 
-		if len(diffs) == 0 {
+		diffs = models.RenderChanges(changes)
+
+		ic.result.Diffs = diffs
+		ic.result.Changes = changes
+		if len(changes) == 0 {
+			ic.result.Status = importStatusUnchanged
 			ic.UI.Info("Deployed app is identical to proposed version, nothing to do.")
 			return nil
 		}
@@ -314,6 +409,7 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 		}
 
 		if dryRun {
+			ic.result.Status = importStatusChanged
 			return nil
 		}
 
@@ -330,8 +426,9 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 	ic.UI.Info("Creating draft for app...")
 	draft, err := realmClient.CreateDraft(app.GroupID, app.ID)
 	if err != nil {
-		if e, ok := err.(api.ErrRealmResponse); !ok || e.ErrorCode() != "DraftAlreadyExists" {
-			return fmt.Errorf("failed to create draft for import: %s", err)
+		var conflict api.ErrConflict
+		if !errors.As(err, &conflict) || conflict.ErrorCode() != api.ErrorCodeDraftAlreadyExists {
+			return fmt.Errorf("failed to create draft for import: %w", err)
 		}
 
 		drafts, draftErr := realmClient.GetDrafts(app.GroupID, app.ID)
@@ -341,7 +438,7 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 
 		appDraftDiff, diffErr := realmClient.DraftDiff(app.GroupID, app.ID, drafts[0].ID)
 		if diffErr != nil {
-			return fmt.Errorf("failed to fetch existing draft diff: %s", diffErr)
+			return fmt.Errorf("failed to fetch existing draft diff: %w", diffErr)
 		}
 
 		var discardDraft bool
@@ -355,12 +452,12 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 
 				discardDraft, err = ic.AskYesNo("Would you like to discard these changes?")
 				if err != nil {
-					return fmt.Errorf("failed to create draft for import: %s", err)
+					return fmt.Errorf("failed to create draft for import: %w", err)
 				}
 			} else {
 				discardDraft, err = ic.AskYesNo("An empty draft already exists for your app, would you like to discard it first?")
 				if err != nil {
-					return fmt.Errorf("failed to create draft for import: %s", err)
+					return fmt.Errorf("failed to create draft for import: %w", err)
 				}
 			}
 		}
@@ -369,12 +466,12 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 			ic.UI.Info("Discarding existing draft...")
 			err = realmClient.DiscardDraft(app.GroupID, app.ID, drafts[0].ID)
 			if err != nil {
-				return fmt.Errorf("failed to discard existing draft: %s", err)
+				return fmt.Errorf("failed to discard existing draft: %w", err)
 			}
 
 			draft, err = realmClient.CreateDraft(app.GroupID, app.ID)
 			if err != nil {
-				return fmt.Errorf("failed to create draft for import: %s", err)
+				return fmt.Errorf("failed to create draft for import: %w", err)
 			}
 		} else {
 			ic.UI.Info("Cancelling import.")
@@ -382,33 +479,119 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 		}
 	}
 
+	ic.result.DraftID = draft.ID
 	ic.UI.Info("Draft created successfully...")
 	ic.UI.Info("Importing app...")
 	if importErr := realmClient.Import(app.GroupID, app.ID, appData, ic.flagStrategy); importErr != nil {
 		ic.discardDraftAndWarnOnFailure(app.GroupID, app.ID, draft.ID)
-		return fmt.Errorf("failed to import app: %s", importErr)
+		return fmt.Errorf("failed to import app: %w", importErr)
+	}
+
+	if ic.flagDraftOnly {
+		if err := ic.importHostingAndDependencies(realmClient, app, appPath, rootDir, assetMetadataDiffs); err != nil {
+			return err
+		}
+
+		ic.result.Status = importStatusDrafted
+		ic.UI.Info(fmt.Sprintf("Successfully staged the changes to '%s' in draft %s; run 'realm-cli drafts deploy %s' to deploy them", app.ClientAppID, draft.ID, draft.ID))
+		return nil
 	}
 
-	ic.UI.Info("Deploying app...")
 	deployment, err := realmClient.DeployDraft(app.GroupID, app.ID, draft.ID)
 	if err != nil {
 		ic.discardDraftAndWarnOnFailure(app.GroupID, app.ID, draft.ID)
-		return fmt.Errorf("failed to deploy draft: %s", err)
+		return fmt.Errorf("failed to deploy draft: %w", err)
 	}
 
-	for deployment.Status == models.DeploymentStatusCreated || deployment.Status == models.DeploymentStatusPending {
-		time.Sleep(time.Second * 1)
-		ic.UI.Info("Deploying app...")
+	if !ic.flagNoWait {
+		deployment, err = ic.waitForDeployment(realmClient, app.GroupID, app.ID, deployment, ic.flagDeployTimeout)
+	} else if deployment.Status == models.DeploymentStatusFailed {
+		err = ErrDeploymentFailed{ID: deployment.ID, Message: deployment.StatusErrorMessage}
+	}
 
-		deployment, err = realmClient.GetDeployment(app.GroupID, app.ID, deployment.ID)
-		if err != nil {
-			ic.discardDraftAndWarnOnFailure(app.GroupID, app.ID, draft.ID)
-			return fmt.Errorf("failed to deploy draft: %s", err)
+	ic.result.DeploymentID = deployment.ID
+	ic.result.DeploymentStatus = string(deployment.Status)
+
+	if err != nil {
+		return err
+	}
+
+	if ic.flagNoWait {
+		ic.UI.Info(fmt.Sprintf("Started deployment %s; run 'realm-cli deployments wait %s' to wait for it to finish", deployment.ID, deployment.ID))
+	}
+
+	if err := ic.importHostingAndDependencies(realmClient, app, appPath, rootDir, assetMetadataDiffs); err != nil {
+		return err
+	}
+
+	if ic.flagNoWait {
+		ic.result.Status = importStatusDeploying
+		ic.UI.Info(fmt.Sprintf("Successfully imported '%s', which is still deploying", app.ClientAppID))
+		return nil
+	}
+
+	// the local app is shared by every environment, so it is not overwritten with an environment's app, which
+	// differs from it by the environment's overlay, nor with the values its placeholders resolved to
+	if ic.flagEnv == "" && len(ic.placeholders) == 0 {
+		if err := ic.syncAppDirectory(realmClient, app, appPath); err != nil {
+			return err
+		}
+	}
+
+	if err := ic.runHook(hookPostDeploy, appPath); err != nil {
+		return err
+	}
+
+	ic.result.Status = importStatusImported
+	ic.UI.Info(fmt.Sprintf("Successfully imported '%s'", app.ClientAppID))
+
+	return nil
+}
+
+// diffHostingAssets compares the hosting files in the app directory with those of the app, caching the hashes of
+// the local files
+func (ic *ImportCommand) diffHostingAssets(realmClient api.RealmClient, app *models.App, appPath, rootDir, clientAppID string) (*hosting.AssetMetadataDiffs, error) {
+	assetDescs, fileErr := hosting.MetadataFileToAssetDescriptions(filepath.Join(appPath, utils.HostingAttributes))
+	if fileErr != nil {
+		return nil, errIncludeHosting(fmt.Errorf("error loading metadata.json file: %v", fileErr))
+	}
+
+	cachePath, cPErr := getAssetCachePath(ic.flagConfigPath)
+	if cPErr != nil {
+		return nil, cPErr
+	}
+
+	assetCache, cErr := hosting.CacheFileToAssetCache(cachePath)
+	if cErr != nil {
+		if !os.IsNotExist(cErr) {
+			return nil, cErr
+		}
+		assetCache = hosting.NewAssetCache()
+	}
+
+	localAssetMetadata, aMErr :=
+		hosting.ListLocalAssetMetadata(clientAppID, rootDir, assetDescs, assetCache)
+
+	if aMErr != nil {
+		return nil, errIncludeHosting(fmt.Errorf("error processing local assets %s: %s", rootDir, aMErr))
+	}
+
+	if assetCache.Dirty() {
+		if uError := hosting.UpdateCacheFile(cachePath, assetCache); uError != nil {
+			ic.UI.Error(uError.Error())
 		}
 	}
 
-	ic.UI.Info("Done.")
+	remoteAssetMetadata, rAMErr := realmClient.ListAssetsForAppID(app.GroupID, app.ID)
+	if rAMErr != nil {
+		return nil, errIncludeHosting(fmt.Errorf("error retrieving remote assets: %w", rAMErr))
+	}
 
+	return hosting.DiffAssetMetadata(localAssetMetadata, remoteAssetMetadata, ic.flagStrategy == importStrategyMerge), nil
+}
+
+// importHostingAndDependencies uploads the hosting assets and dependencies of the app, when they are included
+func (ic *ImportCommand) importHostingAndDependencies(realmClient api.RealmClient, app *models.App, appPath, rootDir string, assetMetadataDiffs *hosting.AssetMetadataDiffs) error {
 	if ic.flagIncludeHosting && assetMetadataDiffs != nil {
 		ic.UI.Info("Importing hosting assets...")
 		if hostingImportErr := ImportHosting(app.GroupID, app.ID, rootDir, assetMetadataDiffs, ic.flagResetCDNCache, realmClient, ic.UI); hostingImportErr != nil {
@@ -430,6 +613,11 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 		ic.UI.Info("Done.")
 	}
 
+	return nil
+}
+
+// syncAppDirectory overwrites the local app directory with the app as it was deployed
+func (ic *ImportCommand) syncAppDirectory(realmClient api.RealmClient, app *models.App, appPath string) error {
 	exportStrategy := api.ExportStrategyNone
 	if ic.flagStrategy == importStrategyReplaceByName {
 		exportStrategy = api.ExportStrategySourceControl
@@ -446,8 +634,6 @@ func (ic *ImportCommand) importApp(dryRun bool) error {
 		return errImportAppSyncFailure(err)
 	}
 
-	ic.UI.Info(fmt.Sprintf("Successfully imported '%s'", app.ClientAppID))
-
 	return nil
 }
 
@@ -496,7 +682,7 @@ func (ic *ImportCommand) resolveGroupID() (string, error) {
 
 	var groupID string
 	for {
-		projectResponse, err := ic.Ask("Atlas Project Name or ID", groups[0].Name)
+		projectResponse, err := ic.Ask("Atlas Project Name or ID", groups[0].Name, flagProjectIDName)
 		if err != nil {
 			return "", err
 		}
@@ -541,7 +727,7 @@ func (ic *ImportCommand) askCreateEmptyApp(query, defaultAppName, defaultLocatio
 		return nil, false, nil
 	}
 
-	appName, err := ic.Ask("App name", defaultAppName)
+	appName, err := ic.Ask("App name", defaultAppName, importFlagAppName)
 	if err != nil {
 		return nil, false, err
 	}
@@ -562,12 +748,12 @@ func (ic *ImportCommand) askCreateEmptyApp(query, defaultAppName, defaultLocatio
 		}
 	}
 
-	location, err := ic.AskWithOptions("Location", defaultLocation, locationOptions)
+	location, err := ic.AskWithOptions("Location", defaultLocation, locationOptions, "")
 	if err != nil {
 		return nil, false, err
 	}
 
-	deploymentModel, err := ic.AskWithOptions("Deployment Model", defaultDeploymentModel, deploymentModelOptions)
+	deploymentModel, err := ic.AskWithOptions("Deployment Model", defaultDeploymentModel, deploymentModelOptions, "")
 	if err != nil {
 		return nil, false, err
 	}
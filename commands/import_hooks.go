@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Names of the points of an import hooks run at
+const (
+	hookPreDiff    = "pre_diff"
+	hookPostDeploy = "post_deploy"
+	hookOnFailure  = "on_failure"
+)
+
+// Environment variables hooks are run with
+const (
+	hookEnvHook         = "REALM_CLI_HOOK"
+	hookEnvAppID        = "REALM_CLI_APP_ID"
+	hookEnvGroupID      = "REALM_CLI_GROUP_ID"
+	hookEnvDraftID      = "REALM_CLI_DRAFT_ID"
+	hookEnvDeploymentID = "REALM_CLI_DEPLOYMENT_ID"
+	hookEnvEnvironment  = "REALM_CLI_ENV"
+	hookEnvAppPath      = "REALM_CLI_APP_PATH"
+	hookEnvError        = "REALM_CLI_ERROR"
+)
+
+// ErrHookFailed is returned when a hook command exits with an error
+type ErrHookFailed struct {
+	Hook    string
+	Command string
+	Err     error
+}
+
+func (err ErrHookFailed) Error() string {
+	return fmt.Sprintf("%s hook %q failed: %s", err.Hook, err.Command, err.Err)
+}
+
+func (err ErrHookFailed) Unwrap() error {
+	return err.Err
+}
+
+// hookCommands returns the commands of the named hook given by the project config file
+func (ic *ImportCommand) hookCommands(hook string) ([]string, error) {
+	if ic.projectConfig == nil {
+		return nil, nil
+	}
+
+	hooks, err := ic.projectConfig.ResolveHooks(ic.flagEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	switch hook {
+	case hookPreDiff:
+		return hooks.PreDiff, nil
+	case hookPostDeploy:
+		return hooks.PostDeploy, nil
+	case hookOnFailure:
+		return hooks.OnFailure, nil
+	}
+
+	return nil, fmt.Errorf("unknown hook %q", hook)
+}
+
+// runHook runs the commands of the named hook in order from the directory of the project config file, stopping at
+// the first that fails. The commands are given the import's IDs known so far as environment variables
+func (ic *ImportCommand) runHook(hook, appPath string, extraEnv ...string) error {
+	commands, err := ic.hookCommands(hook)
+	if err != nil {
+		return err
+	}
+
+	env := append(os.Environ(),
+		hookEnvHook+"="+hook,
+		hookEnvAppID+"="+ic.result.AppID,
+		hookEnvGroupID+"="+ic.result.GroupID,
+		hookEnvDraftID+"="+ic.result.DraftID,
+		hookEnvDeploymentID+"="+ic.result.DeploymentID,
+		hookEnvEnvironment+"="+ic.flagEnv,
+		hookEnvAppPath+"="+appPath,
+	)
+	env = append(env, extraEnv...)
+
+	for _, command := range commands {
+		ic.UI.Info(fmt.Sprintf("Running %s hook: %s", hook, command))
+
+		cmd := shellCommand(command)
+		cmd.Dir = ic.projectConfig.Dir()
+		cmd.Env = env
+
+		stdout := &uiLineWriter{write: ic.UI.Info}
+		stderr := &uiLineWriter{write: ic.UI.Warn}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		runErr := cmd.Run()
+		stdout.Flush()
+		stderr.Flush()
+
+		if runErr != nil {
+			return ErrHookFailed{Hook: hook, Command: command, Err: runErr}
+		}
+	}
+
+	return nil
+}
+
+// runFailureHook runs the on_failure hook for the error the import failed with, only warning when it fails itself
+func (ic *ImportCommand) runFailureHook(appPath string, importErr error) {
+	if err := ic.runHook(hookOnFailure, appPath, hookEnvError+"="+importErr.Error()); err != nil {
+		ic.UI.Warn(err.Error())
+	}
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// uiLineWriter writes each line written to it to the UI
+type uiLineWriter struct {
+	write func(string)
+	buf   bytes.Buffer
+}
+
+func (w *uiLineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// keep the incomplete line until the rest of it is written
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+
+		w.write(strings.TrimRight(line, "\r\n"))
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the last line when it does not end with a newline
+func (w *uiLineWriter) Flush() {
+	if w.buf.Len() != 0 {
+		w.write(w.buf.String())
+		w.buf.Reset()
+	}
+}
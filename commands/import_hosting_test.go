@@ -164,6 +164,7 @@ func TestHostingOp(t *testing.T) {
 					},
 					true,
 					false,
+					hosting.AssetMetadata{},
 				},
 			}
 			u.So(t, modify.Do(), gc.ShouldNotBeNil)
@@ -179,6 +180,7 @@ func TestHostingOp(t *testing.T) {
 				},
 				true,
 				false,
+				hosting.AssetMetadata{},
 			},
 		}
 
@@ -214,6 +216,7 @@ func TestHostingOp(t *testing.T) {
 				},
 				false,
 				true,
+				hosting.AssetMetadata{},
 			},
 		}
 
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/10gen/realm-cli/dependency/transpiler"
+	"github.com/10gen/realm-cli/lint"
+	"github.com/10gen/realm-cli/utils"
+)
+
+const importFlagSkipLint = "skip-lint"
+
+// lintHelp documents the flag that skips linting function sources
+const lintHelp = `
+  --skip-lint
+	Do not check function and webhook sources for syntax errors, a missing exports = function(...), and
+	context.functions.execute calls to functions that are not in the app directory.
+`
+
+// lintTranspiler returns the transpiler function sources are compiled with before they are linted, or nil when it is
+// not installed
+func lintTranspiler() transpiler.Transpiler {
+	if _, err := exec.LookPath(transpiler.DefaultTranspilerCommand); err != nil {
+		return nil
+	}
+
+	return transpiler.NewExternalTranspiler(transpiler.DefaultTranspilerCommand)
+}
+
+// lintSources reports the problems of the app's function and webhook sources, failing when any is an error
+func (ic *ImportCommand) lintSources(appPath string, unmarshalOptions utils.UnmarshalOptions, app map[string]interface{}) error {
+	sourceFiles, err := utils.ReadSourceFiles(appPath, unmarshalOptions)
+	if err != nil {
+		return err
+	}
+
+	sources := make([]lint.Source, 0, len(sourceFiles))
+	for _, sourceFile := range sourceFiles {
+		sources = append(sources, lint.Source{Path: sourceFile.Path, Code: sourceFile.Source})
+	}
+
+	functions := map[string]bool{}
+	if appFunctions, ok := app[utils.FunctionsRoot].([]interface{}); ok {
+		for _, appFunction := range appFunctions {
+			config, _ := appFunction.(map[string]interface{})["config"].(map[string]interface{})
+			if name, ok := config["name"].(string); ok {
+				functions[name] = true
+			}
+		}
+	}
+
+	var linter lint.Linter
+	if ic.lintTranspiler != nil {
+		linter.Transpiler = ic.lintTranspiler()
+	}
+
+	problems, err := linter.Lint(context.Background(), sources, functions)
+	if err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, problem := range problems {
+		if problem.Severity == lint.SeverityError {
+			errorCount++
+			ic.UI.Error(problem.String())
+		} else {
+			ic.UI.Warn(problem.String())
+		}
+	}
+
+	if errorCount != 0 {
+		return ErrAppInvalid{Problems: errorCount}
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 
@@ -19,9 +20,11 @@ import (
 	mock_api "github.com/10gen/realm-cli/api/mocks"
 	"github.com/10gen/realm-cli/hosting"
 	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/project"
 	"github.com/10gen/realm-cli/user"
 	"github.com/10gen/realm-cli/utils"
 	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
 	gc "github.com/smartystreets/goconvey/convey"
 
 	"github.com/mitchellh/cli"
@@ -438,7 +441,7 @@ func TestImportNewApp(t *testing.T) {
 			{
 				Description:      "returns an error when an invalid location is entered",
 				Args:             []string{"--path=../testdata/new_app"},
-				ExpectedExitCode: 1,
+				ExpectedExitCode: exitCodeMissingInput,
 				RealmClient: u.MockRealmClient{
 					ExportFn: func(groupID, appID string, strategy api.ExportStrategy) (string, io.ReadCloser, error) {
 						return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
@@ -460,7 +463,7 @@ func TestImportNewApp(t *testing.T) {
 			{
 				Description:      "returns an error when an invalid deployment model is entered",
 				Args:             []string{"--path=../testdata/new_app"},
-				ExpectedExitCode: 0,
+				ExpectedExitCode: exitCodeMissingInput,
 				RealmClient: u.MockRealmClient{
 					ExportFn: func(groupID, appID string, strategy api.ExportStrategy) (string, io.ReadCloser, error) {
 						return "", u.NewResponseBody(bytes.NewReader([]byte{})), nil
@@ -1083,6 +1086,393 @@ func TestImportCommand(t *testing.T) {
 	})
 }
 
+func TestImportCommandAgainstFakeAPI(t *testing.T) {
+	t.Run("should deploy the app and export it back", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "simple-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error { return nil }
+
+		exitCode := importCommand.Run([]string{
+			"--base-url=" + server.URL,
+			"--project-id=" + groupID,
+			"--path=../testdata/simple_app_with_instance_data",
+			"-y",
+		})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 1)
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		mockUI = cli.NewMockUi()
+		cmd, err = NewExportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		exportCommand := cmd.(*ExportCommand)
+		exportCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		exportCommand.workingDirectory = dir
+
+		exitCode = exportCommand.Run([]string{
+			"--base-url=" + server.URL,
+			"--app-id=my-app-abcdef",
+			"--output=" + filepath.Join(dir, "simple-app"),
+		})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		expected, err := utils.UnmarshalFromDir("../testdata/simple_app_with_instance_data")
+		u.So(t, err, gc.ShouldBeNil)
+
+		exported, err := utils.UnmarshalFromDir(filepath.Join(dir, "simple-app"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, exported, gc.ShouldResemble, expected)
+	})
+
+	t.Run("should deploy to the app of the selected environment with its overlay and keep the app directory unchanged", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "simple-app")
+		server.AddApp(groupID, "my-app-staging", "simple-app-staging")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		appConfig, err := ioutil.ReadFile("../testdata/simple_app_with_instance_data/config.json")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), appConfig, 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, project.ConfigFileName), []byte(`environments:
+  staging:
+    app_id: my-app-staging
+    project_id: `+groupID+`
+`), 0600), gc.ShouldBeNil)
+
+		overlayDir := utils.EnvironmentOverlayDirectory(dir, "staging")
+		u.So(t, os.MkdirAll(overlayDir, 0700), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(overlayDir, models.AppConfigFileName), []byte(`{"security": {"allowed_request_origins": ["https://staging.example.com"]}}`), 0600), gc.ShouldBeNil)
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=" + dir, "--env=staging", "-y"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-staging").Deployments, gc.ShouldHaveLength, 1)
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 0)
+		u.So(t, server.App("my-app-staging").Config["security"], gc.ShouldResemble, map[string]interface{}{
+			"allowed_request_origins": []interface{}{"https://staging.example.com"},
+		})
+
+		var appInstanceData models.AppInstanceData
+		u.So(t, appInstanceData.UnmarshalFile(dir), gc.ShouldBeNil)
+		u.So(t, appInstanceData.AppID(), gc.ShouldEqual, "my-app-abcdef")
+		u.So(t, appInstanceData.AppName(), gc.ShouldEqual, "simple-app")
+		u.So(t, appInstanceData["security"], gc.ShouldResemble, map[string]interface{}{
+			"allowed_request_origins": []interface{}{},
+		})
+	})
+
+	t.Run("should resolve placeholders from --var, then the vars file, then the environment and keep the app directory unchanged", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		appConfig := `{
+  "app_id": "my-app-abcdef",
+  "name": "simple-app",
+  "config_version": 20200603,
+  "security": {"allowed_request_origins": ["https://${ORIGIN_HOST}", "https://${SECOND_HOST}", "https://${THIRD_HOST}"]}
+}`
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), []byte(appConfig), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "vars.yaml"), []byte("ORIGIN_HOST: file.example.com\nSECOND_HOST: second.example.com\n"), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, project.ConfigFileName), []byte("commands:\n  import:\n    vars-file: vars.yaml\n"), 0600), gc.ShouldBeNil)
+
+		os.Setenv("REALM_CLI_VAR_THIRD_HOST", "env.example.com")
+		defer os.Unsetenv("REALM_CLI_VAR_THIRD_HOST")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=" + dir, "--var=ORIGIN_HOST=flag.example.com", "--strict-vars", "-y"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Config["security"], gc.ShouldResemble, map[string]interface{}{
+			"allowed_request_origins": []interface{}{"https://flag.example.com", "https://second.example.com", "https://env.example.com"},
+		})
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, models.AppConfigFileName))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(data), gc.ShouldEqual, appConfig)
+	})
+
+	t.Run("should fail before deploying when a function source does not export a function unless --skip-lint is supplied", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		appConfig, err := ioutil.ReadFile("../testdata/simple_app_with_instance_data/config.json")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), appConfig, 0600), gc.ShouldBeNil)
+
+		functionDir := filepath.Join(dir, utils.FunctionsRoot, "greet")
+		u.So(t, os.MkdirAll(functionDir, 0700), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "config.json"), []byte(`{"name": "greet", "private": false}`), 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(functionDir, "source.js"), []byte("exports = 'hello';\ncontext.functions.execute('farewell');\n"), 0600), gc.ShouldBeNil)
+
+		run := func(args ...string) (int, *cli.MockUi) {
+			mockUI := cli.NewMockUi()
+			cmd, err := NewImportCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			importCommand := cmd.(*ImportCommand)
+			importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+			importCommand.lintTranspiler = nil
+			importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+				return nil
+			}
+
+			return importCommand.Run(append([]string{"--base-url=" + server.URL, "--path=" + dir, "-y"}, args...)), mockUI
+		}
+
+		exitCode, mockUI := run()
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeValidation)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, `functions/greet/source.js:1:11: error: exports must be a function
+functions/greet/source.js:2:27: warning: context.functions.execute calls "farewell", which is not a function of the app directory
+found 1 problem(s) in the app directory
+`)
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 0)
+
+		exitCode, mockUI = run("--skip-lint")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 1)
+	})
+
+	t.Run("should run the project's hooks with the import's IDs and abort the import when a pre_diff hook fails", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "simple-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		appConfig, err := ioutil.ReadFile("../testdata/simple_app_with_instance_data/config.json")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), appConfig, 0600), gc.ShouldBeNil)
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, project.ConfigFileName), []byte(`hooks:
+  pre_diff:
+    - echo "checking $REALM_CLI_APP_ID in $REALM_CLI_GROUP_ID"
+    - test ! -f abort
+  post_deploy:
+    - echo "$REALM_CLI_DRAFT_ID $REALM_CLI_DEPLOYMENT_ID" > deployed.txt
+  on_failure:
+    - echo "$REALM_CLI_HOOK after $REALM_CLI_ERROR" > failed.txt
+`), 0600), gc.ShouldBeNil)
+
+		run := func() (int, *cli.MockUi) {
+			mockUI := cli.NewMockUi()
+			cmd, err := NewImportCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			importCommand := cmd.(*ImportCommand)
+			importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+			importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+				return nil
+			}
+
+			return importCommand.Run([]string{"--base-url=" + server.URL, "--path=" + dir, "-y"}), mockUI
+		}
+
+		exitCode, mockUI := run()
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Running pre_diff hook: echo \"checking $REALM_CLI_APP_ID in $REALM_CLI_GROUP_ID\"\nchecking my-app-abcdef in "+groupID+"\n")
+
+		deployments := server.App("my-app-abcdef").Deployments
+		u.So(t, deployments, gc.ShouldHaveLength, 1)
+
+		deployed, err := ioutil.ReadFile(filepath.Join(dir, "deployed.txt"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, strings.Fields(string(deployed)), gc.ShouldHaveLength, 2)
+		u.So(t, strings.Fields(string(deployed))[1], gc.ShouldEqual, deployments[0].ID)
+
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, "abort"), nil, 0600), gc.ShouldBeNil)
+
+		exitCode, mockUI = run()
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "pre_diff hook \"test ! -f abort\" failed: exit status 1\n")
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 1)
+
+		failed, err := ioutil.ReadFile(filepath.Join(dir, "failed.txt"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(failed), gc.ShouldEqual, "on_failure after pre_diff hook \"test ! -f abort\" failed: exit status 1\n")
+	})
+
+	t.Run("should leave placeholders as they are unless values are supplied for them", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		appConfig := `{"app_id": "my-app-abcdef", "name": "simple-app", "config_version": 20200603, "security": {"allowed_request_origins": ["https://${ORIGIN_HOST}"]}}`
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), []byte(appConfig), 0600), gc.ShouldBeNil)
+
+		os.Setenv("ORIGIN_HOST", "env.example.com")
+		defer os.Unsetenv("ORIGIN_HOST")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error { return nil }
+
+		exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=" + dir, "-y"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Config["security"], gc.ShouldResemble, map[string]interface{}{
+			"allowed_request_origins": []interface{}{"https://${ORIGIN_HOST}"},
+		})
+	})
+
+	t.Run("should fail in strict mode before deploying when a placeholder has no value", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-fakeapi")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		appConfig := `{"app_id": "my-app-abcdef", "name": "simple-app", "config_version": 20200603, "security": {"allowed_request_origins": ["https://${UNSET_HOST}"]}}`
+		u.So(t, ioutil.WriteFile(filepath.Join(dir, models.AppConfigFileName), []byte(appConfig), 0600), gc.ShouldBeNil)
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=" + dir, "--strict-vars", "-y"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "config.json: ${UNSET_HOST}")
+		u.So(t, server.App("my-app-abcdef").Deployments, gc.ShouldHaveLength, 0)
+	})
+
+	t.Run("with a deployment that does not finish successfully", func(t *testing.T) {
+		defer func(interval time.Duration) { deploymentPollInterval = interval }(deploymentPollInterval)
+		deploymentPollInterval = time.Millisecond
+
+		setup := func(t *testing.T) (*fakeapi.Server, *ImportCommand, *cli.MockUi) {
+			server := fakeapi.NewServer()
+			server.AddApp("5e0000000000000000000001", "my-app-abcdef", "simple-app")
+
+			mockUI := cli.NewMockUi()
+			cmd, err := NewImportCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			importCommand := cmd.(*ImportCommand)
+			importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+			importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error {
+				return nil
+			}
+
+			return server, importCommand, mockUI
+		}
+
+		t.Run("should fail with the error message of a failed deployment", func(t *testing.T) {
+			server, importCommand, mockUI := setup(t)
+			defer server.Close()
+			server.FailDeployments = true
+
+			exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=../testdata/simple_app_with_instance_data", "-y"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+
+			deployments := server.App("my-app-abcdef").Deployments
+			u.So(t, deployments, gc.ShouldHaveLength, 1)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Deploying app... failed after 0s\n")
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "Successfully imported")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, fmt.Sprintf("deployment %s failed: %s\n", deployments[0].ID, fakeapi.DeploymentErrorMessage))
+		})
+
+		t.Run("should fail once the deploy timeout has passed", func(t *testing.T) {
+			server, importCommand, mockUI := setup(t)
+			defer server.Close()
+			server.DeploymentPolls = 1000000
+
+			exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=../testdata/simple_app_with_instance_data", "--deploy-timeout=20ms", "-y"})
+			u.So(t, exitCode, gc.ShouldEqual, 1)
+
+			deployments := server.App("my-app-abcdef").Deployments
+			u.So(t, deployments, gc.ShouldHaveLength, 1)
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Deploying app... timed out after 0s\n")
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldStartWith, fmt.Sprintf("deployment %s is still pending after 20ms", deployments[0].ID))
+		})
+
+		t.Run("should rewrite a single progress line on a terminal", func(t *testing.T) {
+			server, importCommand, mockUI := setup(t)
+			defer server.Close()
+			server.DeploymentPolls = 2
+
+			terminal := new(bytes.Buffer)
+			importCommand.progressWriter = terminal
+
+			exitCode := importCommand.Run([]string{"--base-url=" + server.URL, "--path=../testdata/simple_app_with_instance_data", "-y"})
+			u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldNotContainSubstring, "Deploying app...")
+			u.So(t, terminal.String(), gc.ShouldEqual, "Deploying app..."+
+				"\r\033[KDeploying app... 0s (created)"+
+				"\r\033[KDeploying app... 0s (pending)"+
+				"\r\033[KDeploying app... 0s (pending)"+
+				"\r\033[KDeploying app... done in 0s\n")
+		})
+	})
+}
+
 func abs(path string) string {
 	p, err := filepath.Abs(path)
 	if err != nil {
@@ -76,13 +76,22 @@ func (lc *LoginCommand) Run(args []string) int {
 	set.StringVar(&lc.flagUsername, flagLoginUsernameName, "", "")
 
 	if err := lc.BaseCommand.run(args); err != nil {
-		lc.UI.Error(err.Error())
-		return 1
+		return lc.fail(err)
+	}
+
+	// login persists supplied API keys itself rather than authenticating with them ephemerally
+	if creds := lc.credentials; creds != nil && creds.hasAPIKey() {
+		if lc.flagAPIKey == "" && lc.flagPrivateAPIKey == "" && lc.flagUsername == "" {
+			lc.flagAPIKey = creds.PublicAPIKey
+			lc.flagPrivateAPIKey = creds.PrivateAPIKey
+		}
+
+		creds.PublicAPIKey = ""
+		creds.PrivateAPIKey = ""
 	}
 
 	if err := lc.logIn(); err != nil {
-		lc.UI.Error(err.Error())
-		return 1
+		return lc.fail(err)
 	}
 
 	return 0
@@ -118,7 +127,7 @@ func (lc *LoginCommand) validateAuthCredentials() (auth.AuthenticationProvider,
 		provider = auth.NewAPIKeyProvider(apiKey, privateAPIKey)
 	case auth.ProviderTypeUsernamePassword:
 		if lc.flagPassword == "" {
-			password, err := lc.UI.AskSecret("Password:")
+			password, err := lc.AskSecret("Password:", "password")
 			if err != nil {
 				return nil, err
 			}
@@ -156,7 +165,7 @@ func (lc *LoginCommand) logIn() error {
 		))
 
 		if askErr != nil {
-			return err
+			return askErr
 		}
 
 		if !shouldContinue {
@@ -186,11 +195,20 @@ func (lc *LoginCommand) logIn() error {
 	user.AccessToken = authResponse.AccessToken
 	user.RefreshToken = authResponse.RefreshToken
 
+	// remember the servers this profile was logged in to, and how to reach them, so they need not be supplied again
+	if baseURL, ok := lc.suppliedBaseURL(); ok {
+		user.BaseURL = baseURL
+	}
+
+	if atlasBaseURL, ok := lc.suppliedAtlasBaseURL(); ok {
+		user.AtlasBaseURL = atlasBaseURL
+	}
+
+	lc.rememberTransportConfig(user)
+
 	if err := lc.storage.WriteUserConfig(user); err != nil {
 		return err
 	}
 
-	lc.UI.Info(fmt.Sprintf("you have successfully logged in as %s", user.PublicAPIKey))
-
-	return nil
+	return lc.report(whoamiResult{PublicAPIKey: user.PublicAPIKey, APIKey: user.RedactedAPIKey()}, fmt.Sprintf("you have successfully logged in as %s", user.PublicAPIKey))
 }
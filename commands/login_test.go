@@ -1,13 +1,19 @@
 package commands
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/10gen/realm-cli/auth"
+	"github.com/10gen/realm-cli/secrets"
+	"github.com/10gen/realm-cli/storage"
 	"github.com/10gen/realm-cli/user"
 	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
 	gc "github.com/smartystreets/goconvey/convey"
 
 	"github.com/mitchellh/cli"
@@ -102,6 +108,76 @@ func TestLoginCommand(t *testing.T) {
 
 			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "you have successfully logged in as my-api-key")
 		})
+
+		t.Run("stores the credentials and base url under the selected profile", func(t *testing.T) {
+			loginCommand, _ := setup()
+			exitCode := loginCommand.Run([]string{
+				`--profile=staging`,
+				`--base-url=https://staging.example.com`,
+				`--api-key=my-api-key`,
+				`--private-api-key=my-private-api-key`,
+			})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			storedUser, err := loginCommand.storage.ReadUserConfig()
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "my-private-api-key")
+			u.So(t, storedUser.BaseURL, gc.ShouldEqual, "https://staging.example.com")
+
+			defaultUser, err := loginCommand.storage.WithProfile("default").ReadUserConfig()
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, defaultUser, gc.ShouldResemble, &user.User{})
+		})
+		t.Run("stores the proxy and TLS settings provided by flag with the profile", func(t *testing.T) {
+			loginCommand, _ := setup()
+			exitCode := loginCommand.Run([]string{
+				`--proxy=http://proxy.example.com:3128`,
+				`--insecure-skip-verify`,
+				`--api-key=my-api-key`,
+				`--private-api-key=my-private-api-key`,
+			})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			storedUser, err := loginCommand.storage.ReadUserConfig()
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, storedUser.ProxyURL, gc.ShouldEqual, "http://proxy.example.com:3128")
+			u.So(t, storedUser.InsecureSkipVerify, gc.ShouldBeTrue)
+			u.So(t, storedUser.CABundle, gc.ShouldBeEmpty)
+		})
+
+		t.Run("logs the user in with a credentials document from stdin", func(t *testing.T) {
+			loginCommand, _ := setup()
+			loginCommand.stdin = strings.NewReader(`{"public_api_key": "my-api-key", "private_api_key": "my-private-api-key", "base_url": "https://staging.example.com"}`)
+			exitCode := loginCommand.Run([]string{`--credentials-stdin`})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			storedUser, err := loginCommand.storage.ReadUserConfig()
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, storedUser, gc.ShouldResemble, &user.User{
+				PublicAPIKey:  "my-api-key",
+				PrivateAPIKey: "my-private-api-key",
+				AccessToken:   "new.access.token",
+				RefreshToken:  "new.refresh.token",
+				BaseURL:       "https://staging.example.com",
+			})
+		})
+
+		t.Run("logs the user in with API keys from the environment", func(t *testing.T) {
+			os.Setenv(envPublicAPIKey, "env-api-key")
+			os.Setenv(envPrivateAPIKey, "env-private-api-key")
+			defer os.Unsetenv(envPublicAPIKey)
+			defer os.Unsetenv(envPrivateAPIKey)
+
+			loginCommand, mockUI := setup()
+			exitCode := loginCommand.Run([]string{})
+			u.So(t, exitCode, gc.ShouldEqual, 0)
+
+			storedUser, err := loginCommand.storage.ReadUserConfig()
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "env-private-api-key")
+
+			u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "you have successfully logged in as env-api-key")
+		})
 	})
 
 	t.Run("when the user is logged in", func(t *testing.T) {
@@ -189,3 +265,74 @@ func TestLoginCommand(t *testing.T) {
 		})
 	})
 }
+
+func TestLoginCommandRecordingCassette(t *testing.T) {
+	t.Run("should replay a recorded login and the commands run with its session", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "my-app")
+		server.App("my-app-abcdef").Secrets = []secrets.Secret{{ID: "5e0a1b2c3d4e5f6a7b8c9d0e", Name: "my-secret"}}
+
+		dir, err := ioutil.TempDir("", "realm-cli-cassette")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		loginCassette := filepath.Join(dir, "login.json")
+		listCassette := filepath.Join(dir, "secrets_list.json")
+
+		login := func(store *storage.Storage, args ...string) (int, *cli.MockUi) {
+			mockUI := cli.NewMockUi()
+			cmd, err := NewLoginCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			loginCommand := cmd.(*LoginCommand)
+			loginCommand.storage = store
+
+			args = append([]string{"--base-url=" + server.URL, "--api-key=my-api-key", "--private-api-key=my-private-api-key"}, args...)
+			return loginCommand.Run(args), mockUI
+		}
+
+		list := func(store *storage.Storage, args ...string) (int, *cli.MockUi) {
+			mockUI := cli.NewMockUi()
+			cmd, err := NewSecretsListCommandFactory(mockUI)()
+			u.So(t, err, gc.ShouldBeNil)
+
+			listCommand := cmd.(*SecretsListCommand)
+			listCommand.storage = store
+
+			args = append([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef"}, args...)
+			return listCommand.Run(args), mockUI
+		}
+
+		recordingStorage := u.NewEmptyStorage()
+
+		exitCode, mockUI := login(recordingStorage, "--record-cassette="+loginCassette)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		exitCode, mockUI = list(recordingStorage, "--record-cassette="+listCassette)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		server.Close()
+
+		replayingStorage := u.NewEmptyStorage()
+
+		exitCode, mockUI = login(replayingStorage, "--replay-cassette="+loginCassette)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		replayedUser, err := replayingStorage.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+
+		expired, err := replayedUser.TokenIsExpired()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, expired, gc.ShouldBeFalse)
+
+		exitCode, mockUI = list(replayingStorage, "--replay-cassette="+listCassette)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "5e0a1b2c3d4e5f6a7b8c9d0e my-secret")
+	})
+}
@@ -37,13 +37,11 @@ OPTIONS:` +
 // Run executes the command
 func (lc *LogoutCommand) Run(args []string) int {
 	if err := lc.BaseCommand.run(args); err != nil {
-		lc.UI.Error(err.Error())
-		return 1
+		return lc.fail(err)
 	}
 
 	if err := lc.storage.Clear(); err != nil {
-		lc.UI.Error(err.Error())
-		return 1
+		return lc.fail(err)
 	}
 
 	return 0
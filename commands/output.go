@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	flagOutputName       = "output"
+	flagOutputFormatName = "output-format"
+
+	outputFormatTable = "table"
+	outputFormatJSON  = "json"
+	outputFormatYAML  = "yaml"
+)
+
+// registerOutputFlag adds --output as a shorthand for --output-format, unless the command defines an
+// --output flag of its own
+func (c *BaseCommand) registerOutputFlag() {
+	if c.Lookup(flagOutputName) == nil {
+		c.StringVar(&c.flagOutputFormat, flagOutputName, outputFormatTable, "")
+	}
+}
+
+// setUpOutput validates the output format and, for structured formats, sends the command's
+// free-form messages to stderr so that stdout only holds its result
+func (c *BaseCommand) setUpOutput() error {
+	switch c.flagOutputFormat {
+	case outputFormatTable:
+		return nil
+	case outputFormatJSON, outputFormatYAML:
+		c.resultUI = c.UI
+		c.UI = &stderrUI{c.UI}
+		return nil
+	}
+
+	return fmt.Errorf("unknown output format %q; accepted values are [%s|%s|%s]", c.flagOutputFormat, outputFormatTable, outputFormatJSON, outputFormatYAML)
+}
+
+// structuredOutput returns whether the command's result is written as JSON or YAML rather than as text
+func (c *BaseCommand) structuredOutput() bool {
+	return c.flagOutputFormat == outputFormatJSON || c.flagOutputFormat == outputFormatYAML
+}
+
+// writeResult writes the command's result to stdout in the selected structured output format
+func (c *BaseCommand) writeResult(result interface{}) error {
+	var data []byte
+	var err error
+
+	switch c.flagOutputFormat {
+	case outputFormatJSON:
+		data, err = json.MarshalIndent(result, "", "  ")
+	case outputFormatYAML:
+		data, err = yaml.Marshal(result)
+	default:
+		return fmt.Errorf("unknown output format %q", c.flagOutputFormat)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write result: %s", err)
+	}
+
+	ui := c.resultUI
+	if ui == nil {
+		ui = c.UI
+	}
+	ui.Output(strings.TrimSuffix(string(data), "\n"))
+
+	return nil
+}
+
+// report writes the result in the selected structured output format, or the message for table output
+func (c *BaseCommand) report(result interface{}, message string) error {
+	if c.structuredOutput() {
+		return c.writeResult(result)
+	}
+
+	c.UI.Info(message)
+
+	return nil
+}
+
+// stderrUI is a cli.Ui that writes informational messages and prompts to stderr
+type stderrUI struct {
+	cli.Ui
+}
+
+// Info writes the message to stderr
+func (ui *stderrUI) Info(message string) {
+	ui.Ui.Error(message)
+}
+
+// Output writes the message to stderr
+func (ui *stderrUI) Output(message string) {
+	ui.Ui.Error(message)
+}
+
+// Ask writes the query to stderr and reads the answer
+func (ui *stderrUI) Ask(query string) (string, error) {
+	return ui.prompter().Ask(query)
+}
+
+// AskSecret writes the query to stderr and reads the answer without echoing it
+func (ui *stderrUI) AskSecret(query string) (string, error) {
+	return ui.prompter().AskSecret(query)
+}
+
+// prompter returns a cli.Ui that reads answers as the wrapped cli.Ui does, but writes the prompts for them to
+// its stderr rather than its stdout
+func (ui *stderrUI) prompter() cli.Ui {
+	switch wrapped := ui.Ui.(type) {
+	case *cli.BasicUi:
+		return &cli.BasicUi{Reader: wrapped.Reader, Writer: wrapped.ErrorWriter, ErrorWriter: wrapped.ErrorWriter}
+	case *cli.MockUi:
+		// a new MockUi replaces its writers when it is first used, unless it is made by NewMockUi
+		mockUI := cli.NewMockUi()
+		mockUI.InputReader, mockUI.OutputWriter, mockUI.ErrorWriter = wrapped.InputReader, wrapped.ErrorWriter, wrapped.ErrorWriter
+		return mockUI
+	}
+	return ui.Ui
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/secrets"
+	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/yaml.v2"
+)
+
+func TestStderrUI(t *testing.T) {
+	setup := func(format, input string) (*BaseCommand, *bytes.Buffer, *bytes.Buffer) {
+		var stdout, stderr bytes.Buffer
+		base := &BaseCommand{
+			UI:               &cli.BasicUi{Reader: strings.NewReader(input), Writer: &stdout, ErrorWriter: &stderr},
+			flagOutputFormat: format,
+		}
+		u.So(t, base.setUpOutput(), gc.ShouldBeNil)
+
+		return base, &stdout, &stderr
+	}
+
+	for _, format := range []string{outputFormatJSON, outputFormatYAML} {
+		t.Run("should write prompts to stderr with "+format+" output", func(t *testing.T) {
+			base, stdout, stderr := setup(format, "y\n")
+			confirm, err := base.AskYesNo("Continue?")
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, confirm, gc.ShouldBeTrue)
+			u.So(t, stdout.String(), gc.ShouldBeEmpty)
+			u.So(t, stderr.String(), gc.ShouldEqual, "Continue? [y/n]: ")
+
+			base, stdout, stderr = setup(format, "my-name\n")
+			name, err := base.Ask("Name", "", "name")
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, name, gc.ShouldEqual, "my-name")
+			u.So(t, stdout.String(), gc.ShouldBeEmpty)
+			u.So(t, stderr.String(), gc.ShouldEqual, "Name: ")
+
+			base, stdout, stderr = setup(format, "hunter2\n")
+			secret, err := base.AskSecret("Secret:", "secret")
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, secret, gc.ShouldEqual, "hunter2")
+			u.So(t, stdout.String(), gc.ShouldBeEmpty)
+			u.So(t, stderr.String(), gc.ShouldEqual, "Secret: ")
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	t.Run("should write the secrets list as JSON to stdout", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "my-app")
+		server.App("my-app-abcdef").Secrets = []secrets.Secret{{ID: "1", Name: "my-secret"}}
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewSecretsListCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		listCommand := cmd.(*SecretsListCommand)
+		listCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := listCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "--output=json"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var results []secretResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &results), gc.ShouldBeNil)
+		u.So(t, results, gc.ShouldResemble, []secretResult{{ID: "1", Name: "my-secret"}})
+	})
+
+	t.Run("should write the added secret as YAML and its progress to stderr", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "my-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewSecretsAddCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		addCommand := cmd.(*SecretsAddCommand)
+		addCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := addCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "--name=my-secret", "--value=hunter2", "--output-format=yaml"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result secretResult
+		u.So(t, yaml.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Name, gc.ShouldEqual, "my-secret")
+		u.So(t, result.Status, gc.ShouldEqual, secretStatusCreated)
+	})
+
+	t.Run("should fail with an unknown output format", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewWhoamiCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		whoamiCommand := cmd.(*WhoamiCommand)
+		whoamiCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := whoamiCommand.Run([]string{"--output=xml"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `unknown output format "xml"`)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldBeEmpty)
+	})
+
+	t.Run("should keep --output as the export path and accept --output-format", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "my-app")
+
+		dir, err := ioutil.TempDir("", "realm-cli-output")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewExportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		exportCommand := cmd.(*ExportCommand)
+		exportCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		exportCommand.workingDirectory = dir
+
+		path := filepath.Join(dir, "my-app")
+		exitCode := exportCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "--output=" + path, "--output-format=json"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result exportResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldResemble, exportResult{AppID: "my-app-abcdef", GroupID: groupID, Path: path})
+	})
+
+	t.Run("should write the import result as JSON", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "simple-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error { return nil }
+
+		exitCode := importCommand.Run([]string{
+			"--base-url=" + server.URL,
+			"--project-id=" + groupID,
+			"--path=../testdata/simple_app_with_instance_data",
+			"--output=json",
+			"-y",
+		})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "Successfully imported 'my-app-abcdef'")
+
+		var result importResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.AppID, gc.ShouldEqual, "my-app-abcdef")
+		u.So(t, result.GroupID, gc.ShouldEqual, groupID)
+		u.So(t, result.Status, gc.ShouldEqual, importStatusImported)
+		u.So(t, result.DeploymentID, gc.ShouldEqual, server.App("my-app-abcdef").Deployments[0].ID)
+		u.So(t, result.DeploymentStatus, gc.ShouldEqual, "successful")
+	})
+
+	t.Run("should write the prompts of an import to stderr", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "simple-app")
+
+		mockUI := cli.NewMockUi()
+		mockUI.InputReader = strings.NewReader("y\n")
+		cmd, err := NewImportCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		importCommand := cmd.(*ImportCommand)
+		importCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		importCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error { return nil }
+
+		exitCode := importCommand.Run([]string{
+			"--base-url=" + server.URL,
+			"--project-id=" + groupID,
+			"--path=../testdata/simple_app_with_instance_data",
+			"--output=json",
+		})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "[y/n]:")
+
+		var result importResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Status, gc.ShouldEqual, importStatusImported)
+	})
+
+	t.Run("should write the changes of a diff as JSON", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		groupID := "5e0000000000000000000001"
+		server.AddApp(groupID, "my-app-abcdef", "simple-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewDiffCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		diffCommand := cmd.(*DiffCommand)
+		diffCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+		diffCommand.writeToDirectory = func(dest string, zipData io.Reader, overwrite bool) error { return nil }
+
+		exitCode := diffCommand.Run([]string{
+			"--base-url=" + server.URL,
+			"--project-id=" + groupID,
+			"--path=../testdata/simple_app_with_instance_data",
+			"--output=json",
+		})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		var result importResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Status, gc.ShouldEqual, importStatusChanged)
+		u.So(t, result.Changes, gc.ShouldHaveLength, len(result.Diffs))
+		for i, change := range result.Changes {
+			u.So(t, change.Kind, gc.ShouldNotBeEmpty)
+			u.So(t, change.Operation, gc.ShouldBeIn, models.ChangeOperationAdd, models.ChangeOperationModify, models.ChangeOperationRemove)
+			u.So(t, change.Lines, gc.ShouldResemble, []string{result.Diffs[i]})
+		}
+	})
+}
@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+)
+
+var (
+	errProfileNameRequired = errors.New("a profile name must be supplied")
+)
+
+// NewProfilesCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewProfilesCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &ProfilesCommand{
+			BaseCommand: &BaseCommand{
+				Name: "profiles",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// ProfilesCommand is used to manage the named profiles that user credentials are stored under
+type ProfilesCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (pc *ProfilesCommand) Synopsis() string {
+	return "List, select or delete named credential profiles."
+}
+
+// Help returns long-form help information for this command
+func (pc *ProfilesCommand) Help() string {
+	return pc.Synopsis()
+}
+
+// Run executes the command
+func (pc *ProfilesCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// NewProfilesListCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewProfilesListCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &ProfilesListCommand{
+			BaseCommand: &BaseCommand{
+				Name: "profiles list",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// ProfilesListCommand is used to list the available profiles
+type ProfilesListCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (plc *ProfilesListCommand) Synopsis() string {
+	return "List the available profiles."
+}
+
+// Help returns long-form help information for this command
+func (plc *ProfilesListCommand) Help() string {
+	return `List the available profiles. The profile currently in use is marked with "*".
+
+Usage: realm-cli profiles list [options]
+
+OPTIONS:` +
+		plc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (plc *ProfilesListCommand) Run(args []string) int {
+	if err := plc.BaseCommand.run(args); err != nil {
+		return plc.fail(err)
+	}
+
+	if err := plc.listProfiles(); err != nil {
+		return plc.fail(err)
+	}
+
+	return 0
+}
+
+func (plc *ProfilesListCommand) listProfiles() error {
+	profiles, err := plc.storage.Profiles()
+	if err != nil {
+		return err
+	}
+
+	currentProfile, err := plc.storage.CurrentProfile()
+	if err != nil {
+		return err
+	}
+
+	if plc.structuredOutput() {
+		results := make([]profileResult, 0, len(profiles))
+		for _, profile := range profiles {
+			results = append(results, profileResult{Name: profile, Current: profile == currentProfile})
+		}
+
+		return plc.writeResult(results)
+	}
+
+	for _, profile := range profiles {
+		marker := " "
+		if profile == currentProfile {
+			marker = "*"
+		}
+
+		plc.UI.Info(fmt.Sprintf("%s %s", marker, profile))
+	}
+
+	return nil
+}
+
+// profileResult describes a profile in structured output
+type profileResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Current bool   `json:"current" yaml:"current"`
+}
+
+// NewProfilesUseCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewProfilesUseCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &ProfilesUseCommand{
+			BaseCommand: &BaseCommand{
+				Name: "profiles use",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// ProfilesUseCommand is used to select the profile used when --profile is not supplied
+type ProfilesUseCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (puc *ProfilesUseCommand) Synopsis() string {
+	return "Select the profile to use by default."
+}
+
+// Help returns long-form help information for this command
+func (puc *ProfilesUseCommand) Help() string {
+	return `Select the profile used by all commands when --profile is not supplied.
+
+Usage: realm-cli profiles use [options] [name]
+
+OPTIONS:` +
+		puc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (puc *ProfilesUseCommand) Run(args []string) int {
+	if err := puc.BaseCommand.run(args); err != nil {
+		return puc.fail(err)
+	}
+
+	profile := puc.FlagSet.Arg(0)
+	if profile == "" {
+		puc.UI.Error(errProfileNameRequired.Error())
+		return 1
+	}
+
+	if err := puc.storage.UseProfile(profile); err != nil {
+		return puc.fail(err)
+	}
+
+	if err := puc.report(profileResult{Name: profile, Current: true}, fmt.Sprintf("Now using profile: %s", profile)); err != nil {
+		return puc.fail(err)
+	}
+
+	return 0
+}
+
+// NewProfilesDeleteCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewProfilesDeleteCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return &ProfilesDeleteCommand{
+			BaseCommand: &BaseCommand{
+				Name: "profiles delete",
+				UI:   ui,
+			},
+		}, nil
+	}
+}
+
+// ProfilesDeleteCommand is used to delete a profile and its stored credentials
+type ProfilesDeleteCommand struct {
+	*BaseCommand
+}
+
+// Synopsis returns a one-liner description for this command
+func (pdc *ProfilesDeleteCommand) Synopsis() string {
+	return "Delete a profile and its stored credentials."
+}
+
+// Help returns long-form help information for this command
+func (pdc *ProfilesDeleteCommand) Help() string {
+	return `Delete a profile and its stored credentials.
+
+Usage: realm-cli profiles delete [options] [name]
+
+OPTIONS:` +
+		pdc.BaseCommand.Help()
+}
+
+// Run executes the command
+func (pdc *ProfilesDeleteCommand) Run(args []string) int {
+	if err := pdc.BaseCommand.run(args); err != nil {
+		return pdc.fail(err)
+	}
+
+	if err := pdc.deleteProfile(pdc.FlagSet.Arg(0)); err != nil {
+		return pdc.fail(err)
+	}
+
+	return 0
+}
+
+func (pdc *ProfilesDeleteCommand) deleteProfile(profile string) error {
+	if profile == "" {
+		return errProfileNameRequired
+	}
+
+	confirm, err := pdc.AskYesNo(fmt.Sprintf("Are you sure you want to delete profile %q and its credentials?", profile))
+	if err != nil {
+		return err
+	}
+
+	if !confirm {
+		return nil
+	}
+
+	if err := pdc.storage.DeleteProfile(profile); err != nil {
+		return err
+	}
+
+	return pdc.report(profileResult{Name: profile}, fmt.Sprintf("Profile deleted: %s", profile))
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/10gen/realm-cli/storage"
+	"github.com/10gen/realm-cli/user"
+	u "github.com/10gen/realm-cli/utils/test"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func newProfilesStorage() *storage.Storage {
+	strg := u.NewPopulatedStorage("default-api-key", "refresh", "access")
+	if err := strg.WithProfile("staging").WriteUserConfig(&user.User{
+		PublicAPIKey:  "staging.name",
+		PrivateAPIKey: "staging-api-key",
+	}); err != nil {
+		panic(err)
+	}
+
+	return strg
+}
+
+func TestProfilesListCommand(t *testing.T) {
+	setup := func(strg *storage.Storage) (*ProfilesListCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewProfilesListCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		listCommand := cmd.(*ProfilesListCommand)
+		listCommand.storage = strg
+
+		return listCommand, mockUI
+	}
+
+	t.Run("lists all profiles and marks the one in use", func(t *testing.T) {
+		listCommand, mockUI := setup(newProfilesStorage())
+
+		exitCode := listCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "* default\n  staging\n")
+	})
+
+	t.Run("marks the profile supplied with --profile as the one in use", func(t *testing.T) {
+		listCommand, mockUI := setup(newProfilesStorage())
+
+		exitCode := listCommand.Run([]string{"--profile=staging"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "  default\n* staging\n")
+	})
+}
+
+func TestProfilesUseCommand(t *testing.T) {
+	setup := func(strg *storage.Storage) (*ProfilesUseCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewProfilesUseCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		useCommand := cmd.(*ProfilesUseCommand)
+		useCommand.storage = strg
+
+		return useCommand, mockUI
+	}
+
+	t.Run("should require a profile name", func(t *testing.T) {
+		useCommand, mockUI := setup(newProfilesStorage())
+
+		exitCode := useCommand.Run([]string{})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, errProfileNameRequired.Error())
+	})
+
+	t.Run("should fail for a profile that does not exist", func(t *testing.T) {
+		useCommand, mockUI := setup(newProfilesStorage())
+
+		exitCode := useCommand.Run([]string{"prod"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, `profile "prod" does not exist`)
+	})
+
+	t.Run("should select the profile for later commands", func(t *testing.T) {
+		strg := newProfilesStorage()
+		useCommand, mockUI := setup(strg)
+
+		exitCode := useCommand.Run([]string{"staging"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Now using profile: staging")
+
+		storedUser, err := strg.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "staging-api-key")
+	})
+}
+
+func TestProfilesDeleteCommand(t *testing.T) {
+	setup := func(strg *storage.Storage) (*ProfilesDeleteCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewProfilesDeleteCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		deleteCommand := cmd.(*ProfilesDeleteCommand)
+		deleteCommand.storage = strg
+
+		return deleteCommand, mockUI
+	}
+
+	t.Run("should not delete the profile if the user cancels", func(t *testing.T) {
+		strg := newProfilesStorage()
+		deleteCommand, mockUI := setup(strg)
+
+		mockUI.InputReader = strings.NewReader("n\n")
+		exitCode := deleteCommand.Run([]string{"staging"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		profiles, err := strg.Profiles()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, profiles, gc.ShouldResemble, []string{"default", "staging"})
+	})
+
+	t.Run("should fail instead of prompting with --non-interactive", func(t *testing.T) {
+		strg := newProfilesStorage()
+		deleteCommand, mockUI := setup(strg)
+
+		exitCode := deleteCommand.Run([]string{"--non-interactive", "staging"})
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeMissingInput)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "supply --yes to confirm")
+
+		profiles, err := strg.Profiles()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, profiles, gc.ShouldResemble, []string{"default", "staging"})
+	})
+
+	t.Run("should delete the profile when confirmed", func(t *testing.T) {
+		strg := newProfilesStorage()
+		deleteCommand, mockUI := setup(strg)
+
+		exitCode := deleteCommand.Run([]string{"-y", "staging"})
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "Profile deleted: staging")
+
+		profiles, err := strg.Profiles()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, profiles, gc.ShouldResemble, []string{"default"})
+	})
+
+	t.Run("should not delete the default profile", func(t *testing.T) {
+		deleteCommand, mockUI := setup(newProfilesStorage())
+
+		exitCode := deleteCommand.Run([]string{"-y", "default"})
+		u.So(t, exitCode, gc.ShouldEqual, 1)
+
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, storage.ErrDefaultProfileNotDeleted.Error())
+	})
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mitchellh/cli"
+)
+
+// clearLine returns the cursor to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// progressLine is a message whose status is rewritten in place as it changes when it is shown on a terminal.
+// Elsewhere, e.g. in a CI log, only the message and its final status are written
+type progressLine struct {
+	ui       cli.Ui
+	terminal io.Writer
+	message  string
+}
+
+// terminalWriter returns the writer the command's messages are shown on when it is a terminal, or nil
+func (c *BaseCommand) terminalWriter() io.Writer {
+	basicUI, ok := c.UI.(*cli.BasicUi)
+	if !ok {
+		return nil
+	}
+
+	writer := basicUI.Writer
+	if c.structuredOutput() {
+		writer = basicUI.ErrorWriter
+	}
+
+	file, ok := writer.(*os.File)
+	if !ok || !isatty.IsTerminal(file.Fd()) {
+		return nil
+	}
+
+	return file
+}
+
+// colorEnabled reports whether output to stdout may be colored, which is when it is a terminal and colors were not
+// disabled with --disable-color
+func (c *BaseCommand) colorEnabled() bool {
+	return !c.flagColorDisabled && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// startProgress writes the message and returns the progressLine that updates its status
+func (c *BaseCommand) startProgress(message string) *progressLine {
+	p := &progressLine{ui: c.UI, terminal: c.progressWriter, message: message}
+
+	if p.terminal == nil {
+		p.ui.Info(message)
+	} else {
+		fmt.Fprint(p.terminal, message)
+	}
+
+	return p
+}
+
+// Update rewrites the line with the status, which is only shown on a terminal
+func (p *progressLine) Update(status string) {
+	if p.terminal == nil {
+		return
+	}
+
+	fmt.Fprintf(p.terminal, "%s%s %s", clearLine, p.message, status)
+}
+
+// Finish writes the final status of the line
+func (p *progressLine) Finish(status string) {
+	if p.terminal == nil {
+		p.ui.Info(fmt.Sprintf("%s %s", p.message, status))
+		return
+	}
+
+	fmt.Fprintf(p.terminal, "%s%s %s\n", clearLine, p.message, status)
+}
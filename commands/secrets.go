@@ -4,10 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/10gen/realm-cli/models"
 	"github.com/10gen/realm-cli/secrets"
-	u "github.com/10gen/realm-cli/user"
-	"github.com/10gen/realm-cli/utils"
 	"github.com/mitchellh/cli"
 )
 
@@ -52,6 +49,20 @@ const (
 	flagSecretNameIdentifierDeprecated = "secret-name"
 )
 
+// The statuses reported for a secret that was changed
+const (
+	secretStatusCreated = "created"
+	secretStatusUpdated = "updated"
+	secretStatusRemoved = "removed"
+)
+
+// secretResult is the structured output of a secret, and of the change made to it
+type secretResult struct {
+	ID     string `json:"id,omitempty" yaml:"id,omitempty"`
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
 var (
 	errSecretNameRequired     = fmt.Errorf("a name (--%s=[string]) is required", flagSecretName)
 	errSecretValueRequired    = fmt.Errorf("a value (--%s=[string]) is required", flagSecretValue)
@@ -61,87 +72,13 @@ var (
 // NewSecretsBaseCommand returns a new *SecretsBaseCommand
 func NewSecretsBaseCommand(name, workingDirectory string, ui cli.Ui) *SecretsBaseCommand {
 	return &SecretsBaseCommand{
-		ProjectCommand:   NewProjectCommand(name, ui),
-		workingDirectory: workingDirectory,
+		AppCommand: NewAppCommand(name, workingDirectory, ui),
 	}
 }
 
 // SecretsBaseCommand represents a common Atlas project-based secrets command
 type SecretsBaseCommand struct {
-	*ProjectCommand
-
-	workingDirectory string
-
-	flagAppID string
-}
-
-// Help returns long-form help information for the SecretsBaseCommand command
-func (sbc *SecretsBaseCommand) Help() string {
-	return `
-OPTIONAL:
-  --app-id [string]
-	The App ID for your app (i.e. the name of your app followed by a unique suffix, like "my-app-nysja").
-	Required if not being run from within a realm project directory.` +
-		sbc.ProjectCommand.Help()
-}
-
-func (sbc *SecretsBaseCommand) run(args []string) error {
-	if sbc.FlagSet == nil {
-		sbc.NewFlagSet()
-	}
-
-	sbc.FlagSet.StringVar(&sbc.flagAppID, flagAppIDName, "", "")
-
-	if err := sbc.ProjectCommand.run(args); err != nil {
-		return err
-	}
-
-	user, err := sbc.User()
-	if err != nil {
-		return err
-	}
-
-	if !user.LoggedIn() {
-		return u.ErrNotLoggedIn
-	}
-
-	return nil
-}
-
-func (sbc *SecretsBaseCommand) resolveApp() (*models.App, error) {
-	appID := sbc.flagAppID
-	if sbc.flagAppID == "" {
-		appPath, err := utils.ResolveAppDirectory("", sbc.workingDirectory)
-		if err != nil {
-			return nil, err
-		}
-
-		appInstanceData, err := utils.ResolveAppInstanceData(sbc.flagAppID, appPath)
-		if err != nil {
-			return nil, err
-		}
-		appID = appInstanceData.AppID()
-	}
-
-	realmClient, err := sbc.RealmClient()
-	if err != nil {
-		return nil, err
-	}
-
-	var app *models.App
-	if sbc.flagProjectID == "" {
-		app, err = realmClient.FetchAppByClientAppID(appID)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		app, err = realmClient.FetchAppByGroupIDAndClientAppID(sbc.flagProjectID, appID)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return app, nil
+	*AppCommand
 }
 
 // NewSecretsListCommandFactory returns a new cli.CommandFactory given a cli.Ui
@@ -153,7 +90,7 @@ func NewSecretsListCommandFactory(ui cli.Ui) cli.CommandFactory {
 		}
 
 		return &SecretsListCommand{
-			SecretsBaseCommand: NewSecretsBaseCommand("list", workingDirectory, ui),
+			SecretsBaseCommand: NewSecretsBaseCommand("secrets list", workingDirectory, ui),
 		}, nil
 	}
 }
@@ -180,14 +117,24 @@ Usage: realm-cli secrets list [options]
 // Run executes the command
 func (slc *SecretsListCommand) Run(args []string) int {
 	if err := slc.SecretsBaseCommand.run(args); err != nil {
-		slc.UI.Error(err.Error())
-		return 1
+		return slc.fail(err)
 	}
 
 	secrets, err := slc.listSecrets()
 	if err != nil {
-		slc.UI.Error(err.Error())
-		return 1
+		return slc.fail(err)
+	}
+
+	if slc.structuredOutput() {
+		results := make([]secretResult, 0, len(secrets))
+		for _, secret := range secrets {
+			results = append(results, secretResult{ID: secret.ID, Name: secret.Name})
+		}
+
+		if err := slc.writeResult(results); err != nil {
+			return slc.fail(err)
+		}
+		return 0
 	}
 
 	if len(secrets) == 0 {
@@ -226,7 +173,7 @@ func NewSecretsAddCommandFactory(ui cli.Ui) cli.CommandFactory {
 		}
 
 		return &SecretsAddCommand{
-			SecretsBaseCommand: NewSecretsBaseCommand("add", workingDirectory, ui),
+			SecretsBaseCommand: NewSecretsBaseCommand("secrets add", workingDirectory, ui),
 		}, nil
 	}
 }
@@ -268,13 +215,11 @@ func (sac *SecretsAddCommand) Run(args []string) int {
 	sac.FlagSet.StringVar(&sac.flagSecretValue, flagSecretValue, "", "")
 
 	if err := sac.SecretsBaseCommand.run(args); err != nil {
-		sac.UI.Error(err.Error())
-		return 1
+		return sac.fail(err)
 	}
 
 	if err := sac.addSecret(); err != nil {
-		sac.UI.Error(err.Error())
-		return 1
+		return sac.fail(err)
 	}
 
 	return 0
@@ -306,8 +251,10 @@ func (sac *SecretsAddCommand) addSecret() error {
 		return addErr
 	}
 
-	sac.UI.Info(fmt.Sprintf("New secret created: %s", sac.flagSecretName))
-	return nil
+	return sac.report(
+		secretResult{Name: sac.flagSecretName, Status: secretStatusCreated},
+		fmt.Sprintf("New secret created: %s", sac.flagSecretName),
+	)
 }
 
 // NewSecretsUpdateCommandFactory returns a new cli.CommandFactory given a cli.Ui
@@ -319,7 +266,7 @@ func NewSecretsUpdateCommandFactory(ui cli.Ui) cli.CommandFactory {
 		}
 
 		return &SecretsUpdateCommand{
-			SecretsBaseCommand: NewSecretsBaseCommand("update", workingDirectory, ui),
+			SecretsBaseCommand: NewSecretsBaseCommand("secrets update", workingDirectory, ui),
 		}, nil
 	}
 }
@@ -367,13 +314,11 @@ func (suc *SecretsUpdateCommand) Run(args []string) int {
 	suc.FlagSet.StringVar(&suc.flagSecretValue, flagSecretValue, "", "")
 
 	if err := suc.SecretsBaseCommand.run(args); err != nil {
-		suc.UI.Error(err.Error())
-		return 1
+		return suc.fail(err)
 	}
 
 	if err := suc.updateSecret(); err != nil {
-		suc.UI.Error(err.Error())
-		return 1
+		return suc.fail(err)
 	}
 
 	return 0
@@ -398,15 +343,21 @@ func (suc *SecretsUpdateCommand) updateSecret() error {
 		if updateErr := realmClient.UpdateSecretByID(app.GroupID, app.ID, suc.flagSecretID, suc.flagSecretValue); updateErr != nil {
 			return updateErr
 		}
-		suc.UI.Info(fmt.Sprintf("Secret updated: %s", suc.flagSecretID))
-	} else {
-		if updateErr := realmClient.UpdateSecretByName(app.GroupID, app.ID, suc.flagSecretName, suc.flagSecretValue); updateErr != nil {
-			return updateErr
-		}
-		suc.UI.Info(fmt.Sprintf("Secret updated: %s", suc.flagSecretName))
+
+		return suc.report(
+			secretResult{ID: suc.flagSecretID, Status: secretStatusUpdated},
+			fmt.Sprintf("Secret updated: %s", suc.flagSecretID),
+		)
 	}
 
-	return nil
+	if updateErr := realmClient.UpdateSecretByName(app.GroupID, app.ID, suc.flagSecretName, suc.flagSecretValue); updateErr != nil {
+		return updateErr
+	}
+
+	return suc.report(
+		secretResult{Name: suc.flagSecretName, Status: secretStatusUpdated},
+		fmt.Sprintf("Secret updated: %s", suc.flagSecretName),
+	)
 }
 
 // NewSecretsRemoveCommandFactory returns a new cli.CommandFactory given a cli.Ui
@@ -418,7 +369,7 @@ func NewSecretsRemoveCommandFactory(ui cli.Ui) cli.CommandFactory {
 		}
 
 		return &SecretsRemoveCommand{
-			SecretsBaseCommand: NewSecretsBaseCommand("remove", workingDirectory, ui),
+			SecretsBaseCommand: NewSecretsBaseCommand("secrets remove", workingDirectory, ui),
 		}, nil
 	}
 }
@@ -461,13 +412,11 @@ func (src *SecretsRemoveCommand) Run(args []string) int {
 	src.FlagSet.StringVar(&src.flagSecretName, flagSecretNameIdentifierDeprecated, "", "")
 
 	if err := src.SecretsBaseCommand.run(args); err != nil {
-		src.UI.Error(err.Error())
-		return 1
+		return src.fail(err)
 	}
 
 	if err := src.removeSecret(); err != nil {
-		src.UI.Error(err.Error())
-		return 1
+		return src.fail(err)
 	}
 
 	return 0
@@ -492,13 +441,19 @@ func (src *SecretsRemoveCommand) removeSecret() error {
 		if removeErr := realmClient.RemoveSecretByID(app.GroupID, app.ID, src.flagSecretID); removeErr != nil {
 			return removeErr
 		}
-		src.UI.Info(fmt.Sprintf("Secret removed: %s", src.flagSecretID))
-	} else {
-		if removeErr := realmClient.RemoveSecretByName(app.GroupID, app.ID, src.flagSecretName); removeErr != nil {
-			return removeErr
-		}
-		src.UI.Info(fmt.Sprintf("Secret removed: %s", src.flagSecretName))
+
+		return src.report(
+			secretResult{ID: src.flagSecretID, Status: secretStatusRemoved},
+			fmt.Sprintf("Secret removed: %s", src.flagSecretID),
+		)
+	}
+
+	if removeErr := realmClient.RemoveSecretByName(app.GroupID, app.ID, src.flagSecretName); removeErr != nil {
+		return removeErr
 	}
 
-	return nil
+	return src.report(
+		secretResult{Name: src.flagSecretName, Status: secretStatusRemoved},
+		fmt.Sprintf("Secret removed: %s", src.flagSecretName),
+	)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/10gen/realm-cli/secrets"
 	"github.com/10gen/realm-cli/user"
 	u "github.com/10gen/realm-cli/utils/test"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
 
 	"github.com/mitchellh/cli"
 	gc "github.com/smartystreets/goconvey/convey"
@@ -542,3 +543,91 @@ func TestSecretsCommand(t *testing.T) {
 		})
 	})
 }
+
+func TestSecretsListCommandReplayingCassette(t *testing.T) {
+	t.Run("should list the secrets recorded in the cassette", func(t *testing.T) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewSecretsListCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		listCommand := cmd.(*SecretsListCommand)
+		listCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := listCommand.Run([]string{"--app-id=my-app-abcdef", "--replay-cassette=../testdata/cassettes/secrets_list.json"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "5e0a1b2c3d4e5f6a7b8c9d0e my-secret")
+	})
+}
+
+func TestSecretsCommandAgainstFakeAPI(t *testing.T) {
+	t.Run("should add and list secrets", func(t *testing.T) {
+		server := fakeapi.NewServer()
+		defer server.Close()
+
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "my-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewSecretsAddCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		addCommand := cmd.(*SecretsAddCommand)
+		addCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode := addCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "--name=my-secret", "--value=hunter2"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, server.App("my-app-abcdef").Secrets[0].Value, gc.ShouldEqual, "hunter2")
+
+		mockUI = cli.NewMockUi()
+		cmd, err = NewSecretsListCommandFactory(mockUI)()
+		u.So(t, err, gc.ShouldBeNil)
+
+		listCommand := cmd.(*SecretsListCommand)
+		listCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		exitCode = listCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "my-secret")
+	})
+}
+
+func TestSecretsCommandErrorsAgainstFakeAPI(t *testing.T) {
+	setup := func() (*fakeapi.Server, *SecretsAddCommand, *cli.MockUi) {
+		server := fakeapi.NewServer()
+		server.AddApp("5e0000000000000000000001", "my-app-abcdef", "my-app")
+
+		mockUI := cli.NewMockUi()
+		cmd, err := NewSecretsAddCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		addCommand := cmd.(*SecretsAddCommand)
+		addCommand.storage = u.NewPopulatedStorage("my-api-key", "my.refresh.token", u.GenerateValidAccessToken())
+
+		return server, addCommand, mockUI
+	}
+
+	t.Run("should exit with the conflict exit code and a hint when the secret already exists", func(t *testing.T) {
+		server, addCommand, mockUI := setup()
+		defer server.Close()
+
+		server.App("my-app-abcdef").Secrets = []secrets.Secret{{ID: "1", Name: "my-secret"}}
+
+		exitCode := addCommand.Run([]string{"--base-url=" + server.URL, "--app-id=my-app-abcdef", "--name=my-secret", "--value=hunter2"})
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeConflict)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "secret already exists with name 'my-secret'")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "hint: ")
+	})
+
+	t.Run("should exit with the not found exit code when the app does not exist", func(t *testing.T) {
+		server, addCommand, mockUI := setup()
+		defer server.Close()
+
+		exitCode := addCommand.Run([]string{"--base-url=" + server.URL, "--app-id=another-app-abcdef", "--name=my-secret", "--value=hunter2"})
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeNotFound)
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldContainSubstring, "another-app-abcdef")
+	})
+}
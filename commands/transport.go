@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/user"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	flagProxyName              = "proxy"
+	flagCABundleName           = "ca-bundle"
+	flagClientCertName         = "client-cert"
+	flagClientKeyName          = "client-key"
+	flagInsecureSkipVerifyName = "insecure-skip-verify"
+
+	envProxy              = "REALM_CLI_PROXY"
+	envCABundle           = "REALM_CLI_CA_BUNDLE"
+	envClientCert         = "REALM_CLI_CLIENT_CERT"
+	envClientKey          = "REALM_CLI_CLIENT_KEY"
+	envInsecureSkipVerify = "REALM_CLI_INSECURE_SKIP_VERIFY"
+
+	traceClientHosting = "hosting"
+)
+
+// transportConfig resolves the proxy and TLS settings supplied by flag or environment, falling back to
+// the ones stored with the current profile
+func (c *BaseCommand) transportConfig() (api.TransportConfig, error) {
+	profile := &user.User{}
+
+	// supplied API keys do not use a profile, so storage is not consulted
+	if c.storage != nil && (c.credentials == nil || !c.credentials.hasAPIKey()) {
+		u, err := c.storage.ReadUserConfig()
+		if err != nil {
+			return api.TransportConfig{}, err
+		}
+		profile = u
+	}
+
+	config := api.TransportConfig{
+		ProxyURL:       flagOrEnvOrProfile(c.flagProxy, envProxy, profile.ProxyURL),
+		CABundlePath:   flagOrEnvOrProfile(c.flagCABundle, envCABundle, profile.CABundle),
+		ClientCertPath: flagOrEnvOrProfile(c.flagClientCert, envClientCert, profile.ClientCert),
+		ClientKeyPath:  flagOrEnvOrProfile(c.flagClientKey, envClientKey, profile.ClientKey),
+	}
+
+	for _, path := range []*string{&config.CABundlePath, &config.ClientCertPath, &config.ClientKeyPath} {
+		expanded, err := homedir.Expand(*path)
+		if err != nil {
+			return api.TransportConfig{}, err
+		}
+		*path = expanded
+	}
+
+	switch insecure := os.Getenv(envInsecureSkipVerify); {
+	case c.flagIsSet(flagInsecureSkipVerifyName):
+		config.InsecureSkipVerify = c.flagInsecureSkipVerify
+	case insecure != "":
+		skip, err := strconv.ParseBool(insecure)
+		if err != nil {
+			return api.TransportConfig{}, fmt.Errorf("invalid value %q for %s: must be true or false", insecure, envInsecureSkipVerify)
+		}
+		config.InsecureSkipVerify = skip
+	default:
+		config.InsecureSkipVerify = profile.InsecureSkipVerify
+	}
+
+	return config, nil
+}
+
+// newHTTPTransport returns the http.RoundTripper that every request made by the command is sent with
+func (c *BaseCommand) newHTTPTransport() (http.RoundTripper, error) {
+	config, err := c.transportConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.NewTransport(config)
+}
+
+// rememberTransportConfig stores the proxy and TLS settings supplied by flag with the user's profile
+func (c *BaseCommand) rememberTransportConfig(u *user.User) {
+	if c.flagIsSet(flagProxyName) {
+		u.ProxyURL = c.flagProxy
+	}
+
+	if c.flagIsSet(flagCABundleName) {
+		u.CABundle = c.flagCABundle
+	}
+
+	if c.flagIsSet(flagClientCertName) {
+		u.ClientCert = c.flagClientCert
+	}
+
+	if c.flagIsSet(flagClientKeyName) {
+		u.ClientKey = c.flagClientKey
+	}
+
+	if c.flagIsSet(flagInsecureSkipVerifyName) {
+		u.InsecureSkipVerify = c.flagInsecureSkipVerify
+	}
+}
+
+func flagOrEnvOrProfile(flagValue, envName, profileValue string) string {
+	if value := flagOrEnv(flagValue, envName); value != "" {
+		return value
+	}
+
+	return profileValue
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/10gen/realm-cli/utils"
+
+	"github.com/mitchellh/cli"
+)
+
+// NewValidateCommandFactory returns a new cli.CommandFactory given a cli.Ui
+func NewValidateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ValidateCommand{
+			BaseCommand: &BaseCommand{
+				Name: "validate",
+				UI:   ui,
+			},
+			workingDirectory: workingDirectory,
+		}, nil
+	}
+}
+
+// ValidateCommand is used to check a local app directory for problems without contacting Realm
+type ValidateCommand struct {
+	*BaseCommand
+
+	workingDirectory string
+
+	flagAppPath    string
+	flagVars       varsFlag
+	flagVarsFile   string
+	flagStrictVars bool
+}
+
+// validateResult describes the problems found in an app directory in structured output
+type validateResult struct {
+	Path     string                    `json:"path" yaml:"path"`
+	Valid    bool                      `json:"valid" yaml:"valid"`
+	Problems []utils.ValidationProblem `json:"problems" yaml:"problems"`
+}
+
+// Help returns long-form help information for this command
+func (vc *ValidateCommand) Help() string {
+	return `Check a local app directory for problems without contacting Realm.
+
+The app is checked against the schema of config_version ` + fmt.Sprint(utils.ValidatedConfigVersion) + `: the required fields and types of each
+config file, unknown keys, duplicate names and _ids, and functions referenced by triggers and custom resolvers
+that do not exist. Each problem is reported with its file and JSON pointer.
+
+Usage: realm-cli validate [options]
+
+OPTIONS:
+  --path [string]
+	A path to the local directory containing your app.
+` + varsHelp + `
+	` +
+		vc.BaseCommand.Help()
+}
+
+// Synopsis returns a one-liner description for this command
+func (vc *ValidateCommand) Synopsis() string {
+	return `Check a local app directory for problems without contacting Realm.`
+}
+
+// Run executes the command
+func (vc *ValidateCommand) Run(args []string) int {
+	flags := vc.NewFlagSet()
+
+	flags.StringVar(&vc.flagAppPath, importFlagPath, "", "")
+	vc.flagVars = varsFlag{}
+	flags.Var(vc.flagVars, importFlagVar, "")
+	flags.StringVar(&vc.flagVarsFile, importFlagVarsFile, "", "")
+	flags.BoolVar(&vc.flagStrictVars, importFlagStrictVars, false, "")
+
+	if err := vc.BaseCommand.run(args); err != nil {
+		return vc.fail(err)
+	}
+
+	if err := vc.validate(); err != nil {
+		return vc.fail(err)
+	}
+
+	return 0
+}
+
+func (vc *ValidateCommand) validate() error {
+	appPath, err := utils.ResolveAppDirectory(vc.flagAppPath, vc.workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	ic := &ImportCommand{
+		BaseCommand: vc.BaseCommand,
+
+		flagVars:       vc.flagVars,
+		flagVarsFile:   vc.flagVarsFile,
+		flagStrictVars: vc.flagStrictVars,
+	}
+
+	unmarshalOptions, err := ic.unmarshalOptions(appPath)
+	if err != nil {
+		return err
+	}
+
+	problems, err := utils.ValidateAppDir(appPath, unmarshalOptions)
+	if err != nil {
+		return err
+	}
+
+	result := validateResult{Path: appPath, Valid: len(problems) == 0, Problems: problems}
+	if result.Problems == nil {
+		result.Problems = []utils.ValidationProblem{}
+	}
+
+	if vc.structuredOutput() {
+		if err := vc.writeResult(result); err != nil {
+			return err
+		}
+	} else {
+		for _, problem := range problems {
+			vc.UI.Info(problem.String())
+		}
+	}
+
+	if len(problems) != 0 {
+		return ErrAppInvalid{Problems: len(problems)}
+	}
+
+	if !vc.structuredOutput() {
+		vc.UI.Info(fmt.Sprintf("'%s' is valid", appPath))
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/10gen/realm-cli/utils"
+	u "github.com/10gen/realm-cli/utils/test"
+
+	"github.com/mitchellh/cli"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateCommand(t *testing.T) {
+	setup := func() (*ValidateCommand, *cli.MockUi) {
+		mockUI := cli.NewMockUi()
+		cmd, err := NewValidateCommandFactory(mockUI)()
+		if err != nil {
+			panic(err)
+		}
+
+		validateCommand := cmd.(*ValidateCommand)
+		validateCommand.storage = u.NewEmptyStorage()
+
+		return validateCommand, mockUI
+	}
+
+	t.Run("should report that a valid app is valid", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+
+		exitCode := validateCommand.Run([]string{"--path=../testdata/simple_app_with_cluster"})
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldBeEmpty)
+		u.So(t, exitCode, gc.ShouldEqual, 0)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, "'../testdata/simple_app_with_cluster' is valid\n")
+	})
+
+	t.Run("should print every problem and exit with the validation exit code", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+
+		exitCode := validateCommand.Run([]string{"--path=../testdata/invalid_app"})
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeValidation)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldContainSubstring, "triggers/on_insert.json#/function_name: function \"fn_missing\" does not exist\n")
+		u.So(t, mockUI.ErrorWriter.String(), gc.ShouldEqual, "found 11 problem(s) in the app directory\n")
+	})
+
+	t.Run("should write the problems as JSON", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+
+		exitCode := validateCommand.Run([]string{"--path=../testdata/invalid_app", "--output=json"})
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeValidation)
+
+		var result validateResult
+		u.So(t, json.Unmarshal(mockUI.OutputWriter.Bytes(), &result), gc.ShouldBeNil)
+		u.So(t, result.Valid, gc.ShouldBeFalse)
+		u.So(t, result.Problems, gc.ShouldHaveLength, 11)
+		u.So(t, result.Problems[1], gc.ShouldResemble, utils.ValidationProblem{Path: "config.json", Pointer: "/name", Message: "is required"})
+	})
+
+	t.Run("should report placeholders without a value with --strict-vars", func(t *testing.T) {
+		validateCommand, mockUI := setup()
+
+		exitCode := validateCommand.Run([]string{"--path=../testdata/vars_app", "--var=APP_SUFFIX=dev", "--var=APP_HOST=dev.example.com", "--strict-vars"})
+		u.So(t, exitCode, gc.ShouldEqual, exitCodeValidation)
+		u.So(t, mockUI.OutputWriter.String(), gc.ShouldEqual, `services/http_service/rules/upstream.json: no value was set for placeholder ${API_HOST}
+values/api_url.json: no value was set for placeholder ${API_HOST}
+`)
+	})
+}
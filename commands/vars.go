@@ -0,0 +1,185 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/10gen/realm-cli/utils"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	importFlagVar        = "var"
+	importFlagVarsFile   = "vars-file"
+	importFlagStrictVars = "strict-vars"
+
+	// envVarPrefix prefixes the names of the environment variables that resolve placeholders
+	envVarPrefix = "REALM_CLI_VAR_"
+)
+
+// varsHelp documents the flags that resolve the placeholders of the app's JSON files
+const varsHelp = `
+  --var [key=value]
+	Resolve ${key} placeholders in the app's JSON files to value. May be supplied more than once.
+
+  --vars-file [string]
+	A YAML or JSON file mapping placeholder names to values. --var values take precedence over the file's,
+	and the file's over REALM_CLI_VAR_<key> environment variables.
+
+  --strict-vars
+	Fail when a placeholder has no value instead of leaving it as it is.
+`
+
+// varsFlag collects the key=value pairs of every --var flag
+type varsFlag map[string]string
+
+func (vf varsFlag) String() string {
+	pairs := make([]string, 0, len(vf))
+	for key, value := range vf {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Set adds a key=value pair
+func (vf varsFlag) Set(pair string) error {
+	idx := strings.Index(pair, "=")
+	if idx < 1 {
+		return fmt.Errorf("%q must be of the form key=value", pair)
+	}
+
+	vf[pair[:idx]] = pair[idx+1:]
+	return nil
+}
+
+// loadVarsFile reads the variables of a YAML or JSON file mapping each name to a value
+func loadVarsFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse vars file %s: %s", path, err)
+	}
+
+	vars := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch value.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("failed to parse vars file %s: %q must be a string, number or boolean", path, name)
+		case nil:
+			vars[name] = ""
+		default:
+			vars[name] = fmt.Sprint(value)
+		}
+	}
+
+	return vars, nil
+}
+
+// varsFilePath resolves the path of the vars file. A relative path set by the project config file is resolved
+// against the directory of that file
+func (ic *ImportCommand) varsFilePath() (string, error) {
+	path, err := homedir.Expand(ic.flagVarsFile)
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(path) && !ic.explicitFlags[importFlagVarsFile] && ic.projectConfig != nil {
+		path = filepath.Join(ic.projectConfig.Dir(), path)
+	}
+
+	return path, nil
+}
+
+// unmarshalOptions returns how the app directory is read: with the overlay of the selected environment, if any, and
+// placeholders resolved from --var, then --vars-file, then the REALM_CLI_VAR_ environment variables. Placeholders
+// are only resolved when one of those, or --strict-vars, is supplied
+func (ic *ImportCommand) unmarshalOptions(appPath string) (utils.UnmarshalOptions, error) {
+	options := utils.UnmarshalOptions{StrictVars: ic.flagStrictVars}
+
+	if ic.flagEnv != "" {
+		options.OverlayPath = utils.EnvironmentOverlayDirectory(appPath, ic.flagEnv)
+	}
+
+	ic.placeholders = map[string]bool{}
+	if !ic.varsEnabled() {
+		return options, nil
+	}
+
+	fileVars := map[string]string{}
+	if ic.flagVarsFile != "" {
+		path, err := ic.varsFilePath()
+		if err != nil {
+			return options, err
+		}
+
+		if fileVars, err = loadVarsFile(path); err != nil {
+			return options, err
+		}
+	}
+
+	options.LookupVar = func(name string) (string, bool) {
+		value, ok := lookupVar(name, ic.flagVars, fileVars)
+		ic.placeholders[name] = ok
+		return value, ok
+	}
+
+	return options, nil
+}
+
+func lookupVar(name string, flagVars, fileVars map[string]string) (string, bool) {
+	if value, ok := flagVars[name]; ok {
+		return value, true
+	}
+
+	if value, ok := fileVars[name]; ok {
+		return value, true
+	}
+
+	return os.LookupEnv(envVarPrefix + name)
+}
+
+// varsEnabled returns whether placeholders are resolved, which is when values are supplied for them by flag, vars
+// file or REALM_CLI_VAR_ environment variable, or when --strict-vars is supplied
+func (ic *ImportCommand) varsEnabled() bool {
+	if len(ic.flagVars) != 0 || ic.flagVarsFile != "" || ic.flagStrictVars {
+		return true
+	}
+
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, envVarPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// warnUnresolvedPlaceholders warns about the placeholders that were left as they are
+func (ic *ImportCommand) warnUnresolvedPlaceholders() {
+	var unresolved []string
+	for name, resolved := range ic.placeholders {
+		if !resolved {
+			unresolved = append(unresolved, "${"+name+"}")
+		}
+	}
+
+	if len(unresolved) == 0 {
+		return
+	}
+
+	sort.Strings(unresolved)
+	ic.UI.Warn(fmt.Sprintf("no value was set for %s; supply --%s or --%s, or use --%s to fail instead",
+		strings.Join(unresolved, ", "), importFlagVar, importFlagVarsFile, importFlagStrictVars))
+}
@@ -38,21 +38,30 @@ OPTIONS:` + whoami.BaseCommand.Help()
 // Run executes the command
 func (whoami *WhoamiCommand) Run(args []string) int {
 	if err := whoami.BaseCommand.run(args); err != nil {
-		whoami.UI.Error(err.Error())
-		return 1
+		return whoami.fail(err)
 	}
 
 	user, err := whoami.User()
 	if err != nil {
-		whoami.UI.Error(err.Error())
-		return 1
+		return whoami.fail(err)
 	}
 
 	message := "no user info available"
+	var result whoamiResult
 	if publicAPIKey := user.PublicAPIKey; publicAPIKey != "" {
 		message = fmt.Sprintf("%s [API Key: %s]", publicAPIKey, user.RedactedAPIKey())
+		result = whoamiResult{PublicAPIKey: publicAPIKey, APIKey: user.RedactedAPIKey()}
+	}
+
+	if err := whoami.report(result, message); err != nil {
+		return whoami.fail(err)
 	}
 
-	whoami.UI.Info(message)
 	return 0
 }
+
+// whoamiResult describes the current user in structured output
+type whoamiResult struct {
+	PublicAPIKey string `json:"public_api_key,omitempty" yaml:"public_api_key,omitempty"`
+	APIKey       string `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/10gen/realm-cli/models"
 	"github.com/10gen/realm-cli/utils"
 )
 
@@ -209,32 +210,43 @@ func DiffAssetMetadata(local, remote []AssetMetadata, merge bool) *AssetMetadata
 	return NewAssetMetadataDiffs(addedLocally, deletedLocally, modifiedLocally)
 }
 
-// Diff returns a list of strings representing the diff
-func (amd *AssetMetadataDiffs) Diff() []string {
-	var diff []string
+// Changes returns the changes to the hosting files, with the fields of a modified file that changed
+func (amd *AssetMetadataDiffs) Changes() []models.Change {
+	var changes []models.Change
 
-	if len(amd.AddedLocally) > 0 {
-		diff = append(diff, "New Files:")
-	}
 	for _, added := range amd.AddedLocally {
-		diff = append(diff, fmt.Sprintf("\t+ %s", added.FilePath))
+		changes = append(changes, models.HostingFileChange(added.FilePath, models.ChangeOperationAdd))
 	}
 
-	if len(amd.DeletedLocally) > 0 {
-		diff = append(diff, "Removed Files:")
-	}
 	for _, deleted := range amd.DeletedLocally {
-		diff = append(diff, fmt.Sprintf("\t- %s", deleted.FilePath))
+		changes = append(changes, models.HostingFileChange(deleted.FilePath, models.ChangeOperationRemove))
 	}
 
-	if len(amd.ModifiedLocally) > 0 {
-		diff = append(diff, "Modified Files:")
-	}
 	for _, modified := range amd.ModifiedLocally {
-		diff = append(diff, fmt.Sprintf("\t* %s", modified.AssetMetadata.FilePath))
+		var fields []models.FieldChange
+		if modified.BodyModified {
+			fields = append(fields, models.FieldChange{
+				Field:  "hash",
+				Before: modified.RemoteAssetMetadata.FileHash,
+				After:  modified.AssetMetadata.FileHash,
+			})
+		}
+		if modified.AttrModified {
+			fields = append(fields, models.FieldChange{
+				Field:  "attrs",
+				Before: modified.RemoteAssetMetadata.Attrs,
+				After:  modified.AssetMetadata.Attrs,
+			})
+		}
+		changes = append(changes, models.HostingFileChange(modified.AssetMetadata.FilePath, models.ChangeOperationModify, fields...))
 	}
 
-	return diff
+	return changes
+}
+
+// Diff returns a list of strings representing the diff
+func (amd *AssetMetadataDiffs) Diff() []string {
+	return models.RenderChanges(amd.Changes())
 }
 
 // ReplacePathSeparator returns path with os dependent path separators replaced by uniform '/'
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/10gen/realm-cli/hosting"
+	"github.com/10gen/realm-cli/models"
 	"github.com/10gen/realm-cli/utils"
 	u "github.com/10gen/realm-cli/utils/test"
 
@@ -212,6 +213,7 @@ func TestGetModifiedAssetMetadata(t *testing.T) {
 			tc.local,
 			tc.bodyModified,
 			tc.attrModified,
+			tc.remote,
 		})
 	}
 }
@@ -475,9 +477,10 @@ func TestDiffAssetMetadata(t *testing.T) {
 			deleted: nil,
 			modified: []hosting.ModifiedAssetMetadata{
 				{
-					AssetMetadata: jsonAM,
-					BodyModified:  true,
-					AttrModified:  false,
+					AssetMetadata:       jsonAM,
+					BodyModified:        true,
+					AttrModified:        false,
+					RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/french/fry", FileHash: "mincedpotato", Attrs: []hosting.AssetAttribute{jsonAttr}},
 				},
 			},
 		},
@@ -502,14 +505,16 @@ func TestDiffAssetMetadata(t *testing.T) {
 			deleted: nil,
 			modified: []hosting.ModifiedAssetMetadata{
 				{
-					AssetMetadata: jsonAM,
-					BodyModified:  true,
-					AttrModified:  false,
+					AssetMetadata:       jsonAM,
+					BodyModified:        true,
+					AttrModified:        false,
+					RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/french/fry", FileHash: "mincedpotato", Attrs: []hosting.AssetAttribute{jsonAttr}},
 				},
 				{
-					AssetMetadata: xmlAM,
-					BodyModified:  true,
-					AttrModified:  false,
+					AssetMetadata:       xmlAM,
+					BodyModified:        true,
+					AttrModified:        false,
+					RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/philip/j/fry", FileHash: "killerpotato", Attrs: []hosting.AssetAttribute{xmlAttr}},
 				},
 			},
 		},
@@ -528,9 +533,10 @@ func TestDiffAssetMetadata(t *testing.T) {
 			deleted: nil,
 			modified: []hosting.ModifiedAssetMetadata{
 				{
-					AssetMetadata: jsonAM,
-					BodyModified:  false,
-					AttrModified:  true,
+					AssetMetadata:       jsonAM,
+					BodyModified:        false,
+					AttrModified:        true,
+					RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/french/fry", FileHash: "choppedpotato", Attrs: []hosting.AssetAttribute{xmlAttr}},
 				},
 			},
 		},
@@ -549,9 +555,10 @@ func TestDiffAssetMetadata(t *testing.T) {
 			deleted: nil,
 			modified: []hosting.ModifiedAssetMetadata{
 				{
-					AssetMetadata: jsonAM,
-					BodyModified:  true,
-					AttrModified:  true,
+					AssetMetadata:       jsonAM,
+					BodyModified:        true,
+					AttrModified:        true,
+					RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/french/fry", FileHash: "potatopotato", Attrs: []hosting.AssetAttribute{xmlAttr}},
 				},
 			},
 		},
@@ -686,3 +693,54 @@ func TestAssetMetadataDiff(t *testing.T) {
 		u.So(t, amd.Diff(), gc.ShouldResemble, append(append(addDiff, deleteDiff...), modifyDiff...))
 	})
 }
+
+func TestAssetMetadataChanges(t *testing.T) {
+	attrs := []hosting.AssetAttribute{{Name: hosting.AttributeContentType, Value: "text/html"}}
+	oldAttrs := []hosting.AssetAttribute{{Name: hosting.AttributeContentType, Value: "text/plain"}}
+
+	amd := hosting.NewAssetMetadataDiffs(
+		[]hosting.AssetMetadata{{FilePath: "/addMe"}},
+		[]hosting.AssetMetadata{{FilePath: "/deleteMe"}},
+		[]hosting.ModifiedAssetMetadata{
+			{
+				AssetMetadata:       hosting.AssetMetadata{FilePath: "/modifyMyBody", FileHash: "hash"},
+				BodyModified:        true,
+				RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/modifyMyBody", FileHash: "oldHash"},
+			},
+			{
+				AssetMetadata:       hosting.AssetMetadata{FilePath: "/modifyMyAttrs", Attrs: attrs},
+				AttrModified:        true,
+				RemoteAssetMetadata: hosting.AssetMetadata{FilePath: "/modifyMyAttrs", Attrs: oldAttrs},
+			},
+		},
+	)
+
+	u.So(t, amd.Changes(), gc.ShouldResemble, []models.Change{
+		{
+			Kind:      models.ChangeKindHostingFile,
+			Name:      "/addMe",
+			Operation: models.ChangeOperationAdd,
+			Lines:     []string{"\t+ /addMe"},
+		},
+		{
+			Kind:      models.ChangeKindHostingFile,
+			Name:      "/deleteMe",
+			Operation: models.ChangeOperationRemove,
+			Lines:     []string{"\t- /deleteMe"},
+		},
+		{
+			Kind:      models.ChangeKindHostingFile,
+			Name:      "/modifyMyBody",
+			Operation: models.ChangeOperationModify,
+			Fields:    []models.FieldChange{{Field: "hash", Before: "oldHash", After: "hash"}},
+			Lines:     []string{"\t* /modifyMyBody"},
+		},
+		{
+			Kind:      models.ChangeKindHostingFile,
+			Name:      "/modifyMyAttrs",
+			Operation: models.ChangeOperationModify,
+			Fields:    []models.FieldChange{{Field: "attrs", Before: oldAttrs, After: attrs}},
+			Lines:     []string{"\t* /modifyMyAttrs"},
+		},
+	})
+}
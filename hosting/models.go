@@ -155,6 +155,8 @@ type ModifiedAssetMetadata struct {
 	AssetMetadata AssetMetadata
 	BodyModified  bool
 	AttrModified  bool
+	// RemoteAssetMetadata is the metadata of the asset as it is deployed
+	RemoteAssetMetadata AssetMetadata
 }
 
 // GetModifiedAssetMetadata returns a ModifiedAssetMetadata created from the
@@ -167,6 +169,7 @@ func GetModifiedAssetMetadata(local, remote AssetMetadata) ModifiedAssetMetadata
 		local,
 		bodyModified,
 		attrModified,
+		remote,
 	}
 }
 
@@ -0,0 +1,337 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/10gen/realm-cli/dependency/transpiler"
+
+	"github.com/robertkrimen/otto/ast"
+	"github.com/robertkrimen/otto/file"
+	"github.com/robertkrimen/otto/parser"
+)
+
+// Severity is how serious a problem is
+type Severity string
+
+// Set of problem severities
+const (
+	// SeverityError is a problem that keeps the function from being deployed or run
+	SeverityError Severity = "error"
+	// SeverityWarning is a problem that may be intended, e.g. a call to a function that only exists in Realm
+	SeverityWarning Severity = "warning"
+)
+
+// Source is the JavaScript source of a function or incoming webhook
+type Source struct {
+	Path string
+	Code string
+}
+
+// Problem is a problem found in a source
+type Problem struct {
+	Path     string   `json:"path" yaml:"path"`
+	Line     int      `json:"line,omitempty" yaml:"line,omitempty"`
+	Column   int      `json:"column,omitempty" yaml:"column,omitempty"`
+	Severity Severity `json:"severity" yaml:"severity"`
+	Message  string   `json:"message" yaml:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", p.Path, p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.Path, p.Line, p.Column, p.Severity, p.Message)
+}
+
+// Linter checks function sources for syntax errors, a missing export, and calls to functions that do not exist
+type Linter struct {
+	// Transpiler compiles the sources to ES5 before they are parsed. Without one, the sources are parsed as they
+	// are, and the syntax errors found, which may be ES6 syntax the parser does not support, are only warnings
+	Transpiler transpiler.Transpiler
+}
+
+// Lint returns the problems of the sources, given the names of the functions of the app
+func (l Linter) Lint(ctx context.Context, sources []Source, functions map[string]bool) ([]Problem, error) {
+	var problems []Problem
+
+	parsed, syntaxProblems, err := l.parse(ctx, sources)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, syntaxProblems...)
+
+	for i, program := range parsed {
+		if program == nil {
+			continue
+		}
+
+		problems = append(problems, checkExport(sources[i].Path, program)...)
+		problems = append(problems, checkExecutedFunctions(sources[i].Path, program, functions)...)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Path != problems[j].Path {
+			return problems[i].Path < problems[j].Path
+		}
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+
+	return problems, nil
+}
+
+// parse parses each source, returning nil for the sources that have syntax errors
+func (l Linter) parse(ctx context.Context, sources []Source) ([]*ast.Program, []Problem, error) {
+	parsed := make([]*ast.Program, len(sources))
+	var problems []Problem
+
+	if l.Transpiler == nil {
+		for i, source := range sources {
+			program, err := parser.ParseFile(nil, source.Path, source.Code, 0)
+			if err != nil {
+				problems = append(problems, syntaxProblem(source.Path, err, SeverityWarning, " (parsed as ES5, since no transpiler was found)"))
+				continue
+			}
+
+			parsed[i] = program
+		}
+
+		return parsed, problems, nil
+	}
+
+	// the transpiler fails the whole batch when any source has a syntax error, so the sources without one are
+	// transpiled again once those errors are known
+	indexes := make([]int, len(sources))
+	for i := range sources {
+		indexes[i] = i
+	}
+
+	results, err := l.transpile(ctx, sources, indexes)
+	var transpileErrs transpiler.TranspileErrors
+	if errors.As(err, &transpileErrs) {
+		failed := map[int]bool{}
+		for _, transpileErr := range transpileErrs {
+			if transpileErr.Index < 0 || transpileErr.Index >= len(sources) {
+				continue
+			}
+
+			failed[transpileErr.Index] = true
+			problems = append(problems, Problem{
+				Path:     sources[transpileErr.Index].Path,
+				Line:     transpileErr.Line,
+				Column:   transpileErr.Column,
+				Severity: SeverityError,
+				Message:  transpileErr.Message,
+			})
+		}
+
+		indexes = indexes[:0]
+		for i := range sources {
+			if !failed[i] {
+				indexes = append(indexes, i)
+			}
+		}
+
+		results, err = l.transpile(ctx, sources, indexes)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to transpile function sources: %w", err)
+	}
+
+	for n, i := range indexes {
+		var sourceMap interface{}
+		if len(results[n].SourceMap) != 0 && string(results[n].SourceMap) != "null" {
+			sourceMap = []byte(results[n].SourceMap)
+		}
+
+		program, err := parser.ParseFileWithSourceMap(nil, sources[i].Path, results[n].Code, sourceMap, 0)
+		if err != nil {
+			problems = append(problems, syntaxProblem(sources[i].Path, err, SeverityError, ""))
+			continue
+		}
+
+		parsed[i] = program
+	}
+
+	return parsed, problems, nil
+}
+
+func (l Linter) transpile(ctx context.Context, sources []Source, indexes []int) ([]transpiler.TranspileResult, error) {
+	if len(indexes) == 0 {
+		return nil, nil
+	}
+
+	codes := make([]string, len(indexes))
+	for n, i := range indexes {
+		codes[n] = sources[i].Code
+	}
+
+	results, err := l.Transpiler.Transpile(ctx, codes...)
+
+	// the errors index into the transpiled batch, which is not every source when retrying
+	var transpileErrs transpiler.TranspileErrors
+	if errors.As(err, &transpileErrs) {
+		remapped := make(transpiler.TranspileErrors, 0, len(transpileErrs))
+		for _, transpileErr := range transpileErrs {
+			if transpileErr.Index >= 0 && transpileErr.Index < len(indexes) {
+				remappedErr := *transpileErr
+				remappedErr.Index = indexes[transpileErr.Index]
+				remapped = append(remapped, &remappedErr)
+			}
+		}
+		return nil, remapped
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != len(codes) {
+		return nil, fmt.Errorf("expected %d results from the transpiler but got %d", len(codes), len(results))
+	}
+
+	return results, nil
+}
+
+// syntaxProblem converts the first error of the parser to a problem, since the errors that follow it are mostly
+// caused by the parser recovering from it
+func syntaxProblem(path string, err error, severity Severity, suffix string) Problem {
+	var parserErr *parser.Error
+	var errs parser.ErrorList
+	switch {
+	case errors.As(err, &errs) && len(errs) != 0:
+		parserErr = errs[0]
+	case !errors.As(err, &parserErr):
+		return Problem{Path: path, Severity: severity, Message: err.Error() + suffix}
+	}
+
+	return Problem{
+		Path:     path,
+		Line:     parserErr.Position.Line,
+		Column:   parserErr.Position.Column,
+		Severity: severity,
+		Message:  parserErr.Message + suffix,
+	}
+}
+
+// checkExport checks the source assigns a function to exports, either with exports = or var exports =
+func checkExport(path string, program *ast.Program) []Problem {
+	for _, statement := range program.Body {
+		var exported ast.Expression
+
+		switch s := statement.(type) {
+		case *ast.ExpressionStatement:
+			if assign, ok := s.Expression.(*ast.AssignExpression); ok && isExports(assign.Left) {
+				exported = assign.Right
+			}
+		case *ast.VariableStatement:
+			for _, declaration := range s.List {
+				if variable, ok := declaration.(*ast.VariableExpression); ok && variable.Name == "exports" && variable.Initializer != nil {
+					exported = variable.Initializer
+				}
+			}
+		}
+
+		if exported == nil {
+			continue
+		}
+
+		switch exported.(type) {
+		case *ast.FunctionLiteral, *ast.Identifier, *ast.CallExpression, *ast.DotExpression, *ast.ConditionalExpression:
+			return nil
+		}
+
+		line, column := position(program, exported)
+		return []Problem{{Path: path, Line: line, Column: column, Severity: SeverityError, Message: "exports must be a function"}}
+	}
+
+	return []Problem{{Path: path, Severity: SeverityError, Message: "does not export a function; assign one with exports = function(...) {...}"}}
+}
+
+func isExports(expression ast.Expression) bool {
+	switch e := expression.(type) {
+	case *ast.Identifier:
+		return e.Name == "exports"
+	case *ast.DotExpression:
+		module, ok := e.Left.(*ast.Identifier)
+		return ok && module.Name == "module" && e.Identifier.Name == "exports"
+	}
+	return false
+}
+
+// executedFunctionsVisitor collects the calls to context.functions.execute with a literal function name
+type executedFunctionsVisitor struct {
+	calls []*ast.StringLiteral
+}
+
+func (v *executedFunctionsVisitor) Enter(n ast.Node) ast.Visitor {
+	call, ok := n.(*ast.CallExpression)
+	if !ok || len(call.ArgumentList) == 0 || !isContextFunctionsExecute(call.Callee) {
+		return v
+	}
+
+	if name, ok := call.ArgumentList[0].(*ast.StringLiteral); ok {
+		v.calls = append(v.calls, name)
+	}
+
+	return v
+}
+
+func (v *executedFunctionsVisitor) Exit(n ast.Node) {}
+
+func isContextFunctionsExecute(callee ast.Expression) bool {
+	execute, ok := callee.(*ast.DotExpression)
+	if !ok || execute.Identifier.Name != "execute" {
+		return false
+	}
+
+	functions, ok := execute.Left.(*ast.DotExpression)
+	if !ok || functions.Identifier.Name != "functions" {
+		return false
+	}
+
+	context, ok := functions.Left.(*ast.Identifier)
+	return ok && context.Name == "context"
+}
+
+// checkExecutedFunctions checks each function called with context.functions.execute exists
+func checkExecutedFunctions(path string, program *ast.Program, functions map[string]bool) []Problem {
+	visitor := &executedFunctionsVisitor{}
+	ast.Walk(visitor, program)
+
+	var problems []Problem
+	for _, name := range visitor.calls {
+		if functions[name.Value] {
+			continue
+		}
+
+		line, column := position(program, name)
+		problems = append(problems, Problem{
+			Path:     path,
+			Line:     line,
+			Column:   column,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("context.functions.execute calls %q, which is not a function of the app directory", name.Value),
+		})
+	}
+
+	return problems
+}
+
+// position returns the line and column of the node in the original source
+func position(program *ast.Program, node ast.Node) (int, int) {
+	if program.File == nil {
+		return 0, 0
+	}
+
+	var p *file.Position
+	if p = program.File.Position(node.Idx0()); p == nil {
+		return 0, 0
+	}
+
+	return p.Line, p.Column
+}
@@ -0,0 +1,90 @@
+package lint_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/10gen/realm-cli/dependency/transpiler"
+	"github.com/10gen/realm-cli/lint"
+	u "github.com/10gen/realm-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeTranspiler returns the codes as they are, failing the batch for the codes that contain "=>"
+type fakeTranspiler struct {
+	batches [][]string
+}
+
+func (ft *fakeTranspiler) Transpile(ctx context.Context, codes ...string) ([]transpiler.TranspileResult, error) {
+	ft.batches = append(ft.batches, codes)
+
+	var errs transpiler.TranspileErrors
+	results := make([]transpiler.TranspileResult, 0, len(codes))
+	for i, code := range codes {
+		if idx := strings.Index(code, "=>"); idx >= 0 {
+			errs = append(errs, &transpiler.TranspileError{Index: i, Message: "Unexpected token", Line: 2, Column: 5})
+		}
+		results = append(results, transpiler.TranspileResult{Code: code})
+	}
+
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	return results, nil
+}
+
+func TestLint(t *testing.T) {
+	functions := map[string]bool{"sum": true}
+
+	t.Run("should find no problems in a function that exports itself", func(t *testing.T) {
+		problems, err := lint.Linter{}.Lint(context.Background(), []lint.Source{
+			{Path: "functions/a/source.js", Code: "exports = function(a, b) {\n  return context.functions.execute(\"sum\", a, b);\n};\n"},
+			{Path: "functions/b/source.js", Code: "function main() { return 1; }\nmodule.exports = main;\n"},
+			{Path: "services/http/incoming_webhooks/hook/source.js", Code: "var exports = function(payload) {\n  return payload.body;\n};\n"},
+		}, functions)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, problems, gc.ShouldBeEmpty)
+	})
+
+	t.Run("should report a missing export and calls to functions that do not exist", func(t *testing.T) {
+		problems, err := lint.Linter{}.Lint(context.Background(), []lint.Source{
+			{Path: "functions/a/source.js", Code: "exports = \"a\";\n"},
+			{Path: "functions/b/source.js", Code: "var f = function() {\n  return context.functions.execute('missing');\n};\n"},
+		}, functions)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, problems, gc.ShouldResemble, []lint.Problem{
+			{Path: "functions/a/source.js", Line: 1, Column: 11, Severity: lint.SeverityError, Message: "exports must be a function"},
+			{Path: "functions/b/source.js", Severity: lint.SeverityError, Message: "does not export a function; assign one with exports = function(...) {...}"},
+			{Path: "functions/b/source.js", Line: 2, Column: 36, Severity: lint.SeverityWarning, Message: `context.functions.execute calls "missing", which is not a function of the app directory`},
+		})
+		u.So(t, problems[2].String(), gc.ShouldStartWith, "functions/b/source.js:2:36: warning: ")
+	})
+
+	t.Run("should only warn about syntax errors without a transpiler", func(t *testing.T) {
+		problems, err := lint.Linter{}.Lint(context.Background(), []lint.Source{
+			{Path: "functions/a/source.js", Code: "exports = function() {\n  return 1 +;\n};\n"},
+		}, functions)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, problems, gc.ShouldHaveLength, 1)
+		u.So(t, problems[0].Line, gc.ShouldEqual, 2)
+		u.So(t, problems[0].Severity, gc.ShouldEqual, lint.SeverityWarning)
+		u.So(t, problems[0].Message, gc.ShouldEndWith, "(parsed as ES5, since no transpiler was found)")
+	})
+
+	t.Run("should report the transpiler's syntax errors and lint the other sources", func(t *testing.T) {
+		tr := &fakeTranspiler{}
+		problems, err := lint.Linter{Transpiler: tr}.Lint(context.Background(), []lint.Source{
+			{Path: "functions/a/source.js", Code: "exports = function() {\n  return x => x;\n};\n"},
+			{Path: "functions/b/source.js", Code: "exports = 1;\n"},
+		}, functions)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, problems, gc.ShouldResemble, []lint.Problem{
+			{Path: "functions/a/source.js", Line: 2, Column: 5, Severity: lint.SeverityError, Message: "Unexpected token"},
+			{Path: "functions/b/source.js", Line: 1, Column: 11, Severity: lint.SeverityError, Message: "exports must be a function"},
+		})
+		u.So(t, tr.batches, gc.ShouldHaveLength, 2)
+		u.So(t, tr.batches[1], gc.ShouldResemble, []string{"exports = 1;\n"})
+	})
+}
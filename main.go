@@ -22,17 +22,34 @@ func main() {
 	}
 
 	c.Commands = map[string]cli.CommandFactory{
-		"whoami":         commands.NewWhoamiCommandFactory(ui),
-		"login":          commands.NewLoginCommandFactory(ui),
-		"logout":         commands.NewLogoutCommandFactory(ui),
-		"export":         commands.NewExportCommandFactory(ui),
-		"import":         commands.NewImportCommandFactory(ui),
-		"diff":           commands.NewDiffCommandFactory(ui),
-		"secrets":        commands.NewSecretsCommandFactory(ui),
-		"secrets list":   commands.NewSecretsListCommandFactory(ui),
-		"secrets add":    commands.NewSecretsAddCommandFactory(ui),
-		"secrets update": commands.NewSecretsUpdateCommandFactory(ui),
-		"secrets remove": commands.NewSecretsRemoveCommandFactory(ui),
+		"whoami":               commands.NewWhoamiCommandFactory(ui),
+		"login":                commands.NewLoginCommandFactory(ui),
+		"logout":               commands.NewLogoutCommandFactory(ui),
+		"export":               commands.NewExportCommandFactory(ui),
+		"import":               commands.NewImportCommandFactory(ui),
+		"diff":                 commands.NewDiffCommandFactory(ui),
+		"validate":             commands.NewValidateCommandFactory(ui),
+		"deployments":          commands.NewDeploymentsCommandFactory(ui),
+		"deployments list":     commands.NewDeploymentsListCommandFactory(ui),
+		"deployments get":      commands.NewDeploymentsGetCommandFactory(ui),
+		"deployments wait":     commands.NewDeploymentsWaitCommandFactory(ui),
+		"deployments rollback": commands.NewDeploymentsRollbackCommandFactory(ui),
+		"drafts":               commands.NewDraftsCommandFactory(ui),
+		"drafts list":          commands.NewDraftsListCommandFactory(ui),
+		"drafts diff":          commands.NewDraftsDiffCommandFactory(ui),
+		"drafts discard":       commands.NewDraftsDiscardCommandFactory(ui),
+		"drafts deploy":        commands.NewDraftsDeployCommandFactory(ui),
+		"secrets":              commands.NewSecretsCommandFactory(ui),
+		"secrets list":         commands.NewSecretsListCommandFactory(ui),
+		"secrets add":          commands.NewSecretsAddCommandFactory(ui),
+		"secrets update":       commands.NewSecretsUpdateCommandFactory(ui),
+		"secrets remove":       commands.NewSecretsRemoveCommandFactory(ui),
+		"profiles":             commands.NewProfilesCommandFactory(ui),
+		"profiles list":        commands.NewProfilesListCommandFactory(ui),
+		"profiles use":         commands.NewProfilesUseCommandFactory(ui),
+		"profiles delete":      commands.NewProfilesDeleteCommandFactory(ui),
+		"config":               commands.NewConfigCommandFactory(ui),
+		"config show":          commands.NewConfigShowCommandFactory(ui),
 	}
 
 	exitStatus, err := c.Run()
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ChangeOperation is what a Change does to a resource of an app
+type ChangeOperation string
+
+// Set of change operations
+const (
+	ChangeOperationAdd    ChangeOperation = "add"
+	ChangeOperationModify ChangeOperation = "modify"
+	ChangeOperationRemove ChangeOperation = "remove"
+)
+
+// Kinds of the changes that are not parsed from the diff of an app's configuration
+const (
+	ChangeKindHostingFile  = "hosting_file"
+	ChangeKindDependencies = "dependencies"
+)
+
+// FieldChange is a change to a single field of a resource. Before is unset for a field that is added, and After
+// for one that is removed
+type FieldChange struct {
+	Field  string      `json:"field" yaml:"field"`
+	Before interface{} `json:"before,omitempty" yaml:"before,omitempty"`
+	After  interface{} `json:"after,omitempty" yaml:"after,omitempty"`
+}
+
+// Change is a change to a resource of an app, e.g. a function, a value or a hosting file
+type Change struct {
+	// Kind is the kind of the resource, e.g. "functions", or empty when the change could not be parsed
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Name is the path of the resource within its kind, e.g. "myFunc", or empty when the change is to the whole kind
+	Name      string          `json:"name,omitempty" yaml:"name,omitempty"`
+	Operation ChangeOperation `json:"operation,omitempty" yaml:"operation,omitempty"`
+	Fields    []FieldChange   `json:"fields,omitempty" yaml:"fields,omitempty"`
+
+	// Lines are the lines of the diff that describe the change, which is how it is rendered
+	Lines []string `json:"lines" yaml:"lines"`
+}
+
+var (
+	// changeMarkerPattern matches a line naming a resource that is added (+++), removed (---) or modified (~~~)
+	changeMarkerPattern = regexp.MustCompile(`^(\+\+\+|---|~~~) (\S+)$`)
+
+	// changeSectionPattern matches the line that starts the field-level changes of a modified resource
+	changeSectionPattern = regexp.MustCompile(`^--- (\S+) ---$`)
+
+	// fieldLinePattern matches a line of a section that removes (-) or adds (+) a field's value
+	fieldLinePattern = regexp.MustCompile(`^([-+])\s*"((?:[^"\\]|\\.)*)"\s*:\s*(.*?),?\s*$`)
+)
+
+var changeMarkerOperations = map[string]ChangeOperation{
+	"+++": ChangeOperationAdd,
+	"---": ChangeOperationRemove,
+	"~~~": ChangeOperationModify,
+}
+
+// ParseDiff parses the lines of the diff the server returns for an app's configuration into changes. A line that is
+// not understood becomes a change of its own with only its line set, so that no line of the diff is lost
+func ParseDiff(lines []string) []Change {
+	var changes []Change
+	var section *Change
+
+	endSection := func() {
+		if section != nil {
+			changes = append(changes, *section)
+			section = nil
+		}
+	}
+
+	for _, line := range lines {
+		if matches := changeSectionPattern.FindStringSubmatch(line); matches != nil {
+			endSection()
+
+			kind, name := splitResourcePath(matches[1])
+			section = &Change{Kind: kind, Name: name, Operation: ChangeOperationModify, Lines: []string{line}}
+			continue
+		}
+
+		if matches := changeMarkerPattern.FindStringSubmatch(line); matches != nil {
+			endSection()
+
+			kind, name := splitResourcePath(matches[2])
+			changes = append(changes, Change{Kind: kind, Name: name, Operation: changeMarkerOperations[matches[1]], Lines: []string{line}})
+			continue
+		}
+
+		if section != nil {
+			section.Lines = append(section.Lines, line)
+			if matches := fieldLinePattern.FindStringSubmatch(line); matches != nil {
+				section.setField(matches[2], matches[1] == "+", parseFieldValue(matches[3]))
+			}
+			continue
+		}
+
+		changes = append(changes, Change{Lines: []string{line}})
+	}
+	endSection()
+
+	return changes
+}
+
+// setField records the value a field had before or has after the change, pairing the two for the same field
+func (c *Change) setField(field string, after bool, value interface{}) {
+	for i := range c.Fields {
+		if c.Fields[i].Field != field {
+			continue
+		}
+
+		if after && c.Fields[i].After == nil {
+			c.Fields[i].After = value
+			return
+		}
+		if !after && c.Fields[i].Before == nil {
+			c.Fields[i].Before = value
+			return
+		}
+	}
+
+	if after {
+		c.Fields = append(c.Fields, FieldChange{Field: field, After: value})
+	} else {
+		c.Fields = append(c.Fields, FieldChange{Field: field, Before: value})
+	}
+}
+
+// parseFieldValue parses a JSON value, keeping the text as it is when it is not one, e.g. the opening brace of an
+// object that spans several lines
+func parseFieldValue(text string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text
+	}
+	return value
+}
+
+func splitResourcePath(path string) (string, string) {
+	if i := strings.Index(path, "/"); i != -1 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// hostingFileHeaders introduce the hosting files changed by each operation when they are rendered
+var hostingFileHeaders = map[ChangeOperation]string{
+	ChangeOperationAdd:    "New Files:",
+	ChangeOperationRemove: "Removed Files:",
+	ChangeOperationModify: "Modified Files:",
+}
+
+// hostingFileMarkers prefix each hosting file changed by an operation when they are rendered
+var hostingFileMarkers = map[ChangeOperation]string{
+	ChangeOperationAdd:    "+",
+	ChangeOperationRemove: "-",
+	ChangeOperationModify: "*",
+}
+
+// HostingFileChange returns the change of a hosting file at the path
+func HostingFileChange(path string, operation ChangeOperation, fields ...FieldChange) Change {
+	return Change{
+		Kind:      ChangeKindHostingFile,
+		Name:      path,
+		Operation: operation,
+		Fields:    fields,
+		Lines:     []string{fmt.Sprintf("\t%s %s", hostingFileMarkers[operation], path)},
+	}
+}
+
+// Changes returns the changes of the hosting files of a draft
+func (d HostingDiff) Changes() []Change {
+	var changes []Change
+	for _, path := range d.Added {
+		changes = append(changes, HostingFileChange(path, ChangeOperationAdd))
+	}
+	for _, path := range d.Deleted {
+		changes = append(changes, HostingFileChange(path, ChangeOperationRemove))
+	}
+	for _, path := range d.Modified {
+		changes = append(changes, HostingFileChange(path, ChangeOperationModify))
+	}
+	return changes
+}
+
+// RenderChanges returns the lines that describe the changes, introducing each run of hosting files changed by the
+// same operation with a header
+func RenderChanges(changes []Change) []string {
+	var lines []string
+	var hostingOperation ChangeOperation
+
+	for _, change := range changes {
+		if change.Kind == ChangeKindHostingFile && change.Operation != hostingOperation {
+			hostingOperation = change.Operation
+			lines = append(lines, hostingFileHeaders[change.Operation])
+		}
+		lines = append(lines, change.Lines...)
+	}
+
+	return lines
+}
@@ -0,0 +1,86 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/10gen/realm-cli/models"
+	u "github.com/10gen/realm-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseDiff(t *testing.T) {
+	t.Run("should parse the resources that are added, removed or modified", func(t *testing.T) {
+		changes := models.ParseDiff([]string{"+++ values/myValue", "--- functions/myFunc", "~~~ security"})
+
+		u.So(t, changes, gc.ShouldResemble, []models.Change{
+			{Kind: "values", Name: "myValue", Operation: models.ChangeOperationAdd, Lines: []string{"+++ values/myValue"}},
+			{Kind: "functions", Name: "myFunc", Operation: models.ChangeOperationRemove, Lines: []string{"--- functions/myFunc"}},
+			{Kind: "security", Operation: models.ChangeOperationModify, Lines: []string{"~~~ security"}},
+		})
+	})
+
+	t.Run("should parse the fields changed by a section", func(t *testing.T) {
+		lines := []string{
+			"--- services/mongodb-atlas/rules/db.coll ---",
+			`-   "read": false,`,
+			`+   "read": true,`,
+			`+   "filters": [],`,
+			`-   "roles": {`,
+			"    }",
+			"--- functions/myFunc ---",
+			`-   "private": false`,
+		}
+
+		u.So(t, models.ParseDiff(lines), gc.ShouldResemble, []models.Change{
+			{
+				Kind:      "services",
+				Name:      "mongodb-atlas/rules/db.coll",
+				Operation: models.ChangeOperationModify,
+				Fields: []models.FieldChange{
+					{Field: "read", Before: false, After: true},
+					{Field: "filters", After: []interface{}{}},
+					{Field: "roles", Before: "{"},
+				},
+				Lines: lines[:6],
+			},
+			{
+				Kind:      "functions",
+				Name:      "myFunc",
+				Operation: models.ChangeOperationModify,
+				Fields:    []models.FieldChange{{Field: "private", Before: false}},
+				Lines:     lines[6:],
+			},
+		})
+	})
+
+	t.Run("should keep a line it does not understand as a change of its own", func(t *testing.T) {
+		u.So(t, models.ParseDiff([]string{"Something changed"}), gc.ShouldResemble, []models.Change{
+			{Lines: []string{"Something changed"}},
+		})
+	})
+
+	t.Run("should return no changes for an empty diff", func(t *testing.T) {
+		u.So(t, models.ParseDiff(nil), gc.ShouldBeEmpty)
+	})
+}
+
+func TestRenderChanges(t *testing.T) {
+	changes := append(
+		models.ParseDiff([]string{"~~~ security"}),
+		models.HostingFileChange("/a", models.ChangeOperationAdd),
+		models.HostingFileChange("/b", models.ChangeOperationAdd),
+		models.HostingFileChange("/c", models.ChangeOperationModify),
+		models.Change{Kind: models.ChangeKindDependencies, Lines: []string{"Import dependencies"}},
+	)
+
+	u.So(t, models.RenderChanges(changes), gc.ShouldResemble, []string{
+		"~~~ security",
+		"New Files:",
+		"\t+ /a",
+		"\t+ /b",
+		"Modified Files:",
+		"\t* /c",
+		"Import dependencies",
+	})
+}
@@ -158,8 +158,20 @@ type AppDraft struct {
 
 // Deployment represents a Realm Deployment
 type Deployment struct {
-	ID     string           `json:"_id"`
-	Status DeploymentStatus `json:"status"`
+	ID         string           `json:"_id"`
+	AppID      string           `json:"app_id,omitempty"`
+	DraftID    string           `json:"draft_id,omitempty"`
+	UserID     string           `json:"user_id,omitempty"`
+	DeployedAt int64            `json:"deployed_at,omitempty"`
+	Origin     string           `json:"origin,omitempty"`
+	Commit     string           `json:"commit,omitempty"`
+	Status     DeploymentStatus `json:"status"`
+
+	// StatusErrorMessage is why the deployment failed, when its status is failed
+	StatusErrorMessage string `json:"status_error_message,omitempty"`
+
+	// DiffURL links to the changes the deployment made, when it was deployed from a GitHub commit
+	DiffURL string `json:"diff_url,omitempty"`
 }
 
 // DraftDiff represents the diff of an AppDraft
@@ -0,0 +1,228 @@
+// Package project reads the project config file that sets default flag values for the commands run
+// against a local app directory.
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/10gen/realm-cli/utils"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFileName is the name of the project config file, which is kept next to the app's config.json
+const ConfigFileName = ".realm-cli.yaml"
+
+// Names of the flags an Environment's deploy target sets
+const (
+	FlagAppID     = "app-id"
+	FlagProjectID = "project-id"
+	FlagProfile   = "profile"
+)
+
+// ErrEnvironmentNotFound is used when a named environment is not declared in the project config file
+type ErrEnvironmentNotFound struct {
+	Name     string
+	Path     string
+	Declared []string
+}
+
+func (eenf ErrEnvironmentNotFound) Error() string {
+	if len(eenf.Declared) == 0 {
+		return fmt.Sprintf("environment %q is not declared in %s, which declares no environments", eenf.Name, eenf.Path)
+	}
+	return fmt.Sprintf("environment %q is not declared in %s; declared environments are [%s]", eenf.Name, eenf.Path, strings.Join(eenf.Declared, "|"))
+}
+
+// Flags maps flag names to the default values they are given
+type Flags map[string]interface{}
+
+// Config is the contents of a project config file
+type Config struct {
+	// Path is the path of the file the Config was read from
+	Path string `yaml:"-"`
+
+	Defaults     Flags                  `yaml:"defaults,omitempty"`
+	Commands     map[string]Flags       `yaml:"commands,omitempty"`
+	Hooks        Hooks                  `yaml:"hooks,omitempty"`
+	Environments map[string]Environment `yaml:"environments,omitempty"`
+}
+
+// Hooks are the shell commands run at points of an import, in order. Each hook point runs the commands given for
+// every environment followed by those given for the selected environment
+type Hooks struct {
+	// PreDiff runs before the app's changes are diffed, and aborts the import when a command fails
+	PreDiff []string `yaml:"pre_diff,omitempty"`
+	// PostDeploy runs after the app has been deployed
+	PostDeploy []string `yaml:"post_deploy,omitempty"`
+	// OnFailure runs when the import fails
+	OnFailure []string `yaml:"on_failure,omitempty"`
+}
+
+// Environment is a named deploy target, holding the app it maps the local app directory to and the flag values
+// used when it is selected. Its Profile names the stored profile, and with it the base URL and credentials, that
+// the app is reached with
+type Environment struct {
+	AppID     string `yaml:"app_id,omitempty"`
+	ProjectID string `yaml:"project_id,omitempty"`
+	Profile   string `yaml:"profile,omitempty"`
+
+	Defaults Flags            `yaml:"defaults,omitempty"`
+	Commands map[string]Flags `yaml:"commands,omitempty"`
+	Hooks    Hooks            `yaml:"hooks,omitempty"`
+}
+
+// Flags returns the flag values the Environment's deploy target is selected with
+func (e Environment) Flags() Flags {
+	flags := Flags{}
+	if e.AppID != "" {
+		flags[FlagAppID] = e.AppID
+	}
+	if e.ProjectID != "" {
+		flags[FlagProjectID] = e.ProjectID
+	}
+	if e.Profile != "" {
+		flags[FlagProfile] = e.Profile
+	}
+	return flags
+}
+
+// Setting is a flag value resolved from a Config
+type Setting struct {
+	Flag  string
+	Value string
+
+	// Source is the section of the Config the value was read from, e.g. "environments.staging.commands.import"
+	Source string
+
+	// CommandSpecific is whether the value was given for a single command rather than as a default for every command
+	CommandSpecific bool
+
+	// DeployTarget is whether the value was given by the fields of an Environment that name its deploy target
+	DeployTarget bool
+}
+
+// Find returns the Config in the provided directory or the nearest directory above it, or nil if there is none
+func Find(dir string) (*Config, error) {
+	configDir, err := utils.GetDirectoryContainingFile(dir, ConfigFileName)
+	if err != nil {
+		// there is no project config file to read
+		return nil, nil
+	}
+
+	return Load(filepath.Join(configDir, ConfigFileName))
+}
+
+// Load reads the Config at the provided path
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{Path: path}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", path, err)
+	}
+
+	return &config, nil
+}
+
+// Dir returns the directory containing the Config
+func (c *Config) Dir() string {
+	return filepath.Dir(c.Path)
+}
+
+// Resolve returns the settings the Config gives the provided command in the provided environment, sorted by flag.
+// Values given for the environment take precedence over those given for every environment, and values given for
+// the command take precedence over defaults. The environment's deploy target takes precedence over its defaults.
+// An empty environment selects no environment
+func (c *Config) Resolve(command, env string) ([]Setting, error) {
+	settings := map[string]Setting{}
+	apply := func(flags Flags, source string, commandSpecific, deployTarget bool) {
+		for flag, value := range flags {
+			settings[flag] = Setting{
+				Flag:            flag,
+				Value:           formatValue(value),
+				Source:          source,
+				CommandSpecific: commandSpecific,
+				DeployTarget:    deployTarget,
+			}
+		}
+	}
+
+	apply(c.Defaults, "defaults", false, false)
+	apply(c.Commands[command], "commands."+command, true, false)
+
+	if env != "" {
+		environment, ok := c.Environments[env]
+		if !ok {
+			return nil, ErrEnvironmentNotFound{Name: env, Path: c.Path, Declared: c.EnvironmentNames()}
+		}
+
+		apply(environment.Defaults, fmt.Sprintf("environments.%s.defaults", env), false, false)
+		apply(environment.Flags(), "environments."+env, false, true)
+		apply(environment.Commands[command], fmt.Sprintf("environments.%s.commands.%s", env, command), true, false)
+	}
+
+	resolved := make([]Setting, 0, len(settings))
+	for _, setting := range settings {
+		resolved = append(resolved, setting)
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Flag < resolved[j].Flag
+	})
+
+	return resolved, nil
+}
+
+// ResolveHooks returns the hooks the Config gives the provided environment. An empty environment selects no environment
+func (c *Config) ResolveHooks(env string) (Hooks, error) {
+	hooks := c.Hooks
+	if env == "" {
+		return hooks, nil
+	}
+
+	environment, ok := c.Environments[env]
+	if !ok {
+		return Hooks{}, ErrEnvironmentNotFound{Name: env, Path: c.Path, Declared: c.EnvironmentNames()}
+	}
+
+	return Hooks{
+		PreDiff:    append(append([]string{}, hooks.PreDiff...), environment.Hooks.PreDiff...),
+		PostDeploy: append(append([]string{}, hooks.PostDeploy...), environment.Hooks.PostDeploy...),
+		OnFailure:  append(append([]string{}, hooks.OnFailure...), environment.Hooks.OnFailure...),
+	}, nil
+}
+
+// EnvironmentNames returns the names of the environments declared in the Config, sorted
+func (c *Config) EnvironmentNames() []string {
+	names := make([]string, 0, len(c.Environments))
+	for name := range c.Environments {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, formatValue(item))
+		}
+		return strings.Join(values, ",")
+	}
+
+	return fmt.Sprint(value)
+}
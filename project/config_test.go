@@ -0,0 +1,162 @@
+package project_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/10gen/realm-cli/project"
+	u "github.com/10gen/realm-cli/utils/test"
+
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+const testConfig = `defaults:
+  retry-attempts: 5
+  include-hosting: true
+commands:
+  import:
+    strategy: replace-by-name
+hooks:
+  pre_diff:
+    - npm test
+  on_failure:
+    - ./notify.sh failed
+environments:
+  staging:
+    defaults:
+      app-id: my-app-staging
+    commands:
+      import:
+        strategy: merge
+    hooks:
+      pre_diff:
+        - ./check-staging.sh
+      post_deploy:
+        - ./smoke-test.sh
+  prod:
+    app_id: my-app-prod
+    project_id: 5e0000000000000000000002
+    profile: prod
+    defaults:
+      app-id: my-app-ignored
+`
+
+func writeConfig(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "realm-cli-project")
+	u.So(t, err, gc.ShouldBeNil)
+
+	u.So(t, ioutil.WriteFile(filepath.Join(dir, project.ConfigFileName), []byte(contents), 0600), gc.ShouldBeNil)
+	u.So(t, os.MkdirAll(filepath.Join(dir, "functions"), 0700), gc.ShouldBeNil)
+
+	return dir
+}
+
+func TestFind(t *testing.T) {
+	t.Run("should find the config in a parent directory", func(t *testing.T) {
+		dir := writeConfig(t, testConfig)
+		defer os.RemoveAll(dir)
+
+		config, err := project.Find(filepath.Join(dir, "functions"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, config.Path, gc.ShouldEqual, filepath.Join(dir, project.ConfigFileName))
+		u.So(t, config.Dir(), gc.ShouldEqual, dir)
+		u.So(t, config.EnvironmentNames(), gc.ShouldResemble, []string{"prod", "staging"})
+	})
+
+	t.Run("should return no config when there is none", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "realm-cli-project")
+		u.So(t, err, gc.ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		config, err := project.Find(dir)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, config, gc.ShouldBeNil)
+	})
+
+	t.Run("should fail on unknown sections", func(t *testing.T) {
+		dir := writeConfig(t, "default:\n  app-id: my-app\n")
+		defer os.RemoveAll(dir)
+
+		_, err := project.Find(dir)
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, "field default not found")
+	})
+}
+
+func TestConfigResolve(t *testing.T) {
+	dir := writeConfig(t, testConfig)
+	defer os.RemoveAll(dir)
+
+	config, err := project.Find(dir)
+	u.So(t, err, gc.ShouldBeNil)
+
+	t.Run("should resolve the command's settings over the defaults", func(t *testing.T) {
+		settings, err := config.Resolve("import", "")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, settings, gc.ShouldResemble, []project.Setting{
+			{Flag: "include-hosting", Value: "true", Source: "defaults"},
+			{Flag: "retry-attempts", Value: "5", Source: "defaults"},
+			{Flag: "strategy", Value: "replace-by-name", Source: "commands.import", CommandSpecific: true},
+		})
+	})
+
+	t.Run("should resolve the environment's settings over every environment's", func(t *testing.T) {
+		settings, err := config.Resolve("import", "staging")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, settings, gc.ShouldResemble, []project.Setting{
+			{Flag: "app-id", Value: "my-app-staging", Source: "environments.staging.defaults"},
+			{Flag: "include-hosting", Value: "true", Source: "defaults"},
+			{Flag: "retry-attempts", Value: "5", Source: "defaults"},
+			{Flag: "strategy", Value: "merge", Source: "environments.staging.commands.import", CommandSpecific: true},
+		})
+	})
+
+	t.Run("should resolve the environment's deploy target over its defaults", func(t *testing.T) {
+		settings, err := config.Resolve("secrets list", "prod")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, settings, gc.ShouldResemble, []project.Setting{
+			{Flag: "app-id", Value: "my-app-prod", Source: "environments.prod", DeployTarget: true},
+			{Flag: "include-hosting", Value: "true", Source: "defaults"},
+			{Flag: "profile", Value: "prod", Source: "environments.prod", DeployTarget: true},
+			{Flag: "project-id", Value: "5e0000000000000000000002", Source: "environments.prod", DeployTarget: true},
+			{Flag: "retry-attempts", Value: "5", Source: "defaults"},
+		})
+	})
+
+	t.Run("should fail for an undeclared environment", func(t *testing.T) {
+		_, err := config.Resolve("import", "dev")
+		u.So(t, err, gc.ShouldResemble, project.ErrEnvironmentNotFound{Name: "dev", Path: config.Path, Declared: []string{"prod", "staging"}})
+		u.So(t, err.Error(), gc.ShouldEndWith, "declared environments are [prod|staging]")
+	})
+}
+
+func TestConfigResolveHooks(t *testing.T) {
+	dir := writeConfig(t, testConfig)
+	defer os.RemoveAll(dir)
+
+	config, err := project.Find(dir)
+	u.So(t, err, gc.ShouldBeNil)
+
+	t.Run("should resolve the hooks of every environment without an environment", func(t *testing.T) {
+		hooks, err := config.ResolveHooks("")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, hooks, gc.ShouldResemble, project.Hooks{PreDiff: []string{"npm test"}, OnFailure: []string{"./notify.sh failed"}})
+	})
+
+	t.Run("should run the environment's hooks after those of every environment", func(t *testing.T) {
+		hooks, err := config.ResolveHooks("staging")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, hooks, gc.ShouldResemble, project.Hooks{
+			PreDiff:    []string{"npm test", "./check-staging.sh"},
+			PostDeploy: []string{"./smoke-test.sh"},
+			OnFailure:  []string{"./notify.sh failed"},
+		})
+	})
+
+	t.Run("should fail for an undeclared environment", func(t *testing.T) {
+		_, err := config.ResolveHooks("dev")
+		u.So(t, err, gc.ShouldHaveSameTypeAs, project.ErrEnvironmentNotFound{})
+	})
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Arguments appended to the secret-store command for each operation
+const (
+	commandArgGet   = "get"
+	commandArgStore = "store"
+)
+
+var (
+	errMissingCredentialCommand = errors.New("a secret-store command is required")
+)
+
+// CommandStrategy is a Strategy that delegates reading and writing data to an external secret-store
+// command. The command is run with a trailing "get" argument and must print the stored data to stdout,
+// or print nothing if no data has been stored. It is run with a trailing "store" argument to save the
+// data it is given on stdin
+type CommandStrategy struct {
+	name string
+	args []string
+}
+
+// NewCommandStrategy returns a new CommandStrategy given a command line, e.g. "my-keychain-helper realm-cli"
+func NewCommandStrategy(command string) (Strategy, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errMissingCredentialCommand
+	}
+
+	return &CommandStrategy{
+		name: fields[0],
+		args: fields[1:],
+	}, nil
+}
+
+// Read returns the data printed by the secret-store command
+func (cs *CommandStrategy) Read() ([]byte, error) {
+	var stdout bytes.Buffer
+
+	if err := cs.run(commandArgGet, nil, &stdout); err != nil {
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Write passes the data to the secret-store command
+func (cs *CommandStrategy) Write(data []byte) error {
+	return cs.run(commandArgStore, bytes.NewReader(data), nil)
+}
+
+func (cs *CommandStrategy) run(operation string, stdin *bytes.Reader, stdout *bytes.Buffer) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(cs.name, append(append([]string{}, cs.args...), operation)...)
+	cmd.Env = os.Environ()
+	cmd.Stderr = &stderr
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("secret-store command %q failed to %s credentials: %s: %s", cs.name, operation, err, msg)
+		}
+		return fmt.Errorf("secret-store command %q failed to %s credentials: %s", cs.name, operation, err)
+	}
+
+	return nil
+}
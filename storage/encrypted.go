@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptionSaltLength = 16
+	encryptionKeyLength  = 32
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var encryptedFileHeader = []byte("realm-cli-encrypted:v1\n")
+
+// Errors related to encrypted storage
+var (
+	ErrMissingEncryptionSecret = errors.New("a passphrase or key file is required to encrypt credentials")
+	ErrDecryptionFailed        = errors.New("failed to decrypt credentials: the passphrase or key file is incorrect")
+)
+
+// EncryptedFileStrategy is a Strategy that reads/persists data to/from a file at the provided path,
+// encrypting it with AES-GCM using a key derived from a passphrase or key file
+type EncryptedFileStrategy struct {
+	path   string
+	secret []byte
+
+	// the derived key is cached along with its salt since derivation is deliberately slow
+	salt []byte
+	key  []byte
+}
+
+// NewEncryptedFileStrategy returns a new EncryptedFileStrategy given a location on disk to store data
+// and the secret to derive the encryption key from
+func NewEncryptedFileStrategy(path string, secret []byte) (Strategy, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingEncryptionSecret
+	}
+
+	return &EncryptedFileStrategy{
+		path:   path,
+		secret: secret,
+	}, nil
+}
+
+// Read reads and decrypts data from the file at the provided path
+func (efs *EncryptedFileStrategy) Read() ([]byte, error) {
+	if _, err := os.Stat(efs.path); os.IsNotExist(err) {
+		return []byte{}, nil
+	}
+
+	raw, err := ioutil.ReadFile(efs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(raw, encryptedFileHeader) {
+		return nil, fmt.Errorf("failed to read credentials: %s is not an encrypted credentials file", efs.path)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(raw[len(encryptedFileHeader):])))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %s", err)
+	}
+
+	if len(sealed) < encryptionSaltLength {
+		return nil, ErrDecryptionFailed
+	}
+
+	salt, sealed := sealed[:encryptionSaltLength], sealed[encryptionSaltLength:]
+
+	gcm, err := efs.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	data, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return data, nil
+}
+
+// Write encrypts and writes data to the file at the provided path
+func (efs *EncryptedFileStrategy) Write(data []byte) error {
+	salt := efs.salt
+	if salt == nil {
+		salt = make([]byte, encryptionSaltLength)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return err
+		}
+	}
+
+	gcm, err := efs.cipher(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	sealed := append(append(append([]byte{}, salt...), nonce...), gcm.Seal(nil, nonce, data, nil)...)
+
+	var buf bytes.Buffer
+	buf.Write(encryptedFileHeader)
+	buf.WriteString(base64.StdEncoding.EncodeToString(sealed))
+	buf.WriteString("\n")
+
+	if err := os.MkdirAll(filepath.Dir(efs.path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(efs.path, buf.Bytes(), 0600)
+}
+
+func (efs *EncryptedFileStrategy) cipher(salt []byte) (cipher.AEAD, error) {
+	if efs.key == nil || !bytes.Equal(efs.salt, salt) {
+		key, err := scrypt.Key(efs.secret, salt, scryptN, scryptR, scryptP, encryptionKeyLength)
+		if err != nil {
+			return nil, err
+		}
+
+		efs.salt = salt
+		efs.key = key
+	}
+
+	block, err := aes.NewCipher(efs.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -1,15 +1,37 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/10gen/realm-cli/user"
 
 	"gopkg.in/yaml.v2"
 )
 
+// DefaultProfile is the name of the profile used when no other profile has been selected
+const DefaultProfile = "default"
+
+// Errors related to profiles
+var (
+	ErrInvalidProfileName       = errors.New("a profile name must not be empty")
+	ErrDefaultProfileNotDeleted = fmt.Errorf("the %q profile cannot be deleted, use 'logout' to clear it instead", DefaultProfile)
+)
+
+// ErrProfileNotFound is used when a named profile does not exist in Storage
+type ErrProfileNotFound struct {
+	Name string
+}
+
+func (epnf ErrProfileNotFound) Error() string {
+	return fmt.Sprintf("profile %q does not exist", epnf.Name)
+}
+
 // New returns a new Storage given a Strategy
 func New(strategy Strategy) *Storage {
 	return &Storage{
@@ -20,6 +42,133 @@ func New(strategy Strategy) *Storage {
 // Storage represents something that can write user data to some form of Storage
 type Storage struct {
 	strategy Strategy
+	profile  string
+}
+
+// config is the document persisted by a Strategy. The default profile is stored inline at the
+// top level so that files written before profiles existed continue to be read as the default profile
+type config struct {
+	user.User      `yaml:",inline"`
+	CurrentProfile string                `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*user.User `yaml:"profiles,omitempty"`
+}
+
+func (c *config) user(profile string) (*user.User, bool) {
+	if profile == DefaultProfile {
+		u := c.User
+		return &u, true
+	}
+
+	u, ok := c.Profiles[profile]
+	if !ok || u == nil {
+		return &user.User{}, false
+	}
+
+	profileUser := *u
+	return &profileUser, true
+}
+
+func (c *config) setUser(profile string, u *user.User) {
+	if profile == DefaultProfile {
+		c.User = *u
+		return
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[string]*user.User{}
+	}
+
+	profileUser := *u
+	c.Profiles[profile] = &profileUser
+}
+
+// WithProfile returns a Storage that reads and writes user data for the named profile
+func (s *Storage) WithProfile(profile string) *Storage {
+	return &Storage{
+		strategy: s.strategy,
+		profile:  profile,
+	}
+}
+
+// CurrentProfile returns the name of the profile that user data is read from and written to
+func (s *Storage) CurrentProfile() (string, error) {
+	c, err := s.readConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return s.activeProfile(c), nil
+}
+
+// Profiles returns the names of all profiles in Storage, sorted by name
+func (s *Storage) Profiles() ([]string, error) {
+	c, err := s.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := []string{DefaultProfile}
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			profiles = append(profiles, name)
+		}
+	}
+
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// UseProfile sets the named profile as the one used when no profile has been explicitly selected
+func (s *Storage) UseProfile(profile string) error {
+	if profile == "" {
+		return ErrInvalidProfileName
+	}
+
+	c, err := s.readConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := c.user(profile); !ok {
+		return ErrProfileNotFound{profile}
+	}
+
+	c.CurrentProfile = profile
+	if profile == DefaultProfile {
+		c.CurrentProfile = ""
+	}
+
+	return s.writeConfig(c)
+}
+
+// DeleteProfile removes the named profile and its user data from Storage. If the profile
+// is currently in use, the default profile is used from then on
+func (s *Storage) DeleteProfile(profile string) error {
+	if profile == "" {
+		return ErrInvalidProfileName
+	}
+
+	if profile == DefaultProfile {
+		return ErrDefaultProfileNotDeleted
+	}
+
+	c, err := s.readConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := c.user(profile); !ok {
+		return ErrProfileNotFound{profile}
+	}
+
+	delete(c.Profiles, profile)
+
+	if c.CurrentProfile == profile {
+		c.CurrentProfile = ""
+	}
+
+	return s.writeConfig(c)
 }
 
 // WriteUserConfig writes the user data to Storage
@@ -33,25 +182,24 @@ func (s *Storage) WriteUserConfig(u *user.User) error {
 		u.APIKey = ""
 	}
 
-	raw, err := yaml.Marshal(u)
+	c, err := s.readConfig()
 	if err != nil {
 		return err
 	}
 
-	return s.strategy.Write(raw)
+	c.setUser(s.activeProfile(c), u)
+
+	return s.writeConfig(c)
 }
 
 // ReadUserConfig reads the user data from Storage
 func (s *Storage) ReadUserConfig() (*user.User, error) {
-	b, err := s.strategy.Read()
+	c, err := s.readConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	var user user.User
-	if err := yaml.Unmarshal(b, &user); err != nil {
-		return nil, err
-	}
+	user, _ := c.user(s.activeProfile(c))
 
 	// TODO remove after personal API key support has been fully removed
 	if user.Username != "" && user.PublicAPIKey == "" {
@@ -62,7 +210,7 @@ func (s *Storage) ReadUserConfig() (*user.User, error) {
 		user.PrivateAPIKey = user.APIKey
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 // Clear clears out a user's data from Storage
@@ -70,6 +218,41 @@ func (s *Storage) Clear() error {
 	return s.WriteUserConfig(&user.User{})
 }
 
+func (s *Storage) activeProfile(c *config) string {
+	if s.profile != "" {
+		return s.profile
+	}
+
+	if c.CurrentProfile != "" {
+		return c.CurrentProfile
+	}
+
+	return DefaultProfile
+}
+
+func (s *Storage) readConfig() (*config, error) {
+	b, err := s.strategy.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var c config
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (s *Storage) writeConfig(c *config) error {
+	raw, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return s.strategy.Write(raw)
+}
+
 // FileStrategy is a Storage that reads/persists data to/from a file at the provided path
 type FileStrategy struct {
 	path string
@@ -100,6 +283,88 @@ func NewFileStrategy(path string) (Strategy, error) {
 	}, nil
 }
 
+// MemoryStrategy is a Strategy that stores data in memory only, so nothing is persisted between runs
+type MemoryStrategy struct {
+	data []byte
+}
+
+// Read reads the data currently stored in memory
+func (ms *MemoryStrategy) Read() ([]byte, error) {
+	return ms.data, nil
+}
+
+// Write records the provided data to memory
+func (ms *MemoryStrategy) Write(data []byte) error {
+	ms.data = data
+	return nil
+}
+
+// NewMemoryStrategy returns a new MemoryStrategy populated with data
+func NewMemoryStrategy(data []byte) *MemoryStrategy {
+	return &MemoryStrategy{
+		data: data,
+	}
+}
+
+// MigrationResult describes what MigrateFile did with the plaintext data it found
+type MigrationResult int
+
+// Set of migration results
+const (
+	// MigrationNone means there was no plaintext data to migrate
+	MigrationNone MigrationResult = iota
+	// MigrationMoved means the plaintext data was moved into the Strategy, or the Strategy already held the same data
+	MigrationMoved
+	// MigrationBackedUp means the Strategy already held other data, which is kept, so the plaintext data was moved
+	// to its backup path instead
+	MigrationBackedUp
+)
+
+// MigrationBackupPath returns the path MigrateFile moves the plaintext data at the provided path to when the
+// Strategy already holds other data
+func MigrationBackupPath(path string) string {
+	return path + ".bak"
+}
+
+// MigrateFile moves the plaintext data stored by a FileStrategy at the provided path into the provided
+// Strategy and then removes the file. Data is only moved if the Strategy does not hold any data yet. Otherwise,
+// unless the Strategy holds the same data, the file is moved to its backup path so that no data is lost without
+// plaintext data being left where it is read from
+func MigrateFile(path string, strategy Strategy) (MigrationResult, error) {
+	plaintext, err := (&FileStrategy{path: path}).Read()
+	if err != nil {
+		return MigrationNone, err
+	}
+
+	if len(bytes.TrimSpace(plaintext)) == 0 {
+		return MigrationNone, nil
+	}
+
+	existing, err := strategy.Read()
+	if err != nil {
+		return MigrationNone, err
+	}
+
+	if len(bytes.TrimSpace(existing)) != 0 {
+		if bytes.Equal(bytes.TrimSpace(existing), bytes.TrimSpace(plaintext)) {
+			return MigrationMoved, os.Remove(path)
+		}
+
+		backupPath := MigrationBackupPath(path)
+		if _, err := os.Stat(backupPath); err == nil {
+			return MigrationNone, fmt.Errorf("the store already holds other data than %s, which cannot be moved to %s since it already exists", path, backupPath)
+		}
+
+		return MigrationBackedUp, os.Rename(path, backupPath)
+	}
+
+	if err := strategy.Write(plaintext); err != nil {
+		return MigrationNone, err
+	}
+
+	return MigrationMoved, os.Remove(path)
+}
+
 // Strategy represents a means of reading and writing data
 type Strategy interface {
 	Read() ([]byte, error)
@@ -1,8 +1,13 @@
 package storage_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/10gen/realm-cli/storage"
 	"github.com/10gen/realm-cli/user"
 	u "github.com/10gen/realm-cli/utils/test"
 
@@ -37,3 +42,261 @@ func TestStorageMigration(t *testing.T) {
 		u.So(t, migratedUser.PrivateAPIKey, gc.ShouldEqual, "my-api-key")
 	})
 }
+
+func TestStorageProfiles(t *testing.T) {
+	t.Run("reads a file written before profiles existed as the default profile", func(t *testing.T) {
+		s := u.NewPopulatedStorage("my-api-key", "refresh", "access")
+
+		currentProfile, err := s.CurrentProfile()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, currentProfile, gc.ShouldEqual, storage.DefaultProfile)
+
+		defaultUser, err := s.WithProfile(storage.DefaultProfile).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, defaultUser.PrivateAPIKey, gc.ShouldEqual, "my-api-key")
+	})
+
+	t.Run("keeps user data separate for each profile", func(t *testing.T) {
+		s := u.NewPopulatedStorage("default-api-key", "refresh", "access")
+
+		err := s.WithProfile("staging").WriteUserConfig(&user.User{
+			PublicAPIKey:  "staging.name",
+			PrivateAPIKey: "staging-api-key",
+			BaseURL:       "https://staging.example.com",
+		})
+		u.So(t, err, gc.ShouldBeNil)
+
+		stagingUser, err := s.WithProfile("staging").ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, stagingUser.PrivateAPIKey, gc.ShouldEqual, "staging-api-key")
+		u.So(t, stagingUser.BaseURL, gc.ShouldEqual, "https://staging.example.com")
+
+		defaultUser, err := s.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, defaultUser.PrivateAPIKey, gc.ShouldEqual, "default-api-key")
+
+		profiles, err := s.Profiles()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, profiles, gc.ShouldResemble, []string{storage.DefaultProfile, "staging"})
+	})
+
+	t.Run("reads and writes the selected profile when none is given", func(t *testing.T) {
+		s := u.NewEmptyStorage()
+
+		err := s.WithProfile("prod").WriteUserConfig(&user.User{PrivateAPIKey: "prod-api-key"})
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, s.UseProfile("prod"), gc.ShouldBeNil)
+
+		currentUser, err := s.ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, currentUser.PrivateAPIKey, gc.ShouldEqual, "prod-api-key")
+
+		u.So(t, s.UseProfile("missing"), gc.ShouldResemble, storage.ErrProfileNotFound{Name: "missing"})
+	})
+
+	t.Run("deleting the profile in use falls back to the default profile", func(t *testing.T) {
+		s := u.NewPopulatedStorage("default-api-key", "refresh", "access")
+
+		err := s.WithProfile("dev").WriteUserConfig(&user.User{PrivateAPIKey: "dev-api-key"})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, s.UseProfile("dev"), gc.ShouldBeNil)
+
+		u.So(t, s.DeleteProfile("dev"), gc.ShouldBeNil)
+
+		currentProfile, err := s.CurrentProfile()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, currentProfile, gc.ShouldEqual, storage.DefaultProfile)
+
+		profiles, err := s.Profiles()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, profiles, gc.ShouldResemble, []string{storage.DefaultProfile})
+	})
+
+	t.Run("the default profile cannot be deleted", func(t *testing.T) {
+		s := u.NewEmptyStorage()
+		u.So(t, s.DeleteProfile(storage.DefaultProfile), gc.ShouldEqual, storage.ErrDefaultProfileNotDeleted)
+	})
+}
+
+func TestEncryptedFileStrategy(t *testing.T) {
+	setup := func(t *testing.T) string {
+		dir, err := ioutil.TempDir("", "realm-cli-storage")
+		u.So(t, err, gc.ShouldBeNil)
+		return dir
+	}
+
+	t.Run("should require a secret", func(t *testing.T) {
+		_, err := storage.NewEncryptedFileStrategy("/somewhere", nil)
+		u.So(t, err, gc.ShouldEqual, storage.ErrMissingEncryptionSecret)
+	})
+
+	t.Run("should encrypt the stored data", func(t *testing.T) {
+		dir := setup(t)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "realm.enc")
+		strategy, err := storage.NewEncryptedFileStrategy(path, []byte("my passphrase"))
+		u.So(t, err, gc.ShouldBeNil)
+
+		s := storage.New(strategy)
+		u.So(t, s.WriteUserConfig(&user.User{PublicAPIKey: "user.name", PrivateAPIKey: "my-private-api-key"}), gc.ShouldBeNil)
+
+		raw, err := ioutil.ReadFile(path)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(raw), gc.ShouldNotContainSubstring, "my-private-api-key")
+
+		reopened, err := storage.NewEncryptedFileStrategy(path, []byte("my passphrase"))
+		u.So(t, err, gc.ShouldBeNil)
+
+		storedUser, err := storage.New(reopened).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "my-private-api-key")
+	})
+
+	t.Run("should fail to read with the wrong secret", func(t *testing.T) {
+		dir := setup(t)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "realm.enc")
+		strategy, err := storage.NewEncryptedFileStrategy(path, []byte("my passphrase"))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, strategy.Write([]byte("data")), gc.ShouldBeNil)
+
+		wrong, err := storage.NewEncryptedFileStrategy(path, []byte("not my passphrase"))
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = wrong.Read()
+		u.So(t, err, gc.ShouldEqual, storage.ErrDecryptionFailed)
+	})
+}
+
+func TestCommandStrategy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret-store command test uses a shell script")
+	}
+
+	dir, err := ioutil.TempDir("", "realm-cli-storage")
+	u.So(t, err, gc.ShouldBeNil)
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "secret-store")
+	u.So(t, ioutil.WriteFile(script, []byte(`#!/bin/sh
+case "$2" in
+  get) cat "$1" 2>/dev/null || true ;;
+  store) cat > "$1" ;;
+  *) echo "unknown operation $2" >&2; exit 1 ;;
+esac
+`), 0700), gc.ShouldBeNil)
+
+	strategy, err := storage.NewCommandStrategy(script + " " + filepath.Join(dir, "store"))
+	u.So(t, err, gc.ShouldBeNil)
+
+	data, err := strategy.Read()
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, data, gc.ShouldBeEmpty)
+
+	u.So(t, strategy.Write([]byte("my data")), gc.ShouldBeNil)
+
+	data, err = strategy.Read()
+	u.So(t, err, gc.ShouldBeNil)
+	u.So(t, string(data), gc.ShouldEqual, "my data")
+}
+
+func TestMigrateFile(t *testing.T) {
+	setup := func(t *testing.T) (string, string) {
+		dir, err := ioutil.TempDir("", "realm-cli-storage")
+		u.So(t, err, gc.ShouldBeNil)
+
+		path := filepath.Join(dir, "realm")
+		u.So(t, ioutil.WriteFile(path, []byte("private_api_key: my-private-api-key\n"), 0600), gc.ShouldBeNil)
+
+		return dir, path
+	}
+
+	t.Run("moves plaintext data into an empty strategy and removes the file", func(t *testing.T) {
+		dir, path := setup(t)
+		defer os.RemoveAll(dir)
+
+		strategy := u.NewMemoryStrategy([]byte{})
+
+		result, err := storage.MigrateFile(path, strategy)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldEqual, storage.MigrationMoved)
+
+		storedUser, err := storage.New(strategy).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "my-private-api-key")
+
+		_, err = os.Stat(path)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+	})
+
+	t.Run("leaves other data in the strategy untouched and moves the file to its backup path", func(t *testing.T) {
+		dir, path := setup(t)
+		defer os.RemoveAll(dir)
+
+		strategy := u.NewMemoryStrategy([]byte("private_api_key: newer-api-key\n"))
+
+		result, err := storage.MigrateFile(path, strategy)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldEqual, storage.MigrationBackedUp)
+
+		storedUser, err := storage.New(strategy).ReadUserConfig()
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, storedUser.PrivateAPIKey, gc.ShouldEqual, "newer-api-key")
+
+		_, err = os.Stat(path)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		backup, err := ioutil.ReadFile(storage.MigrationBackupPath(path))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(backup), gc.ShouldEqual, "private_api_key: my-private-api-key\n")
+	})
+
+	t.Run("removes the file when the strategy already holds the same data", func(t *testing.T) {
+		dir, path := setup(t)
+		defer os.RemoveAll(dir)
+
+		result, err := storage.MigrateFile(path, u.NewMemoryStrategy([]byte("private_api_key: my-private-api-key\n")))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldEqual, storage.MigrationMoved)
+
+		_, err = os.Stat(path)
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+
+		_, err = os.Stat(storage.MigrationBackupPath(path))
+		u.So(t, os.IsNotExist(err), gc.ShouldBeTrue)
+	})
+
+	t.Run("refuses to overwrite an earlier backup", func(t *testing.T) {
+		dir, path := setup(t)
+		defer os.RemoveAll(dir)
+
+		backupPath := storage.MigrationBackupPath(path)
+		u.So(t, ioutil.WriteFile(backupPath, []byte("private_api_key: older-api-key\n"), 0600), gc.ShouldBeNil)
+
+		_, err := storage.MigrateFile(path, u.NewMemoryStrategy([]byte("private_api_key: newer-api-key\n")))
+		u.So(t, err, gc.ShouldNotBeNil)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, path)
+		u.So(t, err.Error(), gc.ShouldContainSubstring, backupPath)
+
+		_, err = os.Stat(path)
+		u.So(t, err, gc.ShouldBeNil)
+
+		backup, err := ioutil.ReadFile(backupPath)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(backup), gc.ShouldEqual, "private_api_key: older-api-key\n")
+	})
+
+	t.Run("does nothing without plaintext data", func(t *testing.T) {
+		dir, path := setup(t)
+		defer os.RemoveAll(dir)
+
+		u.So(t, os.Remove(path), gc.ShouldBeNil)
+
+		result, err := storage.MigrateFile(path, u.NewMemoryStrategy([]byte{}))
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, result, gc.ShouldEqual, storage.MigrationNone)
+	})
+}
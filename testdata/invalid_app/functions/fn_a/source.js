@@ -0,0 +1 @@
+exports = function() { return 1; };
@@ -22,6 +22,15 @@ type User struct {
 
 	RefreshToken string `yaml:"refresh_token"`
 	AccessToken  string `yaml:"access_token"`
+
+	BaseURL      string `yaml:"base_url,omitempty"`
+	AtlasBaseURL string `yaml:"atlas_base_url,omitempty"`
+
+	ProxyURL           string `yaml:"proxy_url,omitempty"`
+	CABundle           string `yaml:"ca_bundle,omitempty"`
+	ClientCert         string `yaml:"client_cert,omitempty"`
+	ClientKey          string `yaml:"client_key,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
 }
 
 // LoggedIn returns a boolean representing whether the user is logged in or not
@@ -20,6 +20,21 @@ const (
 	extTgz = ".tgz"
 )
 
+// dependenciesArchiveName is the name, without its extension, of the archive of an app's dependencies
+const dependenciesArchiveName = "node_modules"
+
+// IsDependenciesArchive reports whether the file at the path is an archive of an app's dependencies, i.e. a
+// node_modules archive with one of the supported extensions
+func IsDependenciesArchive(fullPath string) bool {
+	base := strings.ToLower(path.Base(filepath.ToSlash(fullPath)))
+	for _, ext := range []string{extZip, extTar, extGz, extTgz} {
+		if base == dependenciesArchiveName+ext {
+			return true
+		}
+	}
+	return false
+}
+
 // TraverseArchiveReader traverses the archive reader while invoking the provided handler for each new file
 func TraverseArchiveReader(archiveReader ArchiveReader, fileHandler func(header *FileHeader) error) error {
 	for {
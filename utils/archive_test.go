@@ -259,3 +259,19 @@ func TestArchiveReaderInvalidExtension(t *testing.T) {
 		}
 	}
 }
+
+func TestIsDependenciesArchive(t *testing.T) {
+	for filename, expected := range map[string]bool{
+		"functions/node_modules.tar":    true,
+		"functions/node_modules.zip":    true,
+		"functions/node_modules.tar.gz": true,
+		"functions/node_modules.tgz":    true,
+		"functions/node_modules":        false,
+		"functions/node_modules.txt":    false,
+		"functions/modules.zip":         false,
+	} {
+		if actual := IsDependenciesArchive(filename); actual != expected {
+			t.Fatalf("expected IsDependenciesArchive(%q) to be %t but it was %t", filename, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvironmentsRoot is the directory of an app holding the overlay directory of each environment
+const EnvironmentsRoot = "environments"
+
+// EnvironmentOverlayDirectory returns the overlay directory of the named environment of the app at the provided path
+func EnvironmentOverlayDirectory(appPath, env string) string {
+	return filepath.Join(appPath, EnvironmentsRoot, env)
+}
+
+// appDir reads the files of an app directory, with the files of an optional overlay directory merged over them and
+// the placeholders in their JSON values resolved
+type appDir struct {
+	root       string
+	overlay    string
+	lookupVar  VarLookup
+	unresolved *[]UnresolvedVar
+}
+
+// overlayPath returns the path in the overlay directory of the provided path in the app directory, or "" if there
+// is no overlay directory
+func (d appDir) overlayPath(path string) string {
+	if d.overlay == "" {
+		return ""
+	}
+
+	rel, err := filepath.Rel(d.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	return filepath.Join(d.overlay, rel)
+}
+
+// exists returns whether the file exists in the app or overlay directory
+func (d appDir) exists(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+
+	if overlayPath := d.overlayPath(path); overlayPath != "" {
+		if _, err := os.Stat(overlayPath); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDir returns whether the path is a directory in the app or overlay directory
+func (d appDir) isDir(path string) bool {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return true
+	}
+
+	if overlayPath := d.overlayPath(path); overlayPath != "" {
+		if info, err := os.Stat(overlayPath); err == nil && info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readDir lists the directory in the app directory together with its counterpart in the overlay directory
+func (d appDir) readDir(path string) ([]os.FileInfo, error) {
+	fileInfos, err := ioutil.ReadDir(path)
+
+	overlayPath := d.overlayPath(path)
+	if overlayPath == "" {
+		return fileInfos, err
+	}
+
+	overlayInfos, overlayErr := ioutil.ReadDir(overlayPath)
+	if overlayErr != nil {
+		return fileInfos, err
+	}
+
+	names := map[string]bool{}
+	for _, fileInfo := range fileInfos {
+		names[fileInfo.Name()] = true
+	}
+
+	for _, overlayInfo := range overlayInfos {
+		if !names[overlayInfo.Name()] {
+			fileInfos = append(fileInfos, overlayInfo)
+		}
+	}
+
+	sort.Slice(fileInfos, func(i, j int) bool {
+		return fileInfos[i].Name() < fileInfos[j].Name()
+	})
+
+	return fileInfos, nil
+}
+
+// readFile reads the file from the overlay directory, if it is there, or else from the app directory
+func (d appDir) readFile(path string) ([]byte, error) {
+	if overlayPath := d.overlayPath(path); overlayPath != "" {
+		if _, err := os.Stat(overlayPath); err == nil {
+			return ioutil.ReadFile(overlayPath)
+		}
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// readAndUnmarshalJSONInto unmarshals the JSON file in the app directory with its counterpart in the overlay
+// directory, if any, deep merged over it and its placeholders resolved
+func (d appDir) readAndUnmarshalJSONInto(path string, out interface{}) error {
+	if d.lookupVar == nil {
+		return d.readAndMergeJSONInto(path, out)
+	}
+
+	var value interface{}
+	if err := d.readAndMergeJSONInto(path, &value); err != nil {
+		return err
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	relativePath := path
+	if rel, err := filepath.Rel(d.root, path); err == nil {
+		relativePath = rel
+	}
+
+	interpolated, err := json.Marshal(interpolateVars(value, d.lookupVar, func(name string) {
+		*d.unresolved = append(*d.unresolved, UnresolvedVar{Path: relativePath, Name: name})
+	}))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(interpolated, out)
+}
+
+// readAndMergeJSONInto unmarshals the JSON file in the app directory with its counterpart in the overlay directory,
+// if any, deep merged over it
+func (d appDir) readAndMergeJSONInto(path string, out interface{}) error {
+	overlayPath := d.overlayPath(path)
+	if overlayPath == "" {
+		return readAndUnmarshalJSONInto(path, out)
+	}
+
+	if _, err := os.Stat(overlayPath); err != nil {
+		return readAndUnmarshalJSONInto(path, out)
+	}
+
+	var base interface{}
+	if _, err := os.Stat(path); err == nil {
+		if err := readAndUnmarshalJSONInto(path, &base); err != nil {
+			return err
+		}
+	}
+
+	var overlay interface{}
+	if err := readAndUnmarshalJSONInto(overlayPath, &overlay); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(MergeJSON(base, overlay))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(merged, out); err != nil {
+		return fmt.Errorf("failed to merge %s over %s: %s", overlayPath, path, err)
+	}
+
+	return nil
+}
+
+// MergeJSON deep merges the overlay over the base value. Objects are merged key by key, while any other
+// overlay value, including arrays, replaces the base value
+func MergeJSON(base, overlay interface{}) interface{} {
+	baseObject, baseOK := base.(map[string]interface{})
+	overlayObject, overlayOK := overlay.(map[string]interface{})
+	if !baseOK || !overlayOK {
+		return overlay
+	}
+
+	merged := make(map[string]interface{}, len(baseObject)+len(overlayObject))
+	for key, value := range baseObject {
+		merged[key] = value
+	}
+
+	for key, value := range overlayObject {
+		merged[key] = MergeJSON(baseObject[key], value)
+	}
+
+	return merged
+}
+
+// ReadAppFile reads the file at the path, relative to the app directory, as UnmarshalFromDirWithOptions reads it:
+// from the overlay directory if it only holds a file there, and for a JSON file, with the overlay's file deep merged
+// over it and its placeholders resolved, failing with an ErrUnresolvedVars in strict mode
+func ReadAppFile(appPath, path string, options UnmarshalOptions) ([]byte, error) {
+	d := appDir{root: appPath, overlay: options.OverlayPath, lookupVar: options.LookupVar, unresolved: &[]UnresolvedVar{}}
+	fullPath := filepath.Join(appPath, filepath.FromSlash(path))
+
+	if filepath.Ext(fullPath) != jsonExt || (options.LookupVar == nil && !d.inOverlay(fullPath)) {
+		return d.readFile(fullPath)
+	}
+
+	var value interface{}
+	if err := d.readAndUnmarshalJSONInto(fullPath, &value); err != nil {
+		return nil, err
+	}
+
+	if options.StrictVars && len(*d.unresolved) != 0 {
+		return nil, ErrUnresolvedVars{Vars: sortUnresolvedVars(*d.unresolved)}
+	}
+
+	return json.MarshalIndent(value, "", "    ")
+}
+
+// inOverlay returns whether the file has a counterpart in the overlay directory
+func (d appDir) inOverlay(path string) bool {
+	overlayPath := d.overlayPath(path)
+	if overlayPath == "" {
+		return false
+	}
+
+	_, err := os.Stat(overlayPath)
+	return err == nil
+}
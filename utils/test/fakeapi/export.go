@@ -0,0 +1,216 @@
+package fakeapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exportApp responds with the deployed app configuration, or the one deployed by the deployment given by the
+// "deployment" query parameter, laid out as an app directory in a zip
+func (s *Server) exportApp(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	config := app.Config
+	if deploymentID := r.URL.Query().Get("deployment"); deploymentID != "" {
+		var ok bool
+		if config, ok = s.deployed[deploymentID]; !ok {
+			writeError(w, http.StatusNotFound, "DeploymentNotFound", "deployment not found")
+			return
+		}
+	}
+
+	files, err := appFiles(config)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	data, err := zipFiles(files)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.zip", app.Name, time.Now().UTC().Format("20060102150405"))
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Write(data) // nolint: errcheck
+}
+
+// appFiles splits an app configuration into the files of an app directory, keyed by their path
+func appFiles(config map[string]interface{}) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	add := func(name string, value interface{}) error {
+		data, err := json.MarshalIndent(value, "", "    ")
+		if err != nil {
+			return err
+		}
+		files[name] = data
+		return nil
+	}
+
+	addFunctions := func(dir string, functions interface{}) error {
+		for i, fn := range asSlice(functions) {
+			fnMap := asMap(fn)
+			fnConfig := asMap(fnMap["config"])
+			fnDir := path.Join(dir, nameOf(fnConfig, "name", i))
+
+			if err := add(path.Join(fnDir, "config.json"), fnConfig); err != nil {
+				return err
+			}
+
+			source, _ := fnMap["source"].(string)
+			files[path.Join(fnDir, "source.js")] = []byte(source)
+		}
+		return nil
+	}
+
+	appConfig := map[string]interface{}{}
+	for field, value := range config {
+		switch field {
+		case "secrets", "values", "auth_providers", "functions", "triggers", "graphql", "services":
+		default:
+			appConfig[field] = value
+		}
+	}
+
+	if err := add("config.json", appConfig); err != nil {
+		return nil, err
+	}
+
+	if secrets, ok := config["secrets"]; ok {
+		if err := add("secrets.json", secrets); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, dir := range []string{"values", "auth_providers", "triggers"} {
+		for i, value := range asSlice(config[dir]) {
+			if err := add(path.Join(dir, nameOf(asMap(value), "name", i)+".json"), value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := addFunctions("functions", config["functions"]); err != nil {
+		return nil, err
+	}
+
+	graphQL := asMap(config["graphql"])
+	if graphQLConfig, ok := graphQL["config"]; ok {
+		if err := add("graphql/config.json", graphQLConfig); err != nil {
+			return nil, err
+		}
+	}
+	for i, resolver := range asSlice(graphQL["custom_resolvers"]) {
+		resolverMap := asMap(resolver)
+		name := fmt.Sprintf("%s_%s", resolverMap["on_type"], nameOf(resolverMap, "field_name", i))
+		if err := add(path.Join("graphql/custom_resolvers", name+".json"), resolver); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, svc := range asSlice(config["services"]) {
+		svcMap := asMap(svc)
+		svcConfig := asMap(svcMap["config"])
+		svcDir := path.Join("services", nameOf(svcConfig, "name", i))
+
+		if err := add(path.Join(svcDir, "config.json"), svcConfig); err != nil {
+			return nil, err
+		}
+
+		if err := addFunctions(path.Join(svcDir, "incoming_webhooks"), svcMap["incoming_webhooks"]); err != nil {
+			return nil, err
+		}
+
+		for j, rule := range asSlice(svcMap["rules"]) {
+			ruleMap := asMap(rule)
+			name := nameOf(ruleMap, "name", j)
+			if namespace, ok := ruleMap["namespace"].(string); ok && namespace != "" {
+				name = namespace
+			}
+			if err := add(path.Join(svcDir, "rules", name+".json"), rule); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// zipFiles writes the files into a zip, preceding them with entries for their directories
+func zipFiles(files map[string][]byte) ([]byte, error) {
+	entries := map[string]bool{}
+	for name := range files {
+		entries[name] = true
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			entries[dir+"/"] = true
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range names {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		if strings.HasSuffix(name, "/") {
+			header.SetMode(os.ModeDir | 0755)
+		} else {
+			header.SetMode(0644)
+		}
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return nil, err
+		}
+
+		if data, ok := files[name]; ok {
+			if _, err := fw.Write(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+func asSlice(value interface{}) []interface{} {
+	if s, ok := value.([]interface{}); ok {
+		return s
+	}
+	return nil
+}
+
+// nameOf returns the string value of the field, falling back to the index of the value
+func nameOf(value map[string]interface{}, field string, index int) string {
+	if name, ok := value[field].(string); ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("%d", index)
+}
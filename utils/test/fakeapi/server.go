@@ -0,0 +1,779 @@
+// Package fakeapi provides an in-process fake of the Realm Admin API that keeps apps, drafts, deployments,
+// hosting assets, secrets, and dependencies in memory, so that commands can be tested end-to-end by
+// pointing them at it with --base-url.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/10gen/realm-cli/auth"
+	"github.com/10gen/realm-cli/hosting"
+	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/secrets"
+	u "github.com/10gen/realm-cli/utils/test"
+)
+
+const adminBaseURL = "/api/admin/v3.0"
+
+// hostingPathPrefix is where the Server serves the contents of uploaded hosting assets
+const hostingPathPrefix = "/hosting"
+
+// App is the state the Server keeps for a Realm app
+type App struct {
+	models.App
+
+	// Atlas is set for apps that are only listed with the "product=atlas" query
+	Atlas bool
+
+	// Config is the app configuration that was last deployed
+	Config map[string]interface{}
+
+	Draft       *models.AppDraft
+	DraftConfig map[string]interface{}
+
+	Deployments []*models.Deployment
+
+	Assets             map[string]*Asset
+	CacheInvalidations []string
+
+	Secrets []secrets.Secret
+
+	DependenciesFilename string
+	Dependencies         []byte
+}
+
+// Asset is a hosting asset uploaded to an App
+type Asset struct {
+	hosting.AssetMetadata
+	Body []byte
+}
+
+// DeploymentErrorMessage is the error message of the deployments failed by FailDeployments
+const DeploymentErrorMessage = "error validating app: function 'broken' is not valid"
+
+// Server is an httptest.Server that fakes the Realm Admin API
+type Server struct {
+	*httptest.Server
+
+	// DeploymentPolls is the number of times a deployment is reported as pending before it succeeds
+	DeploymentPolls int
+
+	// FailDeployments makes every deployment fail with DeploymentErrorMessage instead of succeed
+	FailDeployments bool
+
+	// DeploymentListLag is the number of times a new deployment is left out of the deployment history before it
+	// is listed, as it is while the server is catching up
+	DeploymentListLag int
+
+	mu       sync.Mutex
+	groups   map[string][]*App
+	nextID   int
+	pending  map[string]int
+	unlisted map[string]int
+
+	// deployed holds the app configuration each successful deployment deployed
+	deployed map[string]map[string]interface{}
+}
+
+// NewServer starts and returns a new Server without any apps. Callers should Close it when done
+func NewServer() *Server {
+	s := &Server{
+		groups:   map[string][]*App{},
+		pending:  map[string]int{},
+		unlisted: map[string]int{},
+		deployed: map[string]map[string]interface{}{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// AddGroup adds an empty project that the logged in user is a member of
+func (s *Server) AddGroup(groupID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[groupID]; !ok {
+		s.groups[groupID] = []*App{}
+	}
+}
+
+// AddApp adds an app with an empty configuration to the project with the provided group ID
+func (s *Server) AddApp(groupID, clientAppID, name string) *App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.newApp(groupID, name)
+	app.ClientAppID = clientAppID
+	app.Config["name"] = name
+
+	return app
+}
+
+// App returns the app with the provided client app ID, or nil if it does not exist
+func (s *Server) App(clientAppID string) *App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, apps := range s.groups {
+		for _, app := range apps {
+			if app.ClientAppID == clientAppID {
+				return app
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) newApp(groupID, name string) *App {
+	app := &App{
+		App: models.App{
+			ID:          s.newID(),
+			GroupID:     groupID,
+			ClientAppID: fmt.Sprintf("%s-%s", name, strings.ToLower(s.newID()[18:])),
+			Name:        name,
+		},
+		Config: map[string]interface{}{},
+		Assets: map[string]*Asset{},
+	}
+
+	s.groups[groupID] = append(s.groups[groupID], app)
+
+	return app
+}
+
+// newID returns a new unique ObjectID hex string
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("5f0000000000000000%06x", s.nextID)
+}
+
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	handler func(s *Server, w http.ResponseWriter, r *http.Request, app *App, params []string)
+	noApp   bool
+}
+
+func newRoute(method, pattern string, handler func(s *Server, w http.ResponseWriter, r *http.Request, app *App, params []string)) route {
+	return route{
+		method:  method,
+		pattern: regexp.MustCompile("^" + adminBaseURL + strings.Replace(pattern, "{app}", "/groups/([^/]+)/apps/([^/]+)", 1) + "$"),
+		handler: handler,
+	}
+}
+
+func newAppLessRoute(method, pattern string, handler func(s *Server, w http.ResponseWriter, r *http.Request, app *App, params []string)) route {
+	rt := newRoute(method, pattern, handler)
+	rt.noApp = true
+	return rt
+}
+
+var routes = []route{
+	newAppLessRoute(http.MethodPost, "/auth/providers/([^/]+)/login", (*Server).login),
+	newAppLessRoute(http.MethodPost, "/auth/session", (*Server).refreshSession),
+	newAppLessRoute(http.MethodGet, "/auth/profile", (*Server).profile),
+	newAppLessRoute(http.MethodGet, "/groups/([^/]+)/apps", (*Server).listApps),
+	newAppLessRoute(http.MethodPost, "/groups/([^/]+)/apps", (*Server).createApp),
+
+	newRoute(http.MethodPost, "{app}/import", (*Server).importApp),
+	newRoute(http.MethodGet, "{app}/export", (*Server).exportApp),
+
+	newRoute(http.MethodGet, "{app}/drafts", (*Server).listDrafts),
+	newRoute(http.MethodPost, "{app}/drafts", (*Server).createDraft),
+	newRoute(http.MethodDelete, "{app}/drafts/([^/]+)", (*Server).discardDraft),
+	newRoute(http.MethodGet, "{app}/drafts/([^/]+)/diff", (*Server).diffDraft),
+	newRoute(http.MethodPost, "{app}/drafts/([^/]+)/deployment", (*Server).deployDraft),
+	newRoute(http.MethodGet, "{app}/deployments", (*Server).listDeployments),
+	newRoute(http.MethodGet, "{app}/deployments/([^/]+)", (*Server).getDeployment),
+	newRoute(http.MethodPost, "{app}/deployments/([^/]+)/redeploy", (*Server).redeploy),
+
+	newRoute(http.MethodGet, "{app}/hosting/assets", (*Server).listAssets),
+	newRoute(http.MethodPost, "{app}/hosting/assets", (*Server).copyOrMoveAsset),
+	newRoute(http.MethodPut, "{app}/hosting/assets/asset", (*Server).uploadAsset),
+	newRoute(http.MethodPatch, "{app}/hosting/assets/asset", (*Server).setAssetAttributes),
+	newRoute(http.MethodDelete, "{app}/hosting/assets/asset", (*Server).deleteAsset),
+	newRoute(http.MethodPut, "{app}/hosting/cache", (*Server).invalidateCache),
+
+	newRoute(http.MethodGet, "{app}/secrets", (*Server).listSecrets),
+	newRoute(http.MethodPost, "{app}/secrets", (*Server).addSecret),
+	newRoute(http.MethodPut, "{app}/secrets/([^/]+)", (*Server).updateSecret),
+	newRoute(http.MethodDelete, "{app}/secrets/([^/]+)", (*Server).removeSecret),
+
+	newRoute(http.MethodPost, "{app}/dependencies", (*Server).uploadDependencies),
+	newRoute(http.MethodGet, "{app}/dependencies/archive", (*Server).exportDependencies),
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, hostingPathPrefix+"/") {
+		s.serveAsset(w, r)
+		return
+	}
+
+	for _, rt := range routes {
+		matches := rt.pattern.FindStringSubmatch(r.URL.Path)
+		if matches == nil || rt.method != r.Method {
+			continue
+		}
+
+		if !strings.HasSuffix(r.URL.Path, "/login") && !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			writeError(w, http.StatusUnauthorized, "InvalidSession", "invalid session")
+			return
+		}
+
+		params := matches[1:]
+		if rt.noApp {
+			rt.handler(s, w, r, nil, params)
+			return
+		}
+
+		app := s.findApp(params[0], params[1])
+		if app == nil {
+			writeError(w, http.StatusNotFound, "AppNotFound", "app not found")
+			return
+		}
+
+		rt.handler(s, w, r, app, params[2:])
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "", fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+}
+
+func (s *Server) findApp(groupID, appID string) *App {
+	for _, app := range s.groups[groupID] {
+		if app.ID == appID {
+			return app
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request, _ *App, params []string) {
+	var payload map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	if payload["username"] == "" {
+		writeError(w, http.StatusUnauthorized, "InvalidSession", "invalid username/password")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, auth.Response{
+		AccessToken:  u.GenerateValidAccessToken(),
+		RefreshToken: "fake.refresh.token",
+	})
+}
+
+func (s *Server) refreshSession(w http.ResponseWriter, r *http.Request, _ *App, _ []string) {
+	writeJSON(w, http.StatusCreated, auth.Response{AccessToken: u.GenerateValidAccessToken()})
+}
+
+func (s *Server) profile(w http.ResponseWriter, r *http.Request, _ *App, _ []string) {
+	groupIDs := make([]string, 0, len(s.groups))
+	for groupID := range s.groups {
+		groupIDs = append(groupIDs, groupID)
+	}
+	sort.Strings(groupIDs)
+
+	roles := make([]map[string]string, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		roles = append(roles, map[string]string{"role_name": "GROUP_OWNER", "group_id": groupID})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"roles": roles})
+}
+
+func (s *Server) listApps(w http.ResponseWriter, r *http.Request, _ *App, params []string) {
+	groupApps, ok := s.groups[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "GroupNotFound", "group not found")
+		return
+	}
+
+	atlas := r.URL.Query().Get("product") == "atlas"
+
+	apps := []models.App{}
+	for _, app := range groupApps {
+		if app.Atlas == atlas {
+			apps = append(apps, app.App)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, apps)
+}
+
+func (s *Server) createApp(w http.ResponseWriter, r *http.Request, _ *App, params []string) {
+	var payload struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Name == "" {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "an app name is required")
+		return
+	}
+
+	app := s.newApp(params[0], payload.Name)
+	app.Config["name"] = payload.Name
+
+	writeJSON(w, http.StatusCreated, app.App)
+}
+
+func (s *Server) importApp(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	var config map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("failed to parse app: %s", err))
+		return
+	}
+
+	if r.URL.Query().Get("diff") == "true" {
+		writeJSON(w, http.StatusOK, diffConfigs(app.Config, config))
+		return
+	}
+
+	if app.Draft == nil {
+		app.Config = config
+	} else {
+		app.DraftConfig = config
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listDrafts(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	drafts := []models.AppDraft{}
+	if app.Draft != nil {
+		drafts = append(drafts, *app.Draft)
+	}
+
+	writeJSON(w, http.StatusOK, drafts)
+}
+
+func (s *Server) createDraft(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	if app.Draft != nil {
+		writeError(w, http.StatusBadRequest, "DraftAlreadyExists", "a draft already exists for this app")
+		return
+	}
+
+	app.Draft = &models.AppDraft{ID: s.newID()}
+	app.DraftConfig = app.Config
+
+	writeJSON(w, http.StatusCreated, app.Draft)
+}
+
+func (s *Server) discardDraft(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	if app.Draft == nil || app.Draft.ID != params[0] {
+		writeError(w, http.StatusNotFound, "DraftNotFound", "draft not found")
+		return
+	}
+
+	app.Draft = nil
+	app.DraftConfig = nil
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) diffDraft(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	if app.Draft == nil || app.Draft.ID != params[0] {
+		writeError(w, http.StatusNotFound, "DraftNotFound", "draft not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.DraftDiff{Diffs: diffConfigs(app.Config, app.DraftConfig)})
+}
+
+func (s *Server) deployDraft(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	if app.Draft == nil || app.Draft.ID != params[0] {
+		writeError(w, http.StatusNotFound, "DraftNotFound", "draft not found")
+		return
+	}
+
+	deployment := &models.Deployment{
+		ID:         s.newID(),
+		AppID:      app.ID,
+		DraftID:    app.Draft.ID,
+		DeployedAt: time.Now().Unix(),
+		Origin:     "Admin API",
+		Status:     models.DeploymentStatusCreated,
+	}
+	app.Deployments = append(app.Deployments, deployment)
+
+	if s.FailDeployments {
+		deployment.Status = models.DeploymentStatusFailed
+		deployment.StatusErrorMessage = DeploymentErrorMessage
+	} else {
+		s.deploy(app, deployment, app.DraftConfig)
+	}
+
+	app.Draft = nil
+	app.DraftConfig = nil
+
+	writeJSON(w, http.StatusCreated, deployment)
+}
+
+// deploy makes the config the app's deployed config, reporting the deployment as pending for DeploymentPolls polls
+func (s *Server) deploy(app *App, deployment *models.Deployment, config map[string]interface{}) {
+	app.Config = config
+	s.deployed[deployment.ID] = config
+	s.pending[deployment.ID] = s.DeploymentPolls
+	s.unlisted[deployment.ID] = s.DeploymentListLag
+	if s.DeploymentPolls == 0 {
+		deployment.Status = models.DeploymentStatusSuccessful
+	}
+}
+
+func (s *Server) redeploy(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	config, ok := s.deployed[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "DeploymentNotFound", "deployment not found")
+		return
+	}
+
+	deployment := &models.Deployment{
+		ID:         s.newID(),
+		AppID:      app.ID,
+		DeployedAt: time.Now().Unix(),
+		Origin:     "Admin API",
+		Status:     models.DeploymentStatusCreated,
+	}
+	app.Deployments = append(app.Deployments, deployment)
+	s.deploy(app, deployment, config)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listDeployments(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	deployments := make([]*models.Deployment, 0, len(app.Deployments))
+	for i := len(app.Deployments) - 1; i >= 0; i-- {
+		if id := app.Deployments[i].ID; s.unlisted[id] > 0 {
+			s.unlisted[id]--
+			continue
+		}
+		deployments = append(deployments, app.Deployments[i])
+	}
+
+	writeJSON(w, http.StatusOK, deployments)
+}
+
+func (s *Server) getDeployment(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	for _, deployment := range app.Deployments {
+		if deployment.ID != params[0] {
+			continue
+		}
+
+		if deployment.Status == models.DeploymentStatusCreated || deployment.Status == models.DeploymentStatusPending {
+			if s.pending[deployment.ID] > 0 {
+				s.pending[deployment.ID]--
+				deployment.Status = models.DeploymentStatusPending
+			} else {
+				deployment.Status = models.DeploymentStatusSuccessful
+			}
+		}
+
+		writeJSON(w, http.StatusOK, deployment)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "DeploymentNotFound", "deployment not found")
+}
+
+func (s *Server) listAssets(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	paths := make([]string, 0, len(app.Assets))
+	for path := range app.Assets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	assets := make([]hosting.AssetMetadata, 0, len(paths))
+	for _, path := range paths {
+		asset := app.Assets[path].AssetMetadata
+		asset.URL = s.URL + hostingPathPrefix + "/" + app.ID + path
+		assets = append(assets, asset)
+	}
+
+	writeJSON(w, http.StatusOK, assets)
+}
+
+func (s *Server) serveAsset(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, hostingPathPrefix+"/"), "/", 2)
+
+	for _, apps := range s.groups {
+		for _, app := range apps {
+			if app.ID != parts[0] || len(parts) != 2 {
+				continue
+			}
+
+			if asset, ok := app.Assets["/"+parts[1]]; ok {
+				w.Write(asset.Body) // nolint: errcheck
+				return
+			}
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	var asset Asset
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "", err.Error())
+			return
+		}
+
+		switch part.FormName() {
+		case "meta":
+			if err := json.Unmarshal(data, &asset.AssetMetadata); err != nil {
+				writeError(w, http.StatusBadRequest, "", err.Error())
+				return
+			}
+		case "file":
+			asset.Body = data
+		}
+	}
+
+	if asset.FilePath == "" {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "an asset path is required")
+		return
+	}
+
+	asset.AppID = ""
+	app.Assets[asset.FilePath] = &asset
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) setAssetAttributes(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	asset, ok := app.Assets[r.URL.Query().Get("path")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "", "asset not found")
+		return
+	}
+
+	var payload struct {
+		Attributes []hosting.AssetAttribute `json:"attributes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	asset.Attrs = payload.Attributes
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteAsset(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	path := r.URL.Query().Get("path")
+	if _, ok := app.Assets[path]; !ok {
+		writeError(w, http.StatusNotFound, "", "asset not found")
+		return
+	}
+
+	delete(app.Assets, path)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) copyOrMoveAsset(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	var payload struct {
+		CopyFrom string `json:"copy_from"`
+		CopyTo   string `json:"copy_to"`
+		MoveFrom string `json:"move_from"`
+		MoveTo   string `json:"move_to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	from, to := payload.CopyFrom, payload.CopyTo
+	if payload.MoveFrom != "" {
+		from, to = payload.MoveFrom, payload.MoveTo
+	}
+
+	asset, ok := app.Assets[from]
+	if !ok {
+		writeError(w, http.StatusNotFound, "", "asset not found")
+		return
+	}
+
+	copied := *asset
+	copied.FilePath = to
+	app.Assets[to] = &copied
+
+	if payload.MoveFrom != "" {
+		delete(app.Assets, from)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) invalidateCache(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	var payload struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	app.CacheInvalidations = append(app.CacheInvalidations, payload.Path)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	listed := make([]secrets.Secret, 0, len(app.Secrets))
+	for _, secret := range app.Secrets {
+		listed = append(listed, secrets.Secret{ID: secret.ID, Name: secret.Name})
+	}
+
+	writeJSON(w, http.StatusOK, listed)
+}
+
+func (s *Server) addSecret(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	var secret secrets.Secret
+	if err := json.NewDecoder(r.Body).Decode(&secret); err != nil || secret.Name == "" {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", "a secret name is required")
+		return
+	}
+
+	for _, existing := range app.Secrets {
+		if existing.Name == secret.Name {
+			writeError(w, http.StatusConflict, "SecretAlreadyExists", fmt.Sprintf("secret already exists with name '%s'", secret.Name))
+			return
+		}
+	}
+
+	secret.ID = s.newID()
+	app.Secrets = append(app.Secrets, secret)
+
+	writeJSON(w, http.StatusCreated, secrets.Secret{ID: secret.ID, Name: secret.Name})
+}
+
+func (s *Server) updateSecret(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	var update secrets.Secret
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	for i, secret := range app.Secrets {
+		if secret.ID == params[0] {
+			app.Secrets[i].Value = update.Value
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "SecretNotFound", "secret not found")
+}
+
+func (s *Server) removeSecret(w http.ResponseWriter, r *http.Request, app *App, params []string) {
+	for i, secret := range app.Secrets {
+		if secret.ID == params[0] {
+			app.Secrets = append(app.Secrets[:i], app.Secrets[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "SecretNotFound", "secret not found")
+}
+
+func (s *Server) uploadDependencies(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidParameter", fmt.Sprintf("failed to read dependencies: %s", err))
+		return
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	app.DependenciesFilename = header.Filename
+	app.Dependencies = data
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) exportDependencies(w http.ResponseWriter, r *http.Request, app *App, _ []string) {
+	if app.Dependencies == nil {
+		writeError(w, http.StatusNotFound, "DependenciesNotFound", "no dependencies have been uploaded")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": app.DependenciesFilename}))
+	w.Write(app.Dependencies) // nolint: errcheck
+}
+
+// diffConfigs returns a line for every top-level field of the app configuration that differs
+func diffConfigs(current, proposed map[string]interface{}) []string {
+	diffs := []string{}
+
+	for field, value := range proposed {
+		existing, ok := current[field]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("+++ %s", field))
+		case !reflect.DeepEqual(existing, value):
+			diffs = append(diffs, fmt.Sprintf("~~~ %s", field))
+		}
+	}
+
+	for field := range current {
+		if _, ok := proposed[field]; !ok {
+			diffs = append(diffs, fmt.Sprintf("--- %s", field))
+		}
+	}
+
+	sort.Strings(diffs)
+
+	return diffs
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data) // nolint: errcheck
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"error": message, "error_code": code})
+}
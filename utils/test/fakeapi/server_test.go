@@ -0,0 +1,183 @@
+package fakeapi_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/10gen/realm-cli/api"
+	"github.com/10gen/realm-cli/models"
+	"github.com/10gen/realm-cli/secrets"
+	"github.com/10gen/realm-cli/user"
+	"github.com/10gen/realm-cli/utils/test/fakeapi"
+
+	u "github.com/10gen/realm-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+const groupID = "5e0000000000000000000001"
+
+func TestServer(t *testing.T) {
+	setup := func() (*fakeapi.Server, *fakeapi.App, api.RealmClient) {
+		server := fakeapi.NewServer()
+		app := server.AddApp(groupID, "my-app-abcde", "my-app")
+
+		authClient := api.NewAuthClient(api.NewClient(server.URL), &user.User{AccessToken: u.GenerateValidAccessToken()})
+
+		return server, app, api.NewRealmClient(authClient)
+	}
+
+	t.Run("should reject requests without an access token", func(t *testing.T) {
+		server, _, _ := setup()
+		defer server.Close()
+
+		_, err := api.NewRealmClient(api.NewClient(server.URL)).FetchAppsByGroupID(groupID)
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+
+	t.Run("should find apps by client app ID", func(t *testing.T) {
+		server, app, realmClient := setup()
+		defer server.Close()
+
+		found, err := realmClient.FetchAppByClientAppID("my-app-abcde")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, *found, gc.ShouldResemble, app.App)
+	})
+
+	t.Run("should import and deploy an app through a draft", func(t *testing.T) {
+		server, app, realmClient := setup()
+		defer server.Close()
+
+		appData := []byte(`{"name":"my-app","values":[{"name":"my-value","value":"hello"}]}`)
+
+		diff, err := realmClient.Diff(groupID, app.ID, appData, "merge")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, diff, gc.ShouldResemble, []string{"+++ values"})
+
+		draft, err := realmClient.CreateDraft(groupID, app.ID)
+		u.So(t, err, gc.ShouldBeNil)
+
+		_, err = realmClient.CreateDraft(groupID, app.ID)
+		u.So(t, err, gc.ShouldNotBeNil)
+
+		u.So(t, realmClient.Import(groupID, app.ID, appData, "merge"), gc.ShouldBeNil)
+
+		draftDiff, err := realmClient.DraftDiff(groupID, app.ID, draft.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, draftDiff.Diffs, gc.ShouldResemble, []string{"+++ values"})
+
+		deployment, err := realmClient.DeployDraft(groupID, app.ID, draft.ID)
+		u.So(t, err, gc.ShouldBeNil)
+
+		deployment, err = realmClient.GetDeployment(groupID, app.ID, deployment.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, deployment.Status, gc.ShouldEqual, models.DeploymentStatusSuccessful)
+
+		drafts, err := realmClient.GetDrafts(groupID, app.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, drafts, gc.ShouldBeEmpty)
+
+		var expected map[string]interface{}
+		u.So(t, json.Unmarshal(appData, &expected), gc.ShouldBeNil)
+		u.So(t, server.App("my-app-abcde").Config, gc.ShouldResemble, expected)
+	})
+
+	t.Run("should report pending deployments until they have been polled enough", func(t *testing.T) {
+		server, app, realmClient := setup()
+		defer server.Close()
+		server.DeploymentPolls = 1
+
+		draft, err := realmClient.CreateDraft(groupID, app.ID)
+		u.So(t, err, gc.ShouldBeNil)
+
+		deployment, err := realmClient.DeployDraft(groupID, app.ID, draft.ID)
+		u.So(t, err, gc.ShouldBeNil)
+
+		deployment, err = realmClient.GetDeployment(groupID, app.ID, deployment.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, deployment.Status, gc.ShouldEqual, models.DeploymentStatusPending)
+
+		deployment, err = realmClient.GetDeployment(groupID, app.ID, deployment.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, deployment.Status, gc.ShouldEqual, models.DeploymentStatusSuccessful)
+	})
+
+	t.Run("should export the deployed app as an app directory", func(t *testing.T) {
+		server, app, realmClient := setup()
+		defer server.Close()
+
+		app.Config = map[string]interface{}{
+			"name":      "my-app",
+			"functions": []interface{}{map[string]interface{}{"config": map[string]interface{}{"name": "hello"}, "source": "exports = () => 'hello';"}},
+		}
+
+		filename, body, err := realmClient.Export(groupID, app.ID, api.ExportStrategyNone)
+		u.So(t, err, gc.ShouldBeNil)
+		defer body.Close()
+
+		u.So(t, filename, gc.ShouldStartWith, "my-app_")
+
+		data, err := ioutil.ReadAll(body)
+		u.So(t, err, gc.ShouldBeNil)
+
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		u.So(t, err, gc.ShouldBeNil)
+
+		var names []string
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		u.So(t, names, gc.ShouldResemble, []string{"config.json", "functions/", "functions/hello/", "functions/hello/config.json", "functions/hello/source.js"})
+	})
+
+	t.Run("should manage secrets", func(t *testing.T) {
+		server, app, realmClient := setup()
+		defer server.Close()
+
+		u.So(t, realmClient.AddSecret(groupID, app.ID, secrets.Secret{Name: "my-secret", Value: "hunter2"}), gc.ShouldBeNil)
+		u.So(t, realmClient.AddSecret(groupID, app.ID, secrets.Secret{Name: "my-secret", Value: "hunter3"}), gc.ShouldNotBeNil)
+
+		u.So(t, realmClient.UpdateSecretByName(groupID, app.ID, "my-secret", "hunter3"), gc.ShouldBeNil)
+		u.So(t, server.App("my-app-abcde").Secrets[0].Value, gc.ShouldEqual, "hunter3")
+
+		listed, err := realmClient.ListSecrets(groupID, app.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, len(listed), gc.ShouldEqual, 1)
+		u.So(t, listed[0].Value, gc.ShouldBeEmpty)
+
+		u.So(t, realmClient.RemoveSecretByName(groupID, app.ID, "my-secret"), gc.ShouldBeNil)
+		u.So(t, realmClient.RemoveSecretByName(groupID, app.ID, "my-secret"), gc.ShouldNotBeNil)
+	})
+
+	t.Run("should manage hosting assets", func(t *testing.T) {
+		server, app, realmClient := setup()
+		defer server.Close()
+
+		u.So(t, realmClient.UploadAsset(groupID, app.ID, "/index.html", "abc", 5, strings.NewReader("hello")), gc.ShouldBeNil)
+		u.So(t, realmClient.CopyAsset(groupID, app.ID, "/index.html", "/copy.html"), gc.ShouldBeNil)
+		u.So(t, realmClient.MoveAsset(groupID, app.ID, "/copy.html", "/moved.html"), gc.ShouldBeNil)
+		u.So(t, realmClient.InvalidateCache(groupID, app.ID, "/*"), gc.ShouldBeNil)
+
+		assets, err := realmClient.ListAssetsForAppID(groupID, app.ID)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, len(assets), gc.ShouldEqual, 2)
+		u.So(t, assets[0].FilePath, gc.ShouldEqual, "/index.html")
+		u.So(t, assets[1].FilePath, gc.ShouldEqual, "/moved.html")
+
+		res, err := http.Get(assets[1].URL)
+		u.So(t, err, gc.ShouldBeNil)
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, string(body), gc.ShouldEqual, "hello")
+
+		u.So(t, realmClient.DeleteAsset(groupID, app.ID, "/index.html"), gc.ShouldBeNil)
+		u.So(t, server.App("my-app-abcde").Assets, gc.ShouldNotContainKey, "/index.html")
+		u.So(t, server.App("my-app-abcde").CacheInvalidations, gc.ShouldResemble, []string{"/*"})
+	})
+}
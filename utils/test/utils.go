@@ -161,26 +161,11 @@ func NewPopulatedDeprecatedStorage(username, apiKey string) *storage.Storage {
 }
 
 // MemoryStrategy is a storage.Strategy that stores data in memory
-type MemoryStrategy struct {
-	data []byte
-}
-
-// Write records the provided data to memory storage
-func (ms *MemoryStrategy) Write(data []byte) error {
-	ms.data = data
-	return nil
-}
-
-// Read reads the data currently stored in memory storage
-func (ms *MemoryStrategy) Read() ([]byte, error) {
-	return ms.data, nil
-}
+type MemoryStrategy = storage.MemoryStrategy
 
 // NewMemoryStrategy returns a new MemoryStrategy
 func NewMemoryStrategy(data []byte) *MemoryStrategy {
-	return &MemoryStrategy{
-		data: data,
-	}
+	return storage.NewMemoryStrategy(data)
 }
 
 // GenerateValidAccessToken generates and returns a valid access token *from the future*
@@ -253,6 +238,16 @@ func (msc *MockRealmClient) ExportDependencies(groupID, appID string) (string, i
 	return "", nil, nil
 }
 
+// ExportDeployment will download a deployment of a Realm app as a .zip
+func (msc *MockRealmClient) ExportDeployment(groupID, appID, deploymentID string) (string, io.ReadCloser, error) {
+	return "", nil, nil
+}
+
+// RedeployDeployment does nothing
+func (msc *MockRealmClient) RedeployDeployment(groupID, appID, deploymentID string) error {
+	return nil
+}
+
 // CreateDraft returns a mock AppDraft
 func (msc *MockRealmClient) CreateDraft(groupID, appID string) (*models.AppDraft, error) {
 	return &models.AppDraft{ID: "draft-id"}, nil
@@ -278,6 +273,11 @@ func (msc *MockRealmClient) GetDeployment(groupID, appID, deploymentID string) (
 	return &models.Deployment{ID: "deployment-id"}, nil
 }
 
+// ListDeployments returns an empty list of Deployments
+func (msc *MockRealmClient) ListDeployments(groupID, appID string) ([]models.Deployment, error) {
+	return []models.Deployment{}, nil
+}
+
 // GetDrafts returns an empty list of AppDrafts
 func (msc *MockRealmClient) GetDrafts(groupID, appID string) ([]models.AppDraft, error) {
 	return []models.AppDraft{}, nil
@@ -160,22 +160,42 @@ func processFile(path string, zipFile *zip.File) error {
 
 // UnmarshalFromDir unmarshals a Realm app from the given directory into a map[string]interface{}
 func UnmarshalFromDir(path string) (map[string]interface{}, error) {
+	return UnmarshalFromDirWithOptions(path, UnmarshalOptions{})
+}
+
+// UnmarshalOptions configures how UnmarshalFromDirWithOptions reads an app directory
+type UnmarshalOptions struct {
+	// OverlayPath is a directory whose JSON files are deep merged over the file at the same relative path in the app
+	// directory. Files only found in the overlay directory are added to the app
+	OverlayPath string
+
+	// LookupVar resolves the ${NAME} placeholders in the string values of the app's JSON files. Placeholders are
+	// left as they are when it is nil
+	LookupVar VarLookup
+
+	// StrictVars fails the unmarshal with an ErrUnresolvedVars when LookupVar has no value for a placeholder
+	StrictVars bool
+}
+
+// UnmarshalFromDirWithOptions unmarshals a Realm app from the given directory into a map[string]interface{}
+func UnmarshalFromDirWithOptions(path string, options UnmarshalOptions) (map[string]interface{}, error) {
+	d := appDir{root: path, overlay: options.OverlayPath, lookupVar: options.LookupVar, unresolved: &[]UnresolvedVar{}}
 	app := map[string]interface{}{}
 
-	if err := readAndUnmarshalJSONInto(filepath.Join(path, appConfigName+jsonExt), &app); err != nil {
+	if err := d.readAndUnmarshalJSONInto(filepath.Join(path, appConfigName+jsonExt), &app); err != nil {
 		return app, err
 	}
 
-	if _, err := os.Stat(filepath.Join(path, secretsName+jsonExt)); err == nil {
+	if d.exists(filepath.Join(path, secretsName+jsonExt)) {
 		var secrets interface{}
-		if err := readAndUnmarshalJSONInto(filepath.Join(path, secretsName+jsonExt), &secrets); err != nil {
+		if err := d.readAndUnmarshalJSONInto(filepath.Join(path, secretsName+jsonExt), &secrets); err != nil {
 			return app, err
 		}
 
 		app[secretsName] = secrets
 	}
 
-	values, err := unmarshalJSONFiles(filepath.Join(path, valuesName), true)
+	values, err := d.unmarshalJSONFiles(filepath.Join(path, valuesName), true)
 	if err != nil {
 		return app, err
 	}
@@ -184,7 +204,7 @@ func UnmarshalFromDir(path string) (map[string]interface{}, error) {
 		app[valuesName] = values
 	}
 
-	authProviders, err := unmarshalJSONFiles(filepath.Join(path, authProvidersName), true)
+	authProviders, err := d.unmarshalJSONFiles(filepath.Join(path, authProvidersName), true)
 	if err != nil {
 		return app, err
 	}
@@ -193,7 +213,7 @@ func UnmarshalFromDir(path string) (map[string]interface{}, error) {
 		app[authProvidersName] = authProviders
 	}
 
-	functions, err := unmarshalFunctionDirectories(filepath.Join(path, FunctionsRoot), true)
+	functions, err := d.unmarshalFunctionDirectories(filepath.Join(path, FunctionsRoot), true)
 	if err != nil {
 		return app, err
 	}
@@ -202,7 +222,7 @@ func UnmarshalFromDir(path string) (map[string]interface{}, error) {
 		app[FunctionsRoot] = functions
 	}
 
-	triggers, err := unmarshalJSONFiles(filepath.Join(path, triggersName), true)
+	triggers, err := d.unmarshalJSONFiles(filepath.Join(path, triggersName), true)
 	if err != nil {
 		return app, err
 	}
@@ -211,25 +231,89 @@ func UnmarshalFromDir(path string) (map[string]interface{}, error) {
 		app[triggersName] = triggers
 	}
 
-	graphQL, err := unmarshalGraphQLDirectories(filepath.Join(path, graphQLName), true)
+	graphQL, err := d.unmarshalGraphQLDirectories(filepath.Join(path, graphQLName), true)
 	if err != nil {
 		return app, err
 	}
 
 	app[graphQLName] = graphQL
 
-	services, err := unmarshalServiceDirectories(filepath.Join(path, servicesName), true)
+	services, err := d.unmarshalServiceDirectories(filepath.Join(path, servicesName), true)
 	if err != nil {
 		return app, err
 	}
 
 	app[servicesName] = services
 
+	if options.StrictVars && len(*d.unresolved) != 0 {
+		return app, ErrUnresolvedVars{Vars: sortUnresolvedVars(*d.unresolved)}
+	}
+
 	return app, nil
 }
 
-func unmarshalJSONFiles(path string, ignoreDirErr bool) ([]interface{}, error) {
-	fileInfos, err := ioutil.ReadDir(path)
+// SourceFile is the source of a function or incoming webhook of an app directory
+type SourceFile struct {
+	// Path is the path of the file, relative to the app directory
+	Path   string
+	Source string
+}
+
+// ReadSourceFiles reads the source of every function and incoming webhook of the app directory, read as
+// UnmarshalFromDirWithOptions reads it
+func ReadSourceFiles(path string, options UnmarshalOptions) ([]SourceFile, error) {
+	d := appDir{root: path, overlay: options.OverlayPath}
+	var sourceFiles []SourceFile
+
+	readSources := func(dir string) error {
+		fileInfos, err := d.readDir(dir)
+		if err != nil {
+			return nil
+		}
+
+		return d.iterDirectories(func(info os.FileInfo, path string) error {
+			if strings.Contains(path, "node_modules") {
+				return nil
+			}
+
+			sourcePath := filepath.Join(path, sourceName+jsExt)
+			if !d.exists(sourcePath) {
+				return nil
+			}
+
+			source, err := d.readFile(sourcePath)
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(d.root, sourcePath)
+			if err != nil {
+				return err
+			}
+
+			sourceFiles = append(sourceFiles, SourceFile{Path: rel, Source: string(source)})
+			return nil
+		}, dir, fileInfos)
+	}
+
+	if err := readSources(filepath.Join(path, FunctionsRoot)); err != nil {
+		return nil, err
+	}
+
+	serviceInfos, err := d.readDir(filepath.Join(path, servicesName))
+	if err != nil {
+		return sourceFiles, nil
+	}
+
+	err = d.iterDirectories(func(info os.FileInfo, path string) error {
+		return readSources(filepath.Join(path, incomingWebhooksName))
+	}, filepath.Join(path, servicesName), serviceInfos)
+
+	return sourceFiles, err
+}
+
+func (d appDir) unmarshalJSONFiles(path string, ignoreDirErr bool) ([]interface{}, error) {
+	fileInfos, err := d.readDir(path)
 	if err != nil && !ignoreDirErr {
 		return []interface{}{}, err
 	}
@@ -242,7 +326,7 @@ func unmarshalJSONFiles(path string, ignoreDirErr bool) ([]interface{}, error) {
 		}
 
 		var f interface{}
-		if err := readAndUnmarshalJSONInto(jsonFilePath, &f); err != nil {
+		if err := d.readAndUnmarshalJSONInto(jsonFilePath, &f); err != nil {
 			return []interface{}{}, err
 		}
 
@@ -252,24 +336,24 @@ func unmarshalJSONFiles(path string, ignoreDirErr bool) ([]interface{}, error) {
 	return files, nil
 }
 
-func unmarshalFunctionDirectories(path string, ignoreDirErr bool) ([]interface{}, error) {
-	fileInfos, err := ioutil.ReadDir(path)
+func (d appDir) unmarshalFunctionDirectories(path string, ignoreDirErr bool) ([]interface{}, error) {
+	fileInfos, err := d.readDir(path)
 	if err != nil && !ignoreDirErr {
 		return []interface{}{}, err
 	}
 	directories := []interface{}{}
 
-	err = iterDirectories(func(info os.FileInfo, path string) error {
+	err = d.iterDirectories(func(info os.FileInfo, path string) error {
 		// we skip over node_modules since we upload that as a single entity
 		if strings.Contains(path, "node_modules") {
 			return nil
 		}
 		var config interface{}
-		if err := readAndUnmarshalJSONInto(filepath.Join(path, configName+jsonExt), &config); err != nil {
+		if err := d.readAndUnmarshalJSONInto(filepath.Join(path, configName+jsonExt), &config); err != nil {
 			return err
 		}
 
-		sourceBytes, err := ioutil.ReadFile(filepath.Join(path, sourceName+jsExt))
+		sourceBytes, err := d.readFile(filepath.Join(path, sourceName+jsExt))
 		if err != nil {
 			return err
 		}
@@ -290,8 +374,8 @@ func unmarshalFunctionDirectories(path string, ignoreDirErr bool) ([]interface{}
 	return directories, nil
 }
 
-func unmarshalGraphQLDirectories(path string, ignoreDirErr bool) (map[string]interface{}, error) {
-	fileInfos, err := ioutil.ReadDir(path)
+func (d appDir) unmarshalGraphQLDirectories(path string, ignoreDirErr bool) (map[string]interface{}, error) {
+	fileInfos, err := d.readDir(path)
 	if err != nil && !ignoreDirErr {
 		return map[string]interface{}{}, err
 	}
@@ -304,21 +388,21 @@ func unmarshalGraphQLDirectories(path string, ignoreDirErr bool) (map[string]int
 	for _, fi := range fileInfos {
 		if fi.Name() == gqlConfigFilename {
 			var config map[string]interface{}
-			if err := readAndUnmarshalJSONInto(filepath.Join(path, fi.Name()), &config); err != nil {
+			if err := d.readAndUnmarshalJSONInto(filepath.Join(path, fi.Name()), &config); err != nil {
 				return map[string]interface{}{}, err
 			}
 			gqlServices[configName] = config
 		}
 	}
-	err = iterDirectories(func(info os.FileInfo, path string) error {
-		gqlSvcFileInfos, err := ioutil.ReadDir(path)
+	err = d.iterDirectories(func(info os.FileInfo, path string) error {
+		gqlSvcFileInfos, err := d.readDir(path)
 		if err != nil {
 			return err
 		}
 
 		for _, fileInfo := range gqlSvcFileInfos {
 			var config map[string]interface{}
-			if err := readAndUnmarshalJSONInto(filepath.Join(path, fileInfo.Name()), &config); err != nil {
+			if err := d.readAndUnmarshalJSONInto(filepath.Join(path, fileInfo.Name()), &config); err != nil {
 				return err
 			}
 
@@ -340,31 +424,31 @@ func unmarshalGraphQLDirectories(path string, ignoreDirErr bool) (map[string]int
 	return gqlServices, nil
 }
 
-func unmarshalServiceDirectories(path string, ignoreDirErr bool) ([]interface{}, error) {
-	fileInfos, err := ioutil.ReadDir(path)
+func (d appDir) unmarshalServiceDirectories(path string, ignoreDirErr bool) ([]interface{}, error) {
+	fileInfos, err := d.readDir(path)
 	if err != nil && !ignoreDirErr {
 		return []interface{}{}, err
 	}
 	services := []interface{}{}
 
-	err = iterDirectories(func(info os.FileInfo, path string) error {
+	err = d.iterDirectories(func(info os.FileInfo, path string) error {
 		svc := map[string]interface{}{}
 
 		var config map[string]interface{}
-		if err := readAndUnmarshalJSONInto(filepath.Join(path, configName+jsonExt), &config); err != nil {
+		if err := d.readAndUnmarshalJSONInto(filepath.Join(path, configName+jsonExt), &config); err != nil {
 			return err
 		}
 
 		svc[configName] = config
 
-		incomingWebhooks, err := unmarshalFunctionDirectories(filepath.Join(path, incomingWebhooksName), true)
+		incomingWebhooks, err := d.unmarshalFunctionDirectories(filepath.Join(path, incomingWebhooksName), true)
 		if err != nil {
 			return err
 		}
 
 		svc[incomingWebhooksName] = incomingWebhooks
 
-		rules, err := unmarshalJSONFiles(filepath.Join(path, rulesName), true)
+		rules, err := d.unmarshalJSONFiles(filepath.Join(path, rulesName), true)
 		if err != nil {
 			return err
 		}
@@ -383,10 +467,10 @@ func unmarshalServiceDirectories(path string, ignoreDirErr bool) ([]interface{},
 	return services, nil
 }
 
-func iterDirectories(iterFn func(info os.FileInfo, path string) error, path string, fileInfos []os.FileInfo) error {
+func (d appDir) iterDirectories(iterFn func(info os.FileInfo, path string) error, path string, fileInfos []os.FileInfo) error {
 	for _, fileInfo := range fileInfos {
 		fileNamePath := filepath.Join(path, fileInfo.Name())
-		if info, err := os.Stat(fileNamePath); !info.IsDir() || err != nil {
+		if !d.isDir(fileNamePath) {
 			continue
 		}
 
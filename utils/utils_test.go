@@ -65,3 +65,124 @@ func TestAppLoadFromDirectory(t *testing.T) {
 		}
 	})
 }
+
+func TestAppLoadFromDirectoryWithOverlay(t *testing.T) {
+	t.Run("should merge the overlay directory over the app directory", func(t *testing.T) {
+		app, err := utils.UnmarshalFromDirWithOptions("../testdata/full_app", utils.UnmarshalOptions{OverlayPath: utils.EnvironmentOverlayDirectory("../testdata/full_app", "staging")})
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, app["name"], gc.ShouldEqual, "full-app")
+		u.So(t, app["config_version"], gc.ShouldEqual, 20200603)
+		u.So(t, app["security"], gc.ShouldResemble, map[string]interface{}{
+			"allowed_request_origins": []interface{}{"https://staging.example.com"},
+		})
+
+		values := app["values"].([]interface{})
+		u.So(t, values, gc.ShouldHaveLength, 3)
+		u.So(t, values[0], gc.ShouldResemble, map[string]interface{}{
+			"id":      "5a1db2f34810c5a045135eed",
+			"name":    "a",
+			"value":   "STAGING",
+			"private": false,
+		})
+		u.So(t, values[2].(map[string]interface{})["name"], gc.ShouldEqual, "c")
+
+		var serviceA map[string]interface{}
+		for _, svc := range app["services"].([]interface{}) {
+			config := svc.(map[string]interface{})["config"].(map[string]interface{})
+			if config["name"] == "service a" {
+				serviceA = config
+			}
+		}
+		u.So(t, serviceA["type"], gc.ShouldEqual, "twilio")
+		u.So(t, serviceA["config"], gc.ShouldResemble, map[string]interface{}{"sid": "staging-sid"})
+
+		functionA := app["functions"].([]interface{})[0].(map[string]interface{})
+		u.So(t, functionA["source"], gc.ShouldContainSubstring, "return x + 2;")
+		u.So(t, app["functions"], gc.ShouldHaveLength, 2)
+	})
+
+	t.Run("should load the app directory unchanged without an overlay directory", func(t *testing.T) {
+		app, err := utils.UnmarshalFromDirWithOptions("../testdata/full_app", utils.UnmarshalOptions{OverlayPath: utils.EnvironmentOverlayDirectory("../testdata/full_app", "prod")})
+		u.So(t, err, gc.ShouldBeNil)
+
+		expected, err := utils.UnmarshalFromDir("../testdata/full_app")
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, app, gc.ShouldResemble, expected)
+	})
+}
+
+func TestMergeJSON(t *testing.T) {
+	base := map[string]interface{}{
+		"name":   "a",
+		"config": map[string]interface{}{"sid": "abc", "region": "us"},
+		"tags":   []interface{}{"x", "y"},
+	}
+	overlay := map[string]interface{}{
+		"config": map[string]interface{}{"sid": "def"},
+		"tags":   []interface{}{"z"},
+	}
+
+	u.So(t, utils.MergeJSON(base, overlay), gc.ShouldResemble, map[string]interface{}{
+		"name":   "a",
+		"config": map[string]interface{}{"sid": "def", "region": "us"},
+		"tags":   []interface{}{"z"},
+	})
+	u.So(t, base["config"], gc.ShouldResemble, map[string]interface{}{"sid": "abc", "region": "us"})
+}
+
+func TestAppLoadFromDirectoryWithVars(t *testing.T) {
+	vars := map[string]string{"APP_SUFFIX": "staging", "APP_HOST": "staging.example.com", "API_HOST": "api.example.com"}
+	lookupVar := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	t.Run("should resolve the placeholders in the string values of the app's JSON files", func(t *testing.T) {
+		app, err := utils.UnmarshalFromDirWithOptions("../testdata/vars_app", utils.UnmarshalOptions{LookupVar: lookupVar, StrictVars: true})
+		u.So(t, err, gc.ShouldBeNil)
+
+		u.So(t, app["name"], gc.ShouldEqual, "vars-app-staging")
+		u.So(t, app["security"], gc.ShouldResemble, map[string]interface{}{
+			"allowed_request_origins": []interface{}{"https://staging.example.com"},
+		})
+
+		value := app["values"].([]interface{})[0].(map[string]interface{})
+		u.So(t, value["value"], gc.ShouldEqual, "https://api.example.com/v1?escaped=${API_HOST}")
+
+		rule := app["services"].([]interface{})[0].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})
+		u.So(t, rule["when"], gc.ShouldResemble, map[string]interface{}{
+			"%%args.url.host": "api.example.com",
+			"%%args.url.path": map[string]interface{}{"$in": []interface{}{"$$ROOT"}},
+		})
+	})
+
+	t.Run("should leave unresolved placeholders as they are", func(t *testing.T) {
+		app, err := utils.UnmarshalFromDirWithOptions("../testdata/vars_app", utils.UnmarshalOptions{LookupVar: func(string) (string, bool) {
+			return "", false
+		}})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, app["name"], gc.ShouldEqual, "vars-app-${APP_SUFFIX}")
+
+		value := app["values"].([]interface{})[0].(map[string]interface{})
+		u.So(t, value["value"], gc.ShouldEqual, "https://${API_HOST}/v1?escaped=${API_HOST}")
+	})
+
+	t.Run("should fail with every unresolved placeholder in strict mode", func(t *testing.T) {
+		_, err := utils.UnmarshalFromDirWithOptions("../testdata/vars_app", utils.UnmarshalOptions{
+			LookupVar: func(name string) (string, bool) {
+				if name == "APP_SUFFIX" {
+					return "prod", true
+				}
+				return "", false
+			},
+			StrictVars: true,
+		})
+		u.So(t, err, gc.ShouldResemble, utils.ErrUnresolvedVars{Vars: []utils.UnresolvedVar{
+			{Path: "config.json", Name: "APP_HOST"},
+			{Path: "services/http_service/rules/upstream.json", Name: "API_HOST"},
+			{Path: "values/api_url.json", Name: "API_HOST"},
+		}})
+		u.So(t, err.Error(), gc.ShouldStartWith, "no value was set for 3 placeholder(s):\n  config.json: ${APP_HOST}\n")
+	})
+}
@@ -0,0 +1,522 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ValidatedConfigVersion is the config_version of the app directories ValidateAppDir knows the schema of
+const ValidatedConfigVersion = 20200603
+
+// ValidationProblem is a problem found in a file of an app directory
+type ValidationProblem struct {
+	// Path is the path of the file, relative to the app directory
+	Path string `json:"path" yaml:"path"`
+	// Pointer is the JSON pointer of the problem within the file, or "" for the whole file
+	Pointer string `json:"pointer,omitempty" yaml:"pointer,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (vp ValidationProblem) String() string {
+	if vp.Pointer == "" {
+		return fmt.Sprintf("%s: %s", vp.Path, vp.Message)
+	}
+	return fmt.Sprintf("%s#%s: %s", vp.Path, vp.Pointer, vp.Message)
+}
+
+type jsonKind string
+
+const (
+	kindString jsonKind = "a string"
+	kindNumber jsonKind = "a number"
+	kindBool   jsonKind = "a boolean"
+	kindObject jsonKind = "an object"
+	kindArray  jsonKind = "an array"
+)
+
+type fieldSchema struct {
+	kind     jsonKind
+	required bool
+}
+
+// configSchema describes the keys a config file may have. Nested objects are only checked for their type, since
+// their keys depend on the type of the entity they configure
+type configSchema map[string]fieldSchema
+
+var (
+	appConfigSchema = configSchema{
+		"app_id":                  {kind: kindString},
+		"config_version":          {kind: kindNumber, required: true},
+		"name":                    {kind: kindString, required: true},
+		"location":                {kind: kindString},
+		"deployment_model":        {kind: kindString},
+		"security":                {kind: kindObject},
+		"hosting":                 {kind: kindObject},
+		"custom_user_data_config": {kind: kindObject},
+		"sync":                    {kind: kindObject},
+	}
+
+	secretsSchema = configSchema{
+		"services":       {kind: kindObject},
+		"auth_providers": {kind: kindObject},
+	}
+
+	valueSchema = configSchema{
+		"_id":         {kind: kindString},
+		"id":          {kind: kindString},
+		"name":        {kind: kindString, required: true},
+		"value":       {required: true},
+		"from_secret": {kind: kindBool},
+		"private":     {kind: kindBool},
+	}
+
+	authProviderSchema = configSchema{
+		"_id":                 {kind: kindString},
+		"id":                  {kind: kindString},
+		"name":                {kind: kindString, required: true},
+		"type":                {kind: kindString, required: true},
+		"disabled":            {kind: kindBool},
+		"config":              {kind: kindObject},
+		"secret_config":       {kind: kindObject},
+		"metadata_fields":     {kind: kindArray},
+		"redirect_uris":       {kind: kindArray},
+		"domain_restrictions": {kind: kindArray},
+	}
+
+	functionSchema = configSchema{
+		"_id":                          {kind: kindString},
+		"id":                           {kind: kindString},
+		"name":                         {kind: kindString, required: true},
+		"private":                      {kind: kindBool},
+		"can_evaluate":                 {kind: kindObject},
+		"disable_arg_logs":             {kind: kindBool},
+		"run_as_system":                {kind: kindBool},
+		"run_as_user_id":               {kind: kindString},
+		"run_as_user_id_script_source": {kind: kindString},
+	}
+
+	triggerSchema = configSchema{
+		"_id":              {kind: kindString},
+		"id":               {kind: kindString},
+		"name":             {kind: kindString, required: true},
+		"type":             {kind: kindString, required: true},
+		"config":           {kind: kindObject, required: true},
+		"function_name":    {kind: kindString},
+		"function_id":      {kind: kindString},
+		"event_processors": {kind: kindObject},
+		"disabled":         {kind: kindBool},
+	}
+
+	serviceSchema = configSchema{
+		"_id":           {kind: kindString},
+		"id":            {kind: kindString},
+		"name":          {kind: kindString, required: true},
+		"type":          {kind: kindString, required: true},
+		"config":        {kind: kindObject},
+		"secret_config": {kind: kindObject},
+		"version":       {kind: kindNumber},
+	}
+
+	ruleSchema = configSchema{
+		"_id":           {kind: kindString},
+		"id":            {kind: kindString},
+		"name":          {kind: kindString},
+		"actions":       {kind: kindArray},
+		"when":          {},
+		"database":      {kind: kindString},
+		"collection":    {kind: kindString},
+		"roles":         {kind: kindArray},
+		"schema":        {kind: kindObject},
+		"filters":       {kind: kindArray},
+		"relationships": {kind: kindObject},
+	}
+
+	incomingWebhookSchema = configSchema{
+		"_id":                          {kind: kindString},
+		"id":                           {kind: kindString},
+		"name":                         {kind: kindString, required: true},
+		"can_evaluate":                 {kind: kindObject},
+		"create_user_on_auth":          {kind: kindBool},
+		"disable_arg_logs":             {kind: kindBool},
+		"fetch_custom_user_data":       {kind: kindBool},
+		"options":                      {kind: kindObject},
+		"respond_result":               {kind: kindBool},
+		"run_as_authed_user":           {kind: kindBool},
+		"run_as_user_id":               {kind: kindString},
+		"run_as_user_id_script_source": {kind: kindString},
+	}
+
+	graphQLConfigSchema = configSchema{
+		"use_natural_pluralization": {kind: kindBool},
+	}
+
+	customResolverSchema = configSchema{
+		"_id":                 {kind: kindString},
+		"id":                  {kind: kindString},
+		"function_name":       {kind: kindString, required: true},
+		"function_id":         {kind: kindString},
+		"on_type":             {kind: kindString, required: true},
+		"field_name":          {kind: kindString, required: true},
+		"input_type":          {},
+		"input_type_format":   {kind: kindString},
+		"payload_type":        {},
+		"payload_type_format": {kind: kindString},
+	}
+
+	// mongoDBServiceTypes are the service types whose rules are defined per namespace instead of by name
+	mongoDBServiceTypes = map[string]bool{"mongodb": true, "mongodb-atlas": true, "mongodb-datalake": true}
+)
+
+// functionReference is a function_name found in a config file
+type functionReference struct {
+	path    string
+	pointer string
+	name    string
+}
+
+// validator collects the problems of an app directory
+type validator struct {
+	d        appDir
+	problems []ValidationProblem
+
+	// names holds the file each name was first seen in, by the kind of entity it names
+	names map[string]map[string]string
+	// ids holds the file each _id was first seen in
+	ids map[string]string
+
+	functions  map[string]bool
+	references []functionReference
+}
+
+// ValidateAppDir checks the app directory, read as UnmarshalFromDirWithOptions reads it, against the schema of
+// config_version 20200603 without contacting Realm, and returns every problem it finds
+func ValidateAppDir(path string, options UnmarshalOptions) ([]ValidationProblem, error) {
+	if _, err := os.Stat(filepath.Join(path, appConfigName+jsonExt)); err != nil {
+		return nil, err
+	}
+
+	v := &validator{
+		d:         appDir{root: path, overlay: options.OverlayPath, lookupVar: options.LookupVar, unresolved: &[]UnresolvedVar{}},
+		names:     map[string]map[string]string{},
+		ids:       map[string]string{},
+		functions: map[string]bool{},
+	}
+
+	if app, ok := v.readConfig(filepath.Join(path, appConfigName+jsonExt), appConfigSchema); ok {
+		if version, ok := app["config_version"].(float64); ok && version != ValidatedConfigVersion {
+			v.report(filepath.Join(path, appConfigName+jsonExt), "/config_version",
+				fmt.Sprintf("config_version %v is not supported; only %d can be validated", version, ValidatedConfigVersion))
+			return v.problems, nil
+		}
+	}
+
+	if v.d.exists(filepath.Join(path, secretsName+jsonExt)) {
+		v.readConfig(filepath.Join(path, secretsName+jsonExt), secretsSchema)
+	}
+
+	v.validateJSONFiles(filepath.Join(path, valuesName), "value", "", valueSchema, nil)
+	v.validateJSONFiles(filepath.Join(path, authProvidersName), "auth provider", "", authProviderSchema, nil)
+	v.validateFunctions(filepath.Join(path, FunctionsRoot))
+	v.validateJSONFiles(filepath.Join(path, triggersName), "trigger", "", triggerSchema, v.validateTrigger)
+	v.validateGraphQL(filepath.Join(path, graphQLName))
+	v.validateServices(filepath.Join(path, servicesName))
+
+	for _, reference := range v.references {
+		if !v.functions[reference.name] {
+			v.report(reference.path, reference.pointer, fmt.Sprintf("function %q does not exist", reference.name))
+		}
+	}
+
+	if options.StrictVars {
+		for _, unresolved := range sortUnresolvedVars(*v.d.unresolved) {
+			v.problems = append(v.problems, ValidationProblem{
+				Path:    unresolved.Path,
+				Message: fmt.Sprintf("no value was set for placeholder ${%s}", unresolved.Name),
+			})
+		}
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Path < v.problems[j].Path
+	})
+
+	return v.problems, nil
+}
+
+func (v *validator) report(path, pointer, message string) {
+	if rel, err := filepath.Rel(v.d.root, path); err == nil {
+		path = rel
+	}
+
+	v.problems = append(v.problems, ValidationProblem{Path: path, Pointer: pointer, Message: message})
+}
+
+// readConfig reads the JSON object of the config file and checks it against the schema
+func (v *validator) readConfig(path string, schema configSchema) (map[string]interface{}, bool) {
+	var value interface{}
+	if err := v.d.readAndUnmarshalJSONInto(path, &value); err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			v.report(path, "", "file is missing")
+		} else {
+			v.report(path, "", strings.TrimPrefix(err.Error(), fmt.Sprintf("failed to parse %s: ", path)))
+		}
+		return nil, false
+	}
+
+	config, ok := value.(map[string]interface{})
+	if !ok {
+		v.report(path, "", "must be a JSON object")
+		return nil, false
+	}
+
+	v.checkSchema(path, config, schema)
+	return config, true
+}
+
+func (v *validator) checkSchema(path string, config map[string]interface{}, schema configSchema) {
+	keys := make([]string, 0, len(schema)+len(config))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	for key := range config {
+		if _, ok := schema[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, known := schema[key]
+		value, set := config[key]
+
+		switch {
+		case !known:
+			v.report(path, jsonPointer(key), fmt.Sprintf("unknown key %q", key))
+		case !set || value == nil:
+			if field.required {
+				v.report(path, jsonPointer(key), "is required")
+			}
+		case field.kind != "" && kindOf(value) != field.kind:
+			v.report(path, jsonPointer(key), fmt.Sprintf("must be %s", field.kind))
+		}
+	}
+
+	if id, ok := config["_id"].(string); ok && id != "" {
+		v.checkUniqueID(path, "/_id", id)
+	}
+	if id, ok := config["id"].(string); ok && id != "" {
+		v.checkUniqueID(path, "/id", id)
+	}
+}
+
+func (v *validator) checkUniqueID(path, pointer, id string) {
+	if other, ok := v.ids[id]; ok {
+		v.report(path, pointer, fmt.Sprintf("_id %q is already used by %s", id, other))
+		return
+	}
+
+	v.ids[id] = v.relativePath(path)
+}
+
+// checkUniqueName checks the name of the entity is not used by another entity of its kind within the same scope,
+// e.g. the webhooks of a service
+func (v *validator) checkUniqueName(path, pointer, kind, scope, name string) {
+	key := kind + "\x00" + scope
+	if v.names[key] == nil {
+		v.names[key] = map[string]string{}
+	}
+
+	if other, ok := v.names[key][name]; ok {
+		v.report(path, pointer, fmt.Sprintf("%s name %q is already used by %s", kind, name, other))
+		return
+	}
+
+	v.names[key][name] = v.relativePath(path)
+}
+
+func (v *validator) relativePath(path string) string {
+	if rel, err := filepath.Rel(v.d.root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// validateJSONFiles checks each JSON file of the directory against the schema, and that their names are unique
+// within the scope
+func (v *validator) validateJSONFiles(dir, kind, scope string, schema configSchema, check func(path string, config map[string]interface{})) {
+	fileInfos, err := v.d.readDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, fileInfo := range fileInfos {
+		path := filepath.Join(dir, fileInfo.Name())
+		if filepath.Ext(path) != jsonExt || v.d.isDir(path) {
+			continue
+		}
+
+		config, ok := v.readConfig(path, schema)
+		if !ok {
+			continue
+		}
+
+		if name, ok := config["name"].(string); ok {
+			v.checkUniqueName(path, "/name", kind, scope, name)
+		}
+
+		if check != nil {
+			check(path, config)
+		}
+	}
+}
+
+func (v *validator) validateFunctions(dir string) {
+	v.iterDirectories(dir, func(path string) {
+		if strings.Contains(path, "node_modules") {
+			return
+		}
+
+		configPath := filepath.Join(path, configName+jsonExt)
+		if config, ok := v.readConfig(configPath, functionSchema); ok {
+			if name, ok := config["name"].(string); ok {
+				v.checkUniqueName(configPath, "/name", "function", "", name)
+				v.functions[name] = true
+			}
+		}
+
+		v.checkSourceExists(path)
+	})
+}
+
+func (v *validator) validateTrigger(path string, config map[string]interface{}) {
+	name, hasFunction := config["function_name"].(string)
+	_, hasEventProcessors := config["event_processors"].(map[string]interface{})
+
+	switch {
+	case hasFunction && name != "":
+		v.references = append(v.references, functionReference{path: path, pointer: "/function_name", name: name})
+	case !hasEventProcessors:
+		v.report(path, "/function_name", "is required unless event_processors is set")
+	}
+
+	triggerConfig, ok := config["config"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	var required []string
+	switch config["type"] {
+	case "DATABASE":
+		required = []string{"service_name", "database", "collection", "operation_types"}
+	case "SCHEDULED":
+		required = []string{"schedule"}
+	}
+
+	for _, key := range required {
+		if value, ok := triggerConfig[key]; !ok || value == nil {
+			v.report(path, "/config/"+jsonPointer(key)[1:], fmt.Sprintf("is required for %s triggers", config["type"]))
+		}
+	}
+}
+
+func (v *validator) validateGraphQL(dir string) {
+	if v.d.exists(filepath.Join(dir, configName+jsonExt)) {
+		v.readConfig(filepath.Join(dir, configName+jsonExt), graphQLConfigSchema)
+	}
+
+	v.validateJSONFiles(filepath.Join(dir, customResolversName), "custom resolver", "", customResolverSchema, func(path string, config map[string]interface{}) {
+		if name, ok := config["function_name"].(string); ok && name != "" {
+			v.references = append(v.references, functionReference{path: path, pointer: "/function_name", name: name})
+		}
+
+		onType, _ := config["on_type"].(string)
+		fieldName, _ := config["field_name"].(string)
+		if onType != "" && fieldName != "" {
+			v.checkUniqueName(path, "/field_name", "custom resolver field", "", onType+"."+fieldName)
+		}
+	})
+}
+
+func (v *validator) validateServices(dir string) {
+	v.iterDirectories(dir, func(path string) {
+		configPath := filepath.Join(path, configName+jsonExt)
+		config, ok := v.readConfig(configPath, serviceSchema)
+
+		var serviceName, serviceType string
+		if ok {
+			serviceName, _ = config["name"].(string)
+			serviceType, _ = config["type"].(string)
+			v.checkUniqueName(configPath, "/name", "service", "", serviceName)
+		}
+
+		v.validateJSONFiles(filepath.Join(path, rulesName), "rule", serviceName, ruleSchema, func(rulePath string, rule map[string]interface{}) {
+			required := []string{"name"}
+			if mongoDBServiceTypes[serviceType] {
+				required = []string{"database", "collection"}
+			}
+
+			for _, key := range required {
+				if value, ok := rule[key]; !ok || value == nil {
+					v.report(rulePath, jsonPointer(key), fmt.Sprintf("is required for the rules of a %s service", serviceType))
+				}
+			}
+		})
+
+		v.iterDirectories(filepath.Join(path, incomingWebhooksName), func(webhookPath string) {
+			webhookConfigPath := filepath.Join(webhookPath, configName+jsonExt)
+			if webhook, ok := v.readConfig(webhookConfigPath, incomingWebhookSchema); ok {
+				if name, ok := webhook["name"].(string); ok {
+					v.checkUniqueName(webhookConfigPath, "/name", "webhook", serviceName, name)
+				}
+			}
+
+			v.checkSourceExists(webhookPath)
+		})
+	})
+}
+
+// checkSourceExists checks the directory of a function or webhook has the source of its function
+func (v *validator) checkSourceExists(dir string) {
+	if path := filepath.Join(dir, sourceName+jsExt); !v.d.exists(path) {
+		v.report(path, "", "function source is missing")
+	}
+}
+
+func (v *validator) iterDirectories(dir string, iterFn func(path string)) {
+	fileInfos, err := v.d.readDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, fileInfo := range fileInfos {
+		if path := filepath.Join(dir, fileInfo.Name()); v.d.isDir(path) {
+			iterFn(path)
+		}
+	}
+}
+
+func kindOf(value interface{}) jsonKind {
+	switch value.(type) {
+	case string:
+		return kindString
+	case float64:
+		return kindNumber
+	case bool:
+		return kindBool
+	case map[string]interface{}:
+		return kindObject
+	case []interface{}:
+		return kindArray
+	}
+	return ""
+}
+
+// jsonPointer returns the JSON pointer of the key of the root object
+func jsonPointer(key string) string {
+	return "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
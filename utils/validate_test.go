@@ -0,0 +1,53 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/10gen/realm-cli/utils"
+	u "github.com/10gen/realm-cli/utils/test"
+	gc "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateAppDir(t *testing.T) {
+	t.Run("should find no problems in a valid app", func(t *testing.T) {
+		for _, appPath := range []string{"../testdata/simple_app_with_cluster", "../testdata/vars_app"} {
+			problems, err := utils.ValidateAppDir(appPath, utils.UnmarshalOptions{})
+			u.So(t, err, gc.ShouldBeNil)
+			u.So(t, problems, gc.ShouldBeEmpty)
+		}
+	})
+
+	t.Run("should report every problem with its file and JSON pointer", func(t *testing.T) {
+		problems, err := utils.ValidateAppDir("../testdata/invalid_app", utils.UnmarshalOptions{})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, problems, gc.ShouldResemble, []utils.ValidationProblem{
+			{Path: "auth_providers/api-key.json", Message: "unexpected end of JSON input"},
+			{Path: "config.json", Pointer: "/name", Message: "is required"},
+			{Path: "config.json", Pointer: "/nmae", Message: `unknown key "nmae"`},
+			{Path: "functions/fn_b/config.json", Pointer: "/_id", Message: `_id "5f0000000000000000000001" is already used by functions/fn_a/config.json`},
+			{Path: "functions/fn_b/config.json", Pointer: "/name", Message: `function name "fn_a" is already used by functions/fn_a/config.json`},
+			{Path: "functions/fn_b/source.js", Message: "function source is missing"},
+			{Path: "services/http/incoming_webhooks/hook/config.json", Pointer: "/run_as_system", Message: `unknown key "run_as_system"`},
+			{Path: "triggers/on_insert.json", Pointer: "/config/collection", Message: "is required for DATABASE triggers"},
+			{Path: "triggers/on_insert.json", Pointer: "/function_name", Message: `function "fn_missing" does not exist`},
+			{Path: "values/flag.json", Pointer: "/private", Message: "must be a boolean"},
+			{Path: "values/flag.json", Pointer: "/value", Message: "is required"},
+		})
+		u.So(t, problems[2].String(), gc.ShouldEqual, `config.json#/nmae: unknown key "nmae"`)
+	})
+
+	t.Run("should report unresolved placeholders in strict mode", func(t *testing.T) {
+		problems, err := utils.ValidateAppDir("../testdata/vars_app", utils.UnmarshalOptions{
+			LookupVar:  func(string) (string, bool) { return "", false },
+			StrictVars: true,
+		})
+		u.So(t, err, gc.ShouldBeNil)
+		u.So(t, problems, gc.ShouldHaveLength, 4)
+		u.So(t, problems[0], gc.ShouldResemble, utils.ValidationProblem{Path: "config.json", Message: "no value was set for placeholder ${APP_HOST}"})
+	})
+
+	t.Run("should fail without an app config", func(t *testing.T) {
+		_, err := utils.ValidateAppDir("../testdata/cassettes", utils.UnmarshalOptions{})
+		u.So(t, err, gc.ShouldNotBeNil)
+	})
+}
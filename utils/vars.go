@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderPattern matches ${NAME} placeholders, along with $${NAME}, which escapes a literal ${NAME}
+var placeholderPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// VarLookup returns the value of the named variable and whether it is set
+type VarLookup func(name string) (string, bool)
+
+// UnresolvedVar is a placeholder in an app file that no variable was set for
+type UnresolvedVar struct {
+	Path string
+	Name string
+}
+
+// ErrUnresolvedVars is used when placeholders are left unresolved while loading an app in strict mode
+type ErrUnresolvedVars struct {
+	Vars []UnresolvedVar
+}
+
+func (euv ErrUnresolvedVars) Error() string {
+	lines := make([]string, 0, len(euv.Vars))
+	for _, unresolved := range euv.Vars {
+		lines = append(lines, fmt.Sprintf("  %s: ${%s}", unresolved.Path, unresolved.Name))
+	}
+
+	return fmt.Sprintf("no value was set for %d placeholder(s):\n%s", len(euv.Vars), strings.Join(lines, "\n"))
+}
+
+// interpolateVars replaces the placeholders in every string of the JSON value, calling unresolved with the name of
+// each placeholder the lookup has no value for, which is left as it is
+func interpolateVars(value interface{}, lookup VarLookup, unresolved func(name string)) interface{} {
+	switch v := value.(type) {
+	case string:
+		return placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			if strings.HasPrefix(placeholder, "$$") {
+				return placeholder[1:]
+			}
+
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			if resolved, ok := lookup(name); ok {
+				return resolved
+			}
+
+			unresolved(name)
+			return placeholder
+		})
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = interpolateVars(item, lookup, unresolved)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = interpolateVars(item, lookup, unresolved)
+		}
+	}
+
+	return value
+}
+
+// sortUnresolvedVars sorts the unresolved placeholders by path, then name, removing duplicates
+func sortUnresolvedVars(vars []UnresolvedVar) []UnresolvedVar {
+	sort.Slice(vars, func(i, j int) bool {
+		if vars[i].Path != vars[j].Path {
+			return vars[i].Path < vars[j].Path
+		}
+		return vars[i].Name < vars[j].Name
+	})
+
+	deduplicated := vars[:0]
+	for i, unresolved := range vars {
+		if i == 0 || unresolved != vars[i-1] {
+			deduplicated = append(deduplicated, unresolved)
+		}
+	}
+
+	return deduplicated
+}